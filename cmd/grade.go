@@ -0,0 +1,270 @@
+package main
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/params"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/container"
+	"awesomeProject/pkg/db"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// grade 命令的退出码
+const (
+	gradeExitPassed = 0
+	gradeExitFailed = 1
+	gradeExitError  = 2
+)
+
+var (
+	gradeTemplateID  uint
+	gradeSectionID   uint
+	gradeUserID      uint
+	gradeScripts     []string
+	gradeSetup       []string
+	gradeTeardown    []string
+	gradeMatchType   string
+	gradeExpected    string
+	gradeTimeout     uint
+	gradeParallel    bool
+	gradeConcurrency uint
+	gradeKeepRunning bool
+)
+
+var gradeCmd = &cobra.Command{
+	Use:   "grade",
+	Short: "Run check scripts against a throwaway container",
+	Long: `Start a throwaway container from a template, run check scripts in it and print a per-check report.
+
+Scripts are either read from local files (--script, --setup, --teardown) or loaded from the
+database for a section (--section). Section parameters are derived for --user and injected
+into the container and the scripts like they are for that student. Checks marked as parallel run
+concurrently up to the check concurrency of the template or --concurrency, results are printed in order.
+The command exits with 0 when every check passes,
+1 when a check fails and 2 when the checks could not be run.`,
+	Example: `  ttds grade --template 3 --script check_hello.sh --expect "hello" --match contains
+  ttds grade --section 12 --user 42`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(runGrade())
+	},
+}
+
+func init() {
+	gradeCmd.Flags().UintVar(&gradeTemplateID, "template", 0, "Template ID, defaults to the template of --section")
+	gradeCmd.Flags().UintVar(&gradeSectionID, "section", 0, "Section ID, run the check scripts stored for the section")
+	gradeCmd.Flags().UintVar(&gradeUserID, "user", 0, "User ID used to derive the section parameters")
+	gradeCmd.Flags().StringArrayVar(&gradeScripts, "script", nil, "Check script file, can be repeated")
+	gradeCmd.Flags().StringArrayVar(&gradeSetup, "setup", nil, "Setup script file, can be repeated")
+	gradeCmd.Flags().StringArrayVar(&gradeTeardown, "teardown", nil, "Teardown script file, can be repeated")
+	gradeCmd.Flags().StringVar(&gradeMatchType, "match", "contains", "Match type of --script files: contains / equals / regex")
+	gradeCmd.Flags().StringVar(&gradeExpected, "expect", "", "Expected output of --script files")
+	gradeCmd.Flags().UintVar(&gradeTimeout, "timeout", 10, "Timeout of each script file in seconds")
+	gradeCmd.Flags().BoolVar(&gradeParallel, "parallel", false, "Allow --script files to run concurrently")
+	gradeCmd.Flags().UintVar(&gradeConcurrency, "concurrency", 0, "Maximum number of parallel checks, defaults to the limit of the template")
+	gradeCmd.Flags().BoolVar(&gradeKeepRunning, "keep", false, "Keep the container after grading for debugging")
+	rootCmd.AddCommand(gradeCmd)
+}
+
+// runGrade 执行检测并返回退出码，使用返回值而不是直接退出，保证容器能被清理
+func runGrade() int {
+	template, scripts, err := loadGradeScripts()
+	if err != nil {
+		logrus.Errorf("failed to load scripts: %v", err)
+		return gradeExitError
+	}
+	if len(scripts) == 0 {
+		logrus.Errorf("no scripts to run, use --script or --section")
+		return gradeExitError
+	}
+
+	if template.SecurityProfile != "" {
+		template.Profile, err = repository.NewSecurityProfileRepository(db.DB).GetProfileByName(template.SecurityProfile)
+		if err != nil {
+			logrus.Errorf("security profile %s of template %d not found: %v", template.SecurityProfile, template.ID, err)
+			return gradeExitError
+		}
+	}
+
+	manager := container.NewManager()
+	instance, err := manager.CreateContainer(template)
+	if err != nil {
+		logrus.Errorf("failed to create container: %v", err)
+		return gradeExitError
+	}
+	defer func() {
+		if gradeKeepRunning {
+			logrus.Infof("container %s is kept for debugging", instance.Name)
+			return
+		}
+		if err := manager.RemoveContainer(instance); err != nil {
+			logrus.Warnf("failed to remove container %s: %v", instance.Name, err)
+		}
+	}()
+
+	if err := manager.StartContainer(instance); err != nil {
+		logrus.Errorf("failed to start container: %v", err)
+		return gradeExitError
+	}
+
+	// isolated 模式和线上一样在独立的检测容器中执行
+	target := instance
+	if template.GradeMode == model.GradeIsolated {
+		target, err = manager.CreateGraderContainer(instance, template)
+		if err != nil {
+			logrus.Errorf("failed to create grader container: %v", err)
+			return gradeExitError
+		}
+		defer func() {
+			if err := manager.RemoveContainer(target); err != nil {
+				logrus.Warnf("failed to remove grader container %s: %v", target.Name, err)
+			}
+		}()
+	}
+
+	concurrency := template.CheckConcurrency
+	if gradeConcurrency != 0 {
+		concurrency = gradeConcurrency
+	}
+	results, err := grader.NewRunner(manager).RunConcurrent(target, scripts, concurrency, grader.Hooks{
+		OnResult: func(_ int, result *model.CheckResult) {
+			printCheckResult(result)
+		},
+	})
+	if err != nil {
+		fmt.Printf("\nABORTED  %v\n", err)
+		var setupErr *grader.SetupError
+		if errors.As(err, &setupErr) && !setupErr.Infrastructure() {
+			if setupErr.Output != "" {
+				fmt.Println(indent(setupErr.Output))
+			}
+			return gradeExitFailed
+		}
+		return gradeExitError
+	}
+
+	summary := grader.Summarize(results)
+	fmt.Printf("\n%s  passed %d/%d, score %g/%g (%.1f%%)\n",
+		strings.ToUpper(summary.Status), summary.Passed, summary.Total, summary.Score, summary.MaxScore, summary.Percentage)
+
+	switch summary.Status {
+	case model.SubmissionPassed:
+		return gradeExitPassed
+	case model.SubmissionFailed:
+		return gradeExitFailed
+	default:
+		return gradeExitError
+	}
+}
+
+// loadGradeScripts 获取模板和要执行的脚本，指定小节时使用数据库中保存的脚本，再追加本地的脚本文件
+func loadGradeScripts() (*model.ContainerTemplate, []model.ContainerScript, error) {
+	templateID := gradeTemplateID
+	var scripts []model.ContainerScript
+	var section *model.Section
+
+	if gradeSectionID != 0 {
+		var err error
+		section, err = repository.NewSectionRepository(db.DB).GetSectionByID(gradeSectionID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("section %d: %v", gradeSectionID, err)
+		}
+		if templateID == 0 {
+			templateID = section.TemplateID
+		}
+
+		stored, err := repository.NewContainerScript(db.DB).GetScriptsBySectionID(section.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, script := range stored {
+			scripts = append(scripts, *script)
+		}
+	}
+	if templateID == 0 {
+		return nil, nil, errors.New("--template or --section is required")
+	}
+
+	template, err := repository.NewTemplateRepository(db.DB).GetTemplateByID(templateID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("template %d: %v", templateID, err)
+	}
+
+	files := []struct {
+		phase string
+		paths []string
+	}{
+		{model.PhaseSetup, gradeSetup},
+		{model.PhaseCheck, gradeScripts},
+		{model.PhaseTeardown, gradeTeardown},
+	}
+	for _, f := range files {
+		for _, path := range f.paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			scripts = append(scripts, model.ContainerScript{
+				TemplateID:     templateID,
+				Order:          uint(len(scripts) + 1),
+				Name:           filepath.Base(path),
+				Phase:          f.phase,
+				Visibility:     model.VisibilityVisible,
+				Content:        string(content),
+				ExpectedOutput: gradeExpected,
+				MatchType:      gradeMatchType,
+				Timeout:        gradeTimeout,
+				Points:         1,
+				Parallel:       gradeParallel && f.phase == model.PhaseCheck,
+			})
+		}
+	}
+
+	// 与线上一样为指定用户注入小节的实验参数
+	if section != nil {
+		values, err := params.ForSection(section, gradeUserID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("section %d: %v", gradeSectionID, err)
+		}
+		template.Envs = params.AppendEnvs(template.Envs, values)
+		scripts = params.Scripts(scripts, values)
+	}
+
+	return template, scripts, nil
+}
+
+// printCheckResult 打印单个检测点的结果，未通过时附带输出和差异，隐藏的检测点也完整输出
+func printCheckResult(result *model.CheckResult) {
+	name := result.Name
+	if name == "" {
+		name = fmt.Sprintf("#%d", result.Order)
+	}
+	fmt.Printf("%-5s  %s  %g/%g  (%dms)\n", strings.ToUpper(result.Status), name, result.Points, result.MaxPoints, result.Duration)
+	if result.Status == model.CheckPass {
+		return
+	}
+
+	if result.Output != "" {
+		fmt.Println("  output:")
+		fmt.Println(indent(result.Output))
+	}
+	if result.Diff != "" {
+		fmt.Println("  diff (- expected, + actual):")
+		fmt.Println(indent(result.Diff))
+	}
+}
+
+// indent 为多行文本的每一行增加缩进
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,6 +4,7 @@ import (
 	"awesomeProject/internal/handler"
 	"awesomeProject/internal/task"
 	"awesomeProject/pkg/configs"
+	"awesomeProject/pkg/secret"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -21,6 +22,11 @@ var serverCmd = &cobra.Command{
 	Short: "Start the TTDS server",
 	Long:  `Start the TTDS web server with optional pprof profiling`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// 非开发环境不允许使用默认密钥启动，否则容器的SUDO密码可以被任何人解密
+		if err := secret.CheckKey(configs.GetConfig()); err != nil {
+			logrus.Fatalf("refuse to start: %v", err)
+		}
+
 		// 创建 Gin 引擎
 		r := gin.Default()
 
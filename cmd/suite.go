@@ -0,0 +1,113 @@
+package main
+
+import (
+	"awesomeProject/internal/suite"
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	suiteSectionID uint
+	suiteVersion   uint
+	suiteDir       string
+	suiteFile      string
+)
+
+var suiteCmd = &cobra.Command{
+	Use:   "suite",
+	Short: "Manage grading suites",
+	Long: `Manage grading suites. A grading suite is a directory or a tar.gz archive with a suite.yaml
+manifest listing the checks of a section and the script files they run.`,
+}
+
+var suiteImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a grading suite into a section",
+	Long: `Import a grading suite from a directory or a tar.gz archive, replacing the check scripts
+of the section. Other sections using the same template keep their scripts. Importing a suite
+identical to the latest version does not create a new version, it only resets scripts that were
+edited by hand to that version.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var parsed *suite.Suite
+		var err error
+		if suiteDir != "" {
+			parsed, err = suite.LoadDir(suiteDir)
+		} else {
+			parsed, err = readSuiteFile(suiteFile)
+		}
+		if err != nil {
+			logrus.Fatalf("failed to load suite: %v", err)
+		}
+
+		imported, err := usecase.NewSuiteService().ImportSuite(0, suiteSectionID, parsed)
+		if err != nil {
+			logrus.Fatalf("failed to import suite into section %d: %v", suiteSectionID, err)
+		}
+		logrus.Infof("section %d is graded by suite %q version %d with %d checks", suiteSectionID, imported.Name, imported.Version, imported.Checks)
+	},
+}
+
+var suiteExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the grading suite of a section",
+	Long:  `Export a version of the grading suite of a section, or its current check scripts when --version is 0.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exported, err := usecase.NewSuiteService().ExportSuite(suiteSectionID, suiteVersion)
+		if err != nil {
+			logrus.Fatalf("failed to export suite of section %d: %v", suiteSectionID, err)
+		}
+
+		if suiteDir != "" {
+			err = suite.WriteDir(suiteDir, exported)
+		} else {
+			err = writeSuiteFile(suiteFile, exported)
+		}
+		if err != nil {
+			logrus.Fatalf("failed to write suite: %v", err)
+		}
+		logrus.Infof("suite of section %d exported", suiteSectionID)
+	},
+}
+
+func readSuiteFile(path string) (*suite.Suite, error) {
+	if path == "" {
+		return nil, errors.New("--dir or --file is required")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return suite.ReadArchive(file)
+}
+
+func writeSuiteFile(path string, s *suite.Suite) error {
+	if path == "" {
+		return errors.New("--dir or --file is required")
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := suite.WriteArchive(file, s); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{suiteImportCmd, suiteExportCmd} {
+		c.Flags().UintVar(&suiteSectionID, "section", 0, "Section ID")
+		c.Flags().StringVar(&suiteDir, "dir", "", "Suite directory")
+		c.Flags().StringVar(&suiteFile, "file", "", "Suite tar.gz archive")
+		_ = c.MarkFlagRequired("section")
+		c.MarkFlagsMutuallyExclusive("dir", "file")
+		suiteCmd.AddCommand(c)
+	}
+	suiteExportCmd.Flags().UintVar(&suiteVersion, "version", 0, "Suite version, 0 exports the current check scripts")
+	rootCmd.AddCommand(suiteCmd)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/configs"
+	"awesomeProject/pkg/db"
+	"awesomeProject/pkg/secret"
+	"bufio"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	templateID uint
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage container templates",
+	Long:  `Manage container templates`,
+}
+
+var templateSudoPassCmd = &cobra.Command{
+	Use:   "set-sudo-pass",
+	Short: "Set the sudo password of a template",
+	Long: `Read the sudo password of a template from stdin and store it encrypted.
+An empty password clears it, so that every instance gets a generated one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 与服务启动时一样检查密钥，避免用公开的默认密钥加密
+		if err := secret.CheckKey(configs.GetConfig()); err != nil {
+			logrus.Fatalf("invalid secret key: %v", err)
+		}
+
+		// 从标准输入读取密码，避免密码出现在命令行历史中
+		reader := bufio.NewReader(os.Stdin)
+		password, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			logrus.Fatalf("failed to read password: %v", err)
+		}
+		password = strings.TrimRight(password, "\r\n")
+
+		encrypted, err := secret.NewManager().Encrypt(password)
+		if err != nil {
+			logrus.Fatalf("failed to encrypt password: %v", err)
+		}
+
+		err = repository.NewTemplateRepository(db.DB).UpdateTemplateSUDOPass(templateID, encrypted)
+		if err != nil {
+			logrus.Fatalf("failed to update template %d: %v", templateID, err)
+		}
+		logrus.Infof("sudo password of template %d updated", templateID)
+	},
+}
+
+func init() {
+	templateSudoPassCmd.Flags().UintVar(&templateID, "id", 0, "Template ID")
+	_ = templateSudoPassCmd.MarkFlagRequired("id")
+	templateCmd.AddCommand(templateSudoPassCmd)
+	rootCmd.AddCommand(templateCmd)
+}
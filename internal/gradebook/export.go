@@ -0,0 +1,199 @@
+package gradebook
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Write 按格式写出成绩册
+func Write(w io.Writer, gb *Gradebook, format string) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, gb)
+	case FormatXLSX:
+		return WriteXLSX(w, gb)
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidFilter, format)
+	}
+}
+
+// ContentType 返回导出格式的 MIME 类型
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// table 将成绩册展开为表格，每个小节占 score / completed / attempts / late / last_submission 五列，
+// 数值单元格保留数值类型，便于 XLSX 中直接计算
+func table(gb *Gradebook) ([]string, [][]any) {
+	header := []string{"user_id", "username", "email", "cohort"}
+	for _, column := range gb.Columns {
+		for _, field := range []string{"score", "completed", "attempts", "late", "last_submission"} {
+			header = append(header, fmt.Sprintf("%s %s", column.Title, field))
+		}
+	}
+	header = append(header, "total_score", "total_max_score", "completed_sections")
+
+	rows := make([][]any, 0, len(gb.Rows))
+	for _, row := range gb.Rows {
+		record := []any{int64(row.UserID), row.Username, row.Email, row.Cohort}
+		for _, cell := range row.Cells {
+			last := ""
+			if cell.LastSubmission != nil {
+				last = cell.LastSubmission.Format(time.RFC3339)
+			}
+			record = append(record, cell.Score, cell.Completed, cell.Attempts, cell.Late, last)
+		}
+		record = append(record, row.Score, row.MaxScore, int64(row.Completed))
+		rows = append(rows, record)
+	}
+	return header, rows
+}
+
+func format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return escapeFormula(v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula 用户名、邮箱等由用户填写，以公式字符开头时会被表格软件当作公式执行，
+// 在前面加上单引号使其作为文本显示
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// WriteCSV 写出 CSV 格式的成绩册，开头写入 UTF-8 BOM 以便 Excel 正确识别中文
+func WriteCSV(w io.Writer, gb *Gradebook) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	header, rows := table(gb)
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = format(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// xlsx 文件的固定部分，只包含一个工作表，字符串使用内联字符串，不需要共享字符串表
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Gradebook" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+)
+
+// WriteXLSX 写出只有一个工作表的 XLSX 格式成绩册
+func WriteXLSX(w io.Writer, gb *Gradebook) error {
+	header, rows := table(gb)
+
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	headerRow := make([]any, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	writeXLSXRow(&sheet, 1, headerRow)
+	for i, row := range rows {
+		writeXLSXRow(&sheet, i+2, row)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeXLSXRow(b *strings.Builder, number int, values []any) {
+	fmt.Fprintf(b, `<row r="%d">`, number)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(number)
+		switch value.(type) {
+		case int64, float64:
+			fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, format(value))
+		default:
+			fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(b, []byte(format(value)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+// columnName 将从 0 开始的列号转换为 A、B、...、Z、AA 形式的列名
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,221 @@
+package gradebook
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 导出格式
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrInvalidFilter 导出条件不正确
+var ErrInvalidFilter = errors.New("invalid gradebook filter")
+
+// Filter 导出条件，From 和 To 限定统计的提交时间，为空时不限制
+type Filter struct {
+	Cohort string
+	From   *time.Time
+	To     *time.Time
+}
+
+// Column 成绩册中的一个小节
+type Column struct {
+	SectionID uint
+	Title     string
+	MaxScore  float64 // 小节满分，为 0 时表示不计分或未知，使用提交记录中的满分
+}
+
+// Cell 用户在一个小节的成绩
+type Cell struct {
+	Score          float64
+	MaxScore       float64
+	Percentage     float64
+	Completed      bool
+	Attempts       int64
+	Late           bool       // 取得最好成绩的提交是否迟交
+	LastSubmission *time.Time // 最近一次提交的时间
+}
+
+// Row 一个用户的成绩
+type Row struct {
+	UserID    uint
+	Username  string
+	Email     string
+	Cohort    string
+	Cells     []Cell // 与 Gradebook.Columns 一一对应
+	Score     float64
+	MaxScore  float64
+	Completed int
+}
+
+// Gradebook 课程的成绩册，行为用户，列为小节
+type Gradebook struct {
+	CourseID uint
+	Title    string
+	Columns  []Column
+	Rows     []Row
+}
+
+// Columns 按章节和小节的顺序返回课程的所有小节
+func Columns(course *model.Course) []Column {
+	chapters := make([]model.Chapter, len(course.Chapters))
+	copy(chapters, course.Chapters)
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Order < chapters[j].Order
+	})
+
+	var columns []Column
+	for _, chapter := range chapters {
+		sections := make([]model.Section, len(chapter.Sections))
+		copy(sections, chapter.Sections)
+		sort.SliceStable(sections, func(i, j int) bool {
+			return sections[i].Order < sections[j].Order
+		})
+		for _, section := range sections {
+			columns = append(columns, Column{SectionID: section.ID, Title: section.Title})
+		}
+	}
+	return columns
+}
+
+// SectionMaxScore 计算小节的满分：人工评分使用设置的满分，测验为抽取题目的分值之和
+// （抽取部分题目时按平均分值估算），自动检测为所有检测点的分值之和
+func SectionMaxScore(section *model.Section, checkPoints float64, questionPoints []float64) float64 {
+	switch section.GradingMode {
+	case model.GradingManual:
+		return section.ManualMaxScore
+	case model.GradingQuiz:
+		total := 0.0
+		for _, points := range questionPoints {
+			total += points
+		}
+		count := int(section.QuizQuestionCount)
+		if count == 0 || count >= len(questionPoints) {
+			return total
+		}
+		return total / float64(len(questionPoints)) * float64(count)
+	default:
+		return checkPoints
+	}
+}
+
+// Build 根据提交记录生成成绩册。每个小节取已评分提交中得分百分比最高的一次作为成绩，
+// 有通过的提交即视为完成；提交次数和最近提交时间只统计学生自己的提交，不包括重新评分和平台错误。
+// 总满分是课程所有小节的满分之和，与学生尝试了哪些小节无关
+func Build(course *model.Course, columns []Column, users []model.User, submissions []model.Submission) *Gradebook {
+	gb := &Gradebook{
+		CourseID: course.ID,
+		Title:    course.Title,
+		Columns:  columns,
+	}
+
+	index := make(map[uint]int, len(gb.Columns))
+	for i, column := range gb.Columns {
+		index[column.SectionID] = i
+	}
+
+	rows := make(map[uint]*Row, len(users))
+	for _, user := range users {
+		gb.Rows = append(gb.Rows, Row{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Cohort:   user.Cohort,
+			Cells:    make([]Cell, len(gb.Columns)),
+		})
+	}
+	for i := range gb.Rows {
+		rows[gb.Rows[i].UserID] = &gb.Rows[i]
+	}
+
+	best := make(map[[2]uint]bool) // 是否已经有评分的提交
+	for _, submission := range submissions {
+		row, ok := rows[submission.UserID]
+		if !ok {
+			continue
+		}
+		i, ok := index[submission.SectionID]
+		if !ok {
+			continue
+		}
+		cell := &row.Cells[i]
+
+		if submission.Trigger == model.TriggerStudent && submission.Status != model.SubmissionError {
+			cell.Attempts++
+			if cell.LastSubmission == nil || submission.CreatedAt.After(*cell.LastSubmission) {
+				createdAt := submission.CreatedAt
+				cell.LastSubmission = &createdAt
+			}
+		}
+
+		if submission.Status != model.SubmissionPassed && submission.Status != model.SubmissionFailed {
+			continue
+		}
+		if submission.Status == model.SubmissionPassed {
+			cell.Completed = true
+		}
+		key := [2]uint{submission.UserID, submission.SectionID}
+		if !best[key] || submission.Percentage > cell.Percentage {
+			best[key] = true
+			cell.Score = submission.Score
+			cell.MaxScore = submission.MaxScore
+			cell.Percentage = submission.Percentage
+			cell.Late = submission.Late
+		}
+	}
+
+	for i := range gb.Rows {
+		row := &gb.Rows[i]
+		for j := range row.Cells {
+			cell := &row.Cells[j]
+			if maxScore := gb.Columns[j].MaxScore; maxScore > 0 {
+				cell.MaxScore = maxScore
+			}
+			row.Score += cell.Score
+			row.MaxScore += cell.MaxScore
+			if cell.Completed {
+				row.Completed++
+			}
+		}
+	}
+	return gb
+}
+
+// ParseRange 解析时间范围，支持 RFC3339 和 2006-01-02 格式；只有日期的结束时间包含当天
+func ParseRange(from, to string) (*time.Time, *time.Time, error) {
+	start, err := parseTime(from, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: from: %v", ErrInvalidFilter, err)
+	}
+	end, err := parseTime(to, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: to: %v", ErrInvalidFilter, err)
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	return start, end, nil
+}
+
+func parseTime(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD or RFC3339, got %q", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
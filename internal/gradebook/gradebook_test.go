@@ -0,0 +1,260 @@
+package gradebook
+
+import (
+	"archive/zip"
+	"awesomeProject/internal/model"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCourse() *model.Course {
+	return &model.Course{
+		Model: gorm.Model{ID: 1},
+		Title: "Linux",
+		Chapters: []model.Chapter{
+			{Order: 2, Sections: []model.Section{{Model: gorm.Model{ID: 30}, Title: "Pipes", Order: 1}}},
+			{Order: 1, Sections: []model.Section{
+				{Model: gorm.Model{ID: 20}, Title: "Files", Order: 2},
+				{Model: gorm.Model{ID: 10}, Title: "Shell", Order: 1},
+			}},
+		},
+	}
+}
+
+func submission(userID, sectionID uint, status string, percentage float64, at time.Time) model.Submission {
+	return model.Submission{
+		Model:      gorm.Model{CreatedAt: at},
+		UserID:     userID,
+		SectionID:  sectionID,
+		Trigger:    model.TriggerStudent,
+		Status:     status,
+		Score:      percentage / 10,
+		MaxScore:   10,
+		Percentage: percentage,
+	}
+}
+
+func TestColumns(t *testing.T) {
+	columns := Columns(testCourse())
+	assert.Equal(t, []Column{{SectionID: 10, Title: "Shell"}, {SectionID: 20, Title: "Files"}, {SectionID: 30, Title: "Pipes"}}, columns)
+}
+
+func TestBuild(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	users := []model.User{
+		{Model: gorm.Model{ID: 1}, Username: "alice", Cohort: "cs-1"},
+		{Model: gorm.Model{ID: 2}, Username: "bob", Cohort: "cs-1"},
+	}
+
+	late := submission(1, 10, model.SubmissionPassed, 90, base.Add(2*time.Hour))
+	late.Late = true
+	regrade := submission(1, 20, model.SubmissionPassed, 100, base.Add(5*time.Hour))
+	regrade.Trigger = model.TriggerRegrade
+
+	submissions := []model.Submission{
+		submission(1, 10, model.SubmissionFailed, 40, base),
+		late,
+		submission(1, 10, model.SubmissionFailed, 60, base.Add(3*time.Hour)),
+		submission(1, 20, model.SubmissionFailed, 50, base.Add(time.Hour)),
+		regrade,
+		submission(1, 30, model.SubmissionError, 0, base.Add(4*time.Hour)),
+		submission(1, 30, model.SubmissionSubmitted, 0, base.Add(6*time.Hour)),
+		// 不在成绩册中的用户和小节被忽略
+		submission(3, 10, model.SubmissionPassed, 100, base),
+		submission(2, 99, model.SubmissionPassed, 100, base),
+	}
+
+	columns := Columns(testCourse())
+	columns[0].MaxScore = 10
+	columns[1].MaxScore = 10
+	columns[2].MaxScore = 20
+	gb := Build(testCourse(), columns, users, submissions)
+	assert.Equal(t, "Linux", gb.Title)
+	assert.Len(t, gb.Rows, 2)
+
+	alice := gb.Rows[0]
+	// 取得分最高的一次，迟交标记来自该次提交
+	shell := alice.Cells[0]
+	assert.Equal(t, 9.0, shell.Score)
+	assert.True(t, shell.Completed)
+	assert.True(t, shell.Late)
+	assert.Equal(t, int64(3), shell.Attempts)
+	assert.Equal(t, base.Add(3*time.Hour), *shell.LastSubmission)
+
+	// 重新评分的成绩计入，但不计入提交次数和最近提交时间
+	files := alice.Cells[1]
+	assert.Equal(t, 10.0, files.Score)
+	assert.True(t, files.Completed)
+	assert.Equal(t, int64(1), files.Attempts)
+	assert.Equal(t, base.Add(time.Hour), *files.LastSubmission)
+
+	// 平台错误不计入次数，等待人工评分的提交没有成绩，满分仍然计入
+	pipes := alice.Cells[2]
+	assert.Equal(t, int64(1), pipes.Attempts)
+	assert.Equal(t, 0.0, pipes.Score)
+	assert.Equal(t, 20.0, pipes.MaxScore)
+	assert.False(t, pipes.Completed)
+
+	assert.Equal(t, 19.0, alice.Score)
+	assert.Equal(t, 40.0, alice.MaxScore)
+	assert.Equal(t, 2, alice.Completed)
+
+	// 没有提交的用户也出现在成绩册中
+	bob := gb.Rows[1]
+	assert.Equal(t, "bob", bob.Username)
+	assert.Len(t, bob.Cells, 3)
+	assert.Nil(t, bob.Cells[0].LastSubmission)
+	assert.Equal(t, 0, bob.Completed)
+	// 总满分与尝试了哪些小节无关
+	assert.Equal(t, 40.0, bob.MaxScore)
+}
+
+func TestBuildUnknownMaxScore(t *testing.T) {
+	users := []model.User{{Model: gorm.Model{ID: 1}}}
+	submissions := []model.Submission{submission(1, 10, model.SubmissionPassed, 50, time.Now())}
+
+	// 没有设置满分的小节使用提交记录中的满分
+	gb := Build(testCourse(), Columns(testCourse()), users, submissions)
+	assert.Equal(t, 10.0, gb.Rows[0].Cells[0].MaxScore)
+	assert.Equal(t, 10.0, gb.Rows[0].MaxScore)
+}
+
+func TestSectionMaxScore(t *testing.T) {
+	manual := &model.Section{GradingMode: model.GradingManual, ManualMaxScore: 100}
+	assert.Equal(t, 100.0, SectionMaxScore(manual, 5, nil))
+
+	auto := &model.Section{GradingMode: model.GradingAuto}
+	assert.Equal(t, 5.0, SectionMaxScore(auto, 5, nil))
+
+	quiz := &model.Section{GradingMode: model.GradingQuiz}
+	assert.Equal(t, 6.0, SectionMaxScore(quiz, 0, []float64{1, 2, 3}))
+	quiz.QuizQuestionCount = 2
+	assert.Equal(t, 4.0, SectionMaxScore(quiz, 0, []float64{1, 2, 3}))
+}
+
+func TestParseRange(t *testing.T) {
+	from, to, err := ParseRange("2024-03-01", "2024-03-31")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), *from)
+	// 只有日期的结束时间包含当天
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local), *to)
+
+	from, to, err = ParseRange("2024-03-01T08:00:00Z", "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), from.UTC())
+	assert.Nil(t, to)
+
+	for _, r := range [][2]string{{"03/01/2024", ""}, {"", "tomorrow"}, {"2024-03-02", "2024-03-01"}} {
+		_, _, err := ParseRange(r[0], r[1])
+		assert.True(t, errors.Is(err, ErrInvalidFilter), "%v: got %v", r, err)
+	}
+}
+
+func testGradebook() *Gradebook {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &Gradebook{
+		Columns: []Column{{SectionID: 10, Title: "Shell"}},
+		Rows: []Row{{
+			UserID:    1,
+			Username:  "alice",
+			Email:     "a@example.com",
+			Cohort:    "cs-1",
+			Cells:     []Cell{{Score: 7.5, MaxScore: 10, Completed: true, Attempts: 2, LastSubmission: &at}},
+			Score:     7.5,
+			MaxScore:  10,
+			Completed: 1,
+		}},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, testGradebook(), FormatCSV))
+	assert.True(t, strings.HasPrefix(buf.String(), "\ufeff"))
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"user_id", "username", "email", "cohort", "Shell score", "Shell completed", "Shell attempts", "Shell late",
+			"Shell last_submission", "total_score", "total_max_score", "completed_sections"},
+		{"1", "alice", "a@example.com", "cs-1", "7.5", "yes", "2", "no", "2024-03-01T12:00:00Z", "7.5", "10", "1"},
+	}, records)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	gb := testGradebook()
+	gb.Rows[0].Username = "<alice & bob>"
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, gb, FormatXLSX))
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A2"><v>1</v></c>`)
+	assert.Contains(t, sheet, `&lt;alice &amp; bob&gt;`)
+	assert.Contains(t, sheet, `<c r="E2"><v>7.5</v></c>`)
+	assert.Contains(t, sheet, `<c r="L2"><v>1</v></c>`)
+}
+
+func TestWriteEscapesFormulas(t *testing.T) {
+	gb := testGradebook()
+	gb.Rows[0].Username = "=HYPERLINK(\"http://evil\")"
+	gb.Rows[0].Email = "@SUM(A1)"
+	gb.Rows[0].Cohort = "-1+1"
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, gb, FormatCSV))
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "'=HYPERLINK(\"http://evil\")", "'@SUM(A1)", "'-1+1"}, records[1][:4])
+
+	buf.Reset()
+	assert.NoError(t, Write(&buf, gb, FormatXLSX))
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	for _, f := range reader.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+		assert.Contains(t, string(data), "&#39;@SUM(A1)")
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	err := Write(io.Discard, testGradebook(), "pdf")
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
@@ -0,0 +1,66 @@
+package grader
+
+import "strings"
+
+// maxDiffLines 参与比较的最大行数，避免输出过长时计算量过大
+const maxDiffLines = 200
+
+// Diff 按行比较期望输出和实际输出，相同的行以两个空格开头，
+// 缺少的行以 "- " 开头，多出的行以 "+ " 开头。两者相同时返回空字符串
+func Diff(expected, actual string) string {
+	a := splitLines(expected)
+	b := splitLines(actual)
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var builder strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			builder.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			builder.WriteString("- " + a[i] + "\n")
+			changed = true
+			i++
+		default:
+			builder.WriteString("+ " + b[j] + "\n")
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return Excerpt(builder.String())
+}
+
+// splitLines 去掉首尾空白后按行切分，超出 maxDiffLines 的部分会被丢弃
+func splitLines(s string) []string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > maxDiffLines {
+		lines = lines[:maxDiffLines]
+	}
+	return lines
+}
@@ -0,0 +1,16 @@
+package grader
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "", Diff("a\nb\n", "a\nb"))
+	assert.Equal(t, "", Diff("", "  \n"))
+
+	assert.Equal(t, "  a\n- b\n+ c\n", Diff("a\nb", "a\nc"))
+	assert.Equal(t, "  a\n+ x\n  b\n", Diff("a\nb", "a\nx\nb"))
+	assert.Equal(t, "- a\n  b\n", Diff("a\nb", "b"))
+	assert.Equal(t, "+ out\n", Diff("", "out"))
+}
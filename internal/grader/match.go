@@ -0,0 +1,53 @@
+package grader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 输出匹配方式
+const (
+	MatchNone     = ""
+	MatchContains = "contains"
+	MatchEquals   = "equals"
+	MatchRegex    = "regex"
+)
+
+// 检测结果中最多保存的输出长度
+const maxOutputExcerpt = 4096
+
+// Match 判断脚本输出是否符合期望，没有指定期望输出时只看退出码
+func Match(matchType, expected, output string) (bool, error) {
+	if expected == "" {
+		return true, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(matchType)) {
+	case MatchNone, MatchContains:
+		return strings.Contains(output, expected), nil
+	case MatchEquals:
+		return strings.TrimSpace(output) == strings.TrimSpace(expected), nil
+	case MatchRegex:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %q: %v", expected, err)
+		}
+		return re.MatchString(output), nil
+	default:
+		return false, fmt.Errorf("unknown match type %q", matchType)
+	}
+}
+
+// Excerpt 截取输出的最后一部分，失败原因通常在输出末尾
+func Excerpt(output string) string {
+	if len(output) <= maxOutputExcerpt {
+		return output
+	}
+	cut := len(output) - maxOutputExcerpt
+	// 避免截断在 UTF-8 字符中间
+	for cut < len(output) && output[cut]&0xC0 == 0x80 {
+		cut++
+	}
+	return "...(output truncated)\n" + output[cut:]
+}
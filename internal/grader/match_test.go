@@ -0,0 +1,49 @@
+package grader
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		matchType string
+		expected  string
+		output    string
+		matched   bool
+		wantErr   bool
+	}{
+		{"没有期望输出", MatchContains, "", "anything", true, false},
+		{"包含", MatchContains, "hello", "say hello world", true, false},
+		{"不包含", MatchContains, "bye", "say hello world", false, false},
+		{"相等忽略首尾空白", MatchEquals, "42", "42\n", true, false},
+		{"不相等", MatchEquals, "42", "43", false, false},
+		{"正则", MatchRegex, `^pid=\d+$`, "pid=123", true, false},
+		{"非法正则", MatchRegex, `(`, "pid=123", false, true},
+		{"未知匹配方式", "fuzzy", "a", "a", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := Match(tt.matchType, tt.expected, tt.output)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.matched, matched)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	short := "short output"
+	assert.Equal(t, short, Excerpt(short))
+
+	long := strings.Repeat("a", maxOutputExcerpt) + "tail"
+	excerpt := Excerpt(long)
+	assert.True(t, strings.HasSuffix(excerpt, "tail"), "应保留输出末尾")
+	assert.Contains(t, excerpt, "truncated")
+}
@@ -0,0 +1,31 @@
+package grader
+
+import "awesomeProject/internal/model"
+
+// Redact 返回对学生展示的检测结果，隐藏的检测点只保留顺序、状态、得分和提示，
+// 不暴露名称、说明、输出和差异，避免学生反推出检测内容。提示只在未通过时展示
+func Redact(result model.CheckResult) model.CheckResult {
+	if result.Status == model.CheckPass {
+		result.Hint = ""
+	}
+	if !result.Hidden {
+		return result
+	}
+
+	result.ScriptID = 0
+	result.Name = ""
+	result.Description = ""
+	result.ExitCode = 0
+	result.Output = ""
+	result.Diff = ""
+	return result
+}
+
+// RedactAll 对提交中的所有检测结果做脱敏处理
+func RedactAll(results []model.CheckResult) []model.CheckResult {
+	redacted := make([]model.CheckResult, 0, len(results))
+	for _, result := range results {
+		redacted = append(redacted, Redact(result))
+	}
+	return redacted
+}
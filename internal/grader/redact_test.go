@@ -0,0 +1,41 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	visible := model.CheckResult{
+		ScriptID: 1,
+		Order:    1,
+		Name:     "编译内核",
+		Status:   model.CheckFail,
+		Output:   "make: *** [all] Error 2",
+		Diff:     "- ok\n+ error\n",
+	}
+	assert.Equal(t, visible, Redact(visible))
+
+	hidden := visible
+	hidden.Hidden = true
+	hidden.Hint = "注意空指针"
+	hidden.Points = 0
+	hidden.MaxPoints = 2
+	redacted := Redact(hidden)
+	assert.Equal(t, uint(1), redacted.Order)
+	assert.Equal(t, model.CheckFail, redacted.Status)
+	assert.Equal(t, 2.0, redacted.MaxPoints)
+	assert.Empty(t, redacted.ScriptID)
+	assert.Empty(t, redacted.Name)
+	assert.Empty(t, redacted.Output)
+	assert.Empty(t, redacted.Diff)
+	assert.Equal(t, "注意空指针", redacted.Hint)
+
+	// 通过的检测点不展示提示
+	hidden.Status = model.CheckPass
+	assert.Empty(t, Redact(hidden).Hint)
+
+	// 原始结果不受影响
+	assert.Equal(t, "编译内核", hidden.Name)
+}
@@ -0,0 +1,237 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEmptyReport 测试报告中没有任何测试用例
+var ErrEmptyReport = errors.New("no test cases found in report")
+
+// ErrTooManyCases 测试报告中的用例或计划的用例数超过上限
+var ErrTooManyCases = fmt.Errorf("report has more than %d test cases", maxReportCases)
+
+// maxReportCases 一份测试报告最多包含的用例数。报告来自学生程序的输出，
+// 不限制时一行 1..999999999 就会生成同样多的检测结果
+const maxReportCases = 1000
+
+// TestCase 测试报告中的一个测试用例
+type TestCase struct {
+	Name     string
+	Passed   bool
+	Message  string
+	Duration time.Duration
+}
+
+// ParseReport 按格式解析测试报告
+func ParseReport(format string, data []byte) ([]TestCase, error) {
+	var cases []TestCase
+	var err error
+	switch format {
+	case model.ReportTAP:
+		cases, err = ParseTAP(string(data))
+	case model.ReportJUnit:
+		cases, err = ParseJUnit(data)
+	default:
+		return nil, fmt.Errorf("unknown report format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, ErrEmptyReport
+	}
+	return cases, nil
+}
+
+var (
+	tapPlanRegexp   = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapResultRegexp = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*-?\s*([^#]*)(?:#\s*(\S+)\s*(.*))?$`)
+)
+
+// ParseTAP 解析 TAP (Test Anything Protocol) 格式的输出。
+// SKIP 的用例视为通过，TODO 的用例不影响结果；计划中声明但没有输出的用例视为失败，
+// 输出的用例多于计划时报告无效，避免多余的通过用例稀释失败用例的分值
+func ParseTAP(output string) ([]TestCase, error) {
+	var cases []TestCase
+	planned := -1
+	results := 0
+	inYAML := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+scan:
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		// 缩进的行属于子测试或诊断信息
+		indented := line != strings.TrimLeft(line, " \t")
+
+		// 缩进的 YAML 诊断信息，附加到上一个用例
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+			} else if len(cases) > 0 {
+				appendMessage(&cases[len(cases)-1], trimmed)
+			}
+			continue
+		}
+		if trimmed == "---" && indented {
+			inYAML = true
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "Bail out!"):
+			cases = append(cases, TestCase{
+				Name:    "bail out",
+				Message: strings.TrimSpace(strings.TrimPrefix(trimmed, "Bail out!")),
+			})
+			// 之后的输出被忽略，计划中没有执行的用例仍然计为失败
+			break scan
+		case indented:
+			continue
+		case tapPlanRegexp.MatchString(trimmed):
+			n, err := strconv.Atoi(tapPlanRegexp.FindStringSubmatch(trimmed)[1])
+			if err != nil || n > maxReportCases {
+				return nil, ErrTooManyCases
+			}
+			planned = n
+		case tapResultRegexp.MatchString(trimmed):
+			if results++; results > maxReportCases {
+				return nil, ErrTooManyCases
+			}
+			match := tapResultRegexp.FindStringSubmatch(trimmed)
+			tc := TestCase{
+				Name:   strings.TrimSpace(match[3]),
+				Passed: match[1] == "",
+			}
+			if tc.Name == "" {
+				tc.Name = "test " + match[2]
+			}
+			directive := strings.ToUpper(match[4])
+			switch {
+			case strings.HasPrefix(directive, "SKIP"):
+				tc.Passed = true
+				tc.Message = strings.TrimSpace("skipped " + match[5])
+			case strings.HasPrefix(directive, "TODO"):
+				tc.Passed = true
+				tc.Message = strings.TrimSpace("todo " + match[5])
+			}
+			cases = append(cases, tc)
+		case strings.HasPrefix(trimmed, "#") && len(cases) > 0 && !cases[len(cases)-1].Passed:
+			// 失败用例后面的注释通常是失败原因
+			appendMessage(&cases[len(cases)-1], strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if planned >= 0 && results > planned {
+		return nil, fmt.Errorf("report has %d test cases but planned %d", results, planned)
+	}
+
+	for i := len(cases); i < planned; i++ {
+		cases = append(cases, TestCase{
+			Name:    fmt.Sprintf("test %d", i+1),
+			Message: "test did not run",
+		})
+	}
+	return cases, nil
+}
+
+func appendMessage(tc *TestCase, line string) {
+	if tc.Message == "" {
+		tc.Message = line
+		return
+	}
+	tc.Message += "\n" + line
+}
+
+// junitSuite 同时兼容 <testsuites> 和 <testsuite> 作为根节点
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (m *junitMessage) String() string {
+	text := strings.TrimSpace(m.Text)
+	if m.Message == "" {
+		return text
+	}
+	if text == "" {
+		return m.Message
+	}
+	return m.Message + "\n" + text
+}
+
+// ParseJUnit 解析 JUnit XML 格式的测试报告，failure 和 error 都视为失败，skipped 视为通过
+func ParseJUnit(data []byte) ([]TestCase, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid junit report: %v", err)
+	}
+
+	var cases []TestCase
+	tooMany := false
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, c := range suite.Cases {
+			if len(cases) >= maxReportCases {
+				tooMany = true
+				return
+			}
+			tc := TestCase{
+				Name:   c.Name,
+				Passed: true,
+			}
+			if c.Classname != "" {
+				tc.Name = c.Classname + "." + c.Name
+			}
+			if seconds, err := strconv.ParseFloat(c.Time, 64); err == nil {
+				tc.Duration = time.Duration(seconds * float64(time.Second))
+			}
+			switch {
+			case c.Failure != nil:
+				tc.Passed = false
+				tc.Message = c.Failure.String()
+			case c.Error != nil:
+				tc.Passed = false
+				tc.Message = c.Error.String()
+			case c.Skipped != nil:
+				tc.Message = strings.TrimSpace("skipped " + c.Skipped.String())
+			}
+			cases = append(cases, tc)
+		}
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	if tooMany {
+		return nil, ErrTooManyCases
+	}
+
+	return cases, nil
+}
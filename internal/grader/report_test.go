@@ -0,0 +1,144 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTAP(t *testing.T) {
+	output := `TAP version 13
+1..5
+ok 1 - fork creates a child
+not ok 2 - wait reaps the child
+  ---
+  message: 'expected 0, got 1'
+  ...
+ok 3 # SKIP no network
+not ok 4 - ls shows hidden files # TODO not implemented yet
+    ok 1 - subtest is ignored
+`
+	cases, err := ParseTAP(output)
+	assert.NoError(t, err)
+	assert.Len(t, cases, 5)
+
+	assert.Equal(t, "fork creates a child", cases[0].Name)
+	assert.True(t, cases[0].Passed)
+
+	assert.Equal(t, "wait reaps the child", cases[1].Name)
+	assert.False(t, cases[1].Passed)
+	assert.Equal(t, "message: 'expected 0, got 1'", cases[1].Message)
+
+	assert.Equal(t, "test 3", cases[2].Name)
+	assert.True(t, cases[2].Passed)
+	assert.Equal(t, "skipped no network", cases[2].Message)
+
+	assert.Equal(t, "ls shows hidden files", cases[3].Name)
+	assert.True(t, cases[3].Passed)
+
+	// 计划了 5 个用例，但只输出了 4 个
+	assert.Equal(t, "test 5", cases[4].Name)
+	assert.False(t, cases[4].Passed)
+}
+
+func TestParseTAPBailOut(t *testing.T) {
+	cases, err := ParseTAP("1..3\nok 1 - build\nBail out! missing compiler\nok 2 - run\n")
+	assert.NoError(t, err)
+	assert.Len(t, cases, 3)
+	assert.False(t, cases[1].Passed)
+	assert.Equal(t, "missing compiler", cases[1].Message)
+	// 中途退出时计划中剩余的用例计为未执行，避免提前崩溃反而得分更高
+	assert.False(t, cases[2].Passed)
+	assert.Equal(t, "test did not run", cases[2].Message)
+}
+
+func TestParseTAPTooManyCases(t *testing.T) {
+	// 计划的用例数过大时不生成未执行的用例
+	_, err := ParseTAP("1..999999999\nok 1 - a\n")
+	assert.ErrorIs(t, err, ErrTooManyCases)
+	_, err = ParseTAP("1..99999999999999999999\n")
+	assert.ErrorIs(t, err, ErrTooManyCases)
+
+	_, err = ParseTAP(strings.Repeat("ok\n", maxReportCases+1))
+	assert.ErrorIs(t, err, ErrTooManyCases)
+
+	var junit strings.Builder
+	junit.WriteString("<testsuite>")
+	for i := 0; i <= maxReportCases; i++ {
+		junit.WriteString(`<testcase name="a"/>`)
+	}
+	junit.WriteString("</testsuite>")
+	_, err = ParseJUnit([]byte(junit.String()))
+	assert.ErrorIs(t, err, ErrTooManyCases)
+}
+
+func TestParseTAPMoreThanPlanned(t *testing.T) {
+	// 多输出的通过用例不能稀释失败用例的分值
+	_, err := ParseTAP("1..2\nnot ok 1 - sum\nok 2 - diff\nok 3 - extra\nok 4 - extra\n")
+	assert.Error(t, err)
+
+	// 没有计划时以输出的用例为准
+	cases, err := ParseTAP("not ok 1 - sum\nok 2 - diff\nok 3 - extra\n")
+	assert.NoError(t, err)
+	assert.Len(t, cases, 3)
+}
+
+func TestParseTAPComments(t *testing.T) {
+	cases, err := ParseTAP("not ok 1 - sum\n# expected 3\n# got 4\nok 2 - diff\n# all good\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "expected 3\ngot 4", cases[0].Message)
+	assert.Empty(t, cases[1].Message)
+}
+
+func TestParseJUnit(t *testing.T) {
+	report := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="shell">
+    <testcase classname="shell" name="pipe" time="0.25"/>
+    <testcase classname="shell" name="redirect" time="1.5">
+      <failure message="file is empty">expected hello</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="fs">
+    <testcase name="mkdir"><error message="panic"/></testcase>
+    <testcase name="symlink"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+	cases, err := ParseJUnit([]byte(report))
+	assert.NoError(t, err)
+	assert.Len(t, cases, 4)
+
+	assert.Equal(t, "shell.pipe", cases[0].Name)
+	assert.True(t, cases[0].Passed)
+	assert.Equal(t, 250*time.Millisecond, cases[0].Duration)
+
+	assert.False(t, cases[1].Passed)
+	assert.Equal(t, "file is empty\nexpected hello", cases[1].Message)
+	assert.Equal(t, 1500*time.Millisecond, cases[1].Duration)
+
+	assert.Equal(t, "mkdir", cases[2].Name)
+	assert.False(t, cases[2].Passed)
+	assert.Equal(t, "panic", cases[2].Message)
+
+	assert.True(t, cases[3].Passed)
+}
+
+func TestParseJUnitSingleSuite(t *testing.T) {
+	cases, err := ParseJUnit([]byte(`<testsuite><testcase name="a"/></testsuite>`))
+	assert.NoError(t, err)
+	assert.Len(t, cases, 1)
+	assert.Equal(t, "a", cases[0].Name)
+}
+
+func TestParseReport(t *testing.T) {
+	_, err := ParseReport(model.ReportTAP, []byte("no tap here"))
+	assert.ErrorIs(t, err, ErrEmptyReport)
+
+	_, err = ParseReport(model.ReportJUnit, []byte("<testsuite"))
+	assert.Error(t, err)
+
+	_, err = ParseReport("xunit", nil)
+	assert.Error(t, err)
+}
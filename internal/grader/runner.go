@@ -0,0 +1,347 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/container"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Runner 在容器中执行检测脚本并判定每个检测点的结果
+type Runner struct {
+	manager container.Manager
+}
+
+func NewRunner(manager container.Manager) *Runner {
+	return &Runner{
+		manager: manager,
+	}
+}
+
+// Hooks 检测过程中的回调，index 为检测点在 check 阶段中的序号，从 0 开始。
+// 回调不会被并发调用，OnResult 按检测点的顺序调用，生成测试报告的检测点每个用例调用一次
+type Hooks struct {
+	OnStart  func(index int, script *model.ContainerScript)
+	OnResult func(index int, result *model.CheckResult)
+}
+
+// Run 依次执行 setup、check、teardown 三个阶段的脚本，每完成一个检测点调用一次 onResult。
+// setup 脚本失败时不再执行检测点并返回 *SetupError，teardown 脚本无论如何都会执行
+func (r *Runner) Run(instance *model.ContainerInstance, scripts []model.ContainerScript, onResult func(*model.CheckResult)) ([]*model.CheckResult, error) {
+	var hooks Hooks
+	if onResult != nil {
+		hooks.OnResult = func(_ int, result *model.CheckResult) {
+			onResult(result)
+		}
+	}
+	return r.RunConcurrent(instance, scripts, 1, hooks)
+}
+
+// RunConcurrent 与 Run 相同，但相邻的并行检测点最多 concurrency 个同时执行，非并行的检测点单独执行。
+// 结果的顺序始终与检测点的顺序一致
+func (r *Runner) RunConcurrent(instance *model.ContainerInstance, scripts []model.ContainerScript, concurrency uint, hooks Hooks) ([]*model.CheckResult, error) {
+	setup, checks, teardown := SplitPhases(scripts)
+	defer r.runTeardown(instance, teardown)
+
+	for i := range setup {
+		if err := r.runSetup(instance, &setup[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 并行执行时检测点在不同的 goroutine 中开始，回调需要串行
+	var mu sync.Mutex
+	started := func(index int) {
+		if hooks.OnStart != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			hooks.OnStart(index, &checks[index])
+		}
+	}
+	results := make([]*model.CheckResult, 0, len(checks))
+	emit := func(index int, checkResults []*model.CheckResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, result := range checkResults {
+			results = append(results, result)
+			if hooks.OnResult != nil {
+				hooks.OnResult(index, result)
+			}
+		}
+	}
+
+	for start := 0; start < len(checks); {
+		end := start + 1
+		if concurrency > 1 && checks[start].Parallel {
+			for end < len(checks) && checks[end].Parallel {
+				end++
+			}
+		}
+		r.runBatch(instance, checks, start, end, concurrency, started, emit)
+		start = end
+	}
+	return results, nil
+}
+
+// runBatch 并发执行 checks[start:end]，同时执行的数量不超过 concurrency，按检测点的顺序依次交给 emit，
+// 前面的检测点完成后立即输出，不等待整组结束
+func (r *Runner) runBatch(instance *model.ContainerInstance, checks []model.ContainerScript, start, end int, concurrency uint,
+	started func(int), emit func(int, []*model.CheckResult)) {
+	if end-start == 1 {
+		started(start)
+		emit(start, r.runCheck(instance, &checks[start]))
+		return
+	}
+
+	done := make([]chan []*model.CheckResult, end-start)
+	for i := range done {
+		done[i] = make(chan []*model.CheckResult, 1)
+	}
+
+	// 按顺序启动检测点，先启动的检测点先完成的可能性更大，结果可以尽早输出
+	sem := make(chan struct{}, concurrency)
+	go func() {
+		for i := start; i < end; i++ {
+			sem <- struct{}{}
+			started(i)
+			go func(i int) {
+				defer func() { <-sem }()
+				done[i-start] <- r.runCheck(instance, &checks[i])
+			}(i)
+		}
+	}()
+
+	for i := range done {
+		emit(start+i, <-done[i])
+	}
+}
+
+// runCheck 执行一个检测点，生成测试报告的脚本会展开为多个检测结果
+func (r *Runner) runCheck(instance *model.ContainerInstance, script *model.ContainerScript) []*model.CheckResult {
+	if script.ReportFormat != "" {
+		return r.RunReport(instance, script)
+	}
+	return []*model.CheckResult{r.RunScript(instance, script)}
+}
+
+// runSetup 执行准备脚本，退出码不为 0 或超时都视为失败
+func (r *Runner) runSetup(instance *model.ContainerInstance, script *model.ContainerScript) error {
+	execResult, err := r.manager.ExecCommand(instance, script)
+	if err == nil && execResult.ExitCode == 0 {
+		return nil
+	}
+
+	setupErr := &SetupError{
+		Script: scriptName(script),
+		Err:    err,
+	}
+	if execResult != nil {
+		setupErr.ExitCode = execResult.ExitCode
+		// 隐藏的准备脚本不暴露输出
+		if script.Visibility != model.VisibilityHidden {
+			setupErr.Output = Excerpt(execResult.Output)
+		}
+	}
+	return setupErr
+}
+
+// runTeardown 执行清理脚本，失败只记录日志
+func (r *Runner) runTeardown(instance *model.ContainerInstance, scripts []model.ContainerScript) {
+	for i := range scripts {
+		execResult, err := r.manager.ExecCommand(instance, &scripts[i])
+		if err != nil {
+			logrus.Warnf("teardown script %s failed: %v", scriptName(&scripts[i]), err)
+		} else if execResult.ExitCode != 0 {
+			logrus.Warnf("teardown script %s exited with %d", scriptName(&scripts[i]), execResult.ExitCode)
+		}
+	}
+}
+
+// RunScript 执行单个检测脚本，脚本退出码为 0 且输出符合期望时视为通过
+func (r *Runner) RunScript(instance *model.ContainerInstance, script *model.ContainerScript) *model.CheckResult {
+	result := newCheckResult(script)
+	defer func() {
+		if result.Status == model.CheckPass {
+			result.Points = result.MaxPoints
+		}
+		result.FinishedAt = time.Now()
+		result.Duration = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+	}()
+
+	execResult, err := r.manager.ExecCommand(instance, script)
+	if execResult != nil {
+		result.ExitCode = execResult.ExitCode
+		result.Output = Excerpt(execResult.Output)
+	}
+
+	switch {
+	case errors.Is(err, container.ErrExecTimeout):
+		// 超时视为学生的程序没有按时完成
+		result.Status = model.CheckFail
+		result.Output = Excerpt(result.Output + "\n" + err.Error())
+	case err != nil:
+		logrus.Warnf("exec script %d failed: %v", script.ID, err)
+		result.Status = model.CheckError
+		result.Output = err.Error()
+	case execResult.ExitCode != 0:
+		result.Status = model.CheckFail
+	default:
+		matched, err := Match(script.MatchType, script.ExpectedOutput, execResult.Output)
+		if err != nil {
+			result.Status = model.CheckError
+			result.Output = Excerpt(result.Output + "\n" + err.Error())
+		} else if matched {
+			result.Status = model.CheckPass
+		} else {
+			result.Status = model.CheckFail
+			if script.MatchType == MatchEquals {
+				result.Diff = Diff(script.ExpectedOutput, execResult.Output)
+			}
+		}
+	}
+
+	return result
+}
+
+// RunReport 执行生成测试报告的脚本，将报告中的每个测试用例展开为一个检测结果，脚本的分值平均分配给各个用例。
+// 测试失败时脚本的退出码通常不为 0，因此只要能读取并解析报告就以报告为准
+func (r *Runner) RunReport(instance *model.ContainerInstance, script *model.ContainerScript) []*model.CheckResult {
+	startedAt := time.Now()
+	execResult, err := r.manager.ExecCommand(instance, script)
+	if err != nil {
+		// 超时视为学生的程序没有按时完成，其他错误是平台原因
+		status := model.CheckError
+		if errors.Is(err, container.ErrExecTimeout) {
+			status = model.CheckFail
+		} else {
+			logrus.Warnf("exec script %d failed: %v", script.ID, err)
+		}
+		return []*model.CheckResult{reportFailure(script, startedAt, execResult, status, err)}
+	}
+
+	// 报告缺失或无法解析通常是学生的程序崩溃导致的，按未通过处理
+	data := []byte(execResult.Output)
+	if script.ReportPath != "" {
+		data, err = r.manager.ReadFile(instance, script.ReportPath)
+		if err != nil {
+			return []*model.CheckResult{reportFailure(script, startedAt, execResult, model.CheckFail, fmt.Errorf("failed to read report: %v", err))}
+		}
+	}
+
+	cases, err := ParseReport(script.ReportFormat, data)
+	if err != nil {
+		return []*model.CheckResult{reportFailure(script, startedAt, execResult, model.CheckFail, err)}
+	}
+
+	finishedAt := time.Now()
+	points := script.Points / float64(len(cases))
+	results := make([]*model.CheckResult, 0, len(cases))
+	for i, tc := range cases {
+		result := newCheckResult(script)
+		result.Case = uint(i + 1)
+		result.Name = tc.Name
+		if script.Name != "" {
+			result.Name = script.Name + ": " + tc.Name
+		}
+		result.MaxPoints = points
+		result.ExitCode = execResult.ExitCode
+		result.Output = Excerpt(tc.Message)
+		result.StartedAt = startedAt
+		result.FinishedAt = finishedAt
+		result.Duration = tc.Duration.Milliseconds()
+		if tc.Passed {
+			result.Status = model.CheckPass
+			result.Points = points
+		} else {
+			result.Status = model.CheckFail
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// reportFailure 无法得到测试报告时，整个脚本作为一个未通过的检测点
+func reportFailure(script *model.ContainerScript, startedAt time.Time, execResult *container.ExecResult, status string, err error) *model.CheckResult {
+	result := newCheckResult(script)
+	result.StartedAt = startedAt
+	result.FinishedAt = time.Now()
+	result.Duration = result.FinishedAt.Sub(startedAt).Milliseconds()
+	result.Status = status
+
+	output := ""
+	if execResult != nil {
+		result.ExitCode = execResult.ExitCode
+		output = execResult.Output + "\n"
+	}
+	result.Output = Excerpt(output + err.Error())
+	return result
+}
+
+// newCheckResult 根据脚本创建检测结果，填充名称、可见性和分值等信息
+func newCheckResult(script *model.ContainerScript) *model.CheckResult {
+	return &model.CheckResult{
+		ScriptID:    script.ID,
+		Order:       script.Order,
+		Name:        script.Name,
+		Description: script.Description,
+		Hidden:      script.Visibility == model.VisibilityHidden,
+		Hint:        script.Hint,
+		MaxPoints:   script.Points,
+		Required:    script.Required,
+		StartedAt:   time.Now(),
+	}
+}
+
+// SplitPhases 按执行阶段拆分脚本，保持各阶段内原有的顺序，未设置阶段的脚本视为检测点
+func SplitPhases(scripts []model.ContainerScript) (setup, checks, teardown []model.ContainerScript) {
+	for _, script := range scripts {
+		switch script.Phase {
+		case model.PhaseSetup:
+			setup = append(setup, script)
+		case model.PhaseTeardown:
+			teardown = append(teardown, script)
+		default:
+			checks = append(checks, script)
+		}
+	}
+	return setup, checks, teardown
+}
+
+// SetupError 准备脚本执行失败，本次检测被终止
+type SetupError struct {
+	Script   string
+	ExitCode int
+	Output   string
+	Err      error
+}
+
+func (e *SetupError) Error() string {
+	reason := fmt.Sprintf("setup script %s exited with %d", e.Script, e.ExitCode)
+	if e.Err != nil {
+		reason = fmt.Sprintf("setup script %s failed: %v", e.Script, e.Err)
+	}
+	if e.Output != "" {
+		reason += "\n" + e.Output
+	}
+	return reason
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+// Infrastructure 是否因为平台原因（而不是学生的代码）导致准备脚本无法执行
+func (e *SetupError) Infrastructure() bool {
+	return e.Err != nil && !errors.Is(e.Err, container.ErrExecTimeout)
+}
+
+// scriptName 获取脚本在日志和错误信息中的名称
+func scriptName(script *model.ContainerScript) string {
+	if script.Name != "" {
+		return fmt.Sprintf("%q", script.Name)
+	}
+	return fmt.Sprintf("#%d", script.Order)
+}
@@ -0,0 +1,251 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/container"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeManager 按脚本内容返回预设的执行结果，并记录执行顺序
+type fakeManager struct {
+	container.Manager
+	results  map[string]*container.ExecResult
+	errs     map[string]error
+	executed []string
+	mu       sync.Mutex
+}
+
+func (m *fakeManager) ExecCommand(_ *model.ContainerInstance, script *model.ContainerScript) (*container.ExecResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executed = append(m.executed, script.Content)
+	if err, ok := m.errs[script.Content]; ok {
+		return &container.ExecResult{ExitCode: -1}, err
+	}
+	if result, ok := m.results[script.Content]; ok {
+		return result, nil
+	}
+	return &container.ExecResult{}, nil
+}
+
+func TestRunPhases(t *testing.T) {
+	manager := &fakeManager{
+		results: map[string]*container.ExecResult{
+			"check-2": {ExitCode: 1},
+		},
+	}
+	scripts := []model.ContainerScript{
+		{Order: 1, Phase: model.PhaseTeardown, Content: "teardown"},
+		{Order: 2, Phase: model.PhaseCheck, Content: "check-1", Points: 1},
+		{Order: 3, Phase: model.PhaseSetup, Content: "setup"},
+		{Order: 4, Content: "check-2", Points: 1},
+	}
+
+	var reported []uint
+	results, err := NewRunner(manager).Run(&model.ContainerInstance{}, scripts, func(result *model.CheckResult) {
+		reported = append(reported, result.Order)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"setup", "check-1", "check-2", "teardown"}, manager.executed)
+	assert.Equal(t, []uint{2, 4}, reported)
+	assert.Len(t, results, 2)
+	assert.Equal(t, model.CheckPass, results[0].Status)
+	assert.Equal(t, model.CheckFail, results[1].Status)
+}
+
+func TestRunSetupFailure(t *testing.T) {
+	manager := &fakeManager{
+		results: map[string]*container.ExecResult{
+			"make": {ExitCode: 2, Output: "main.c:1: error"},
+		},
+	}
+	scripts := []model.ContainerScript{
+		{Order: 1, Phase: model.PhaseSetup, Name: "编译", Content: "make"},
+		{Order: 2, Content: "check"},
+		{Order: 3, Phase: model.PhaseTeardown, Content: "teardown"},
+	}
+
+	results, err := NewRunner(manager).Run(&model.ContainerInstance{}, scripts, nil)
+	assert.Nil(t, results)
+	assert.Equal(t, []string{"make", "teardown"}, manager.executed)
+
+	var setupErr *SetupError
+	assert.True(t, errors.As(err, &setupErr))
+	assert.Equal(t, 2, setupErr.ExitCode)
+	assert.False(t, setupErr.Infrastructure())
+	assert.Contains(t, err.Error(), "main.c:1: error")
+}
+
+func TestRunSetupInfrastructureError(t *testing.T) {
+	manager := &fakeManager{
+		errs: map[string]error{
+			"make":  errors.New("container not running"),
+			"sleep": container.ErrExecTimeout,
+		},
+	}
+
+	_, err := NewRunner(manager).Run(&model.ContainerInstance{}, []model.ContainerScript{
+		{Phase: model.PhaseSetup, Content: "make"},
+	}, nil)
+	var setupErr *SetupError
+	assert.True(t, errors.As(err, &setupErr))
+	assert.True(t, setupErr.Infrastructure())
+
+	_, err = NewRunner(manager).Run(&model.ContainerInstance{}, []model.ContainerScript{
+		{Phase: model.PhaseSetup, Content: "sleep"},
+	}, nil)
+	assert.True(t, errors.As(err, &setupErr))
+	assert.False(t, setupErr.Infrastructure())
+}
+
+func (m *fakeManager) ReadFile(_ *model.ContainerInstance, path string) ([]byte, error) {
+	if result, ok := m.results[path]; ok {
+		return []byte(result.Output), nil
+	}
+	return nil, errors.New("no such file")
+}
+
+func TestRunReport(t *testing.T) {
+	manager := &fakeManager{
+		results: map[string]*container.ExecResult{
+			"make test":       {ExitCode: 1, Output: "1..2\nok 1 - add\nnot ok 2 - sub\n# expected 1\n"},
+			"/tmp/report.xml": {Output: `<testsuite><testcase name="a"/><testcase name="b"/><testcase name="c"><failure/></testcase><testcase name="d"/></testsuite>`},
+		},
+	}
+	scripts := []model.ContainerScript{
+		{Order: 1, Name: "单元测试", Content: "make test", ReportFormat: model.ReportTAP, Points: 4},
+		{Order: 2, Content: "pytest", ReportFormat: model.ReportJUnit, ReportPath: "/tmp/report.xml", Points: 2},
+		{Order: 3, Content: "echo ok", Points: 1},
+	}
+
+	results, err := NewRunner(manager).Run(&model.ContainerInstance{}, scripts, nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 7)
+
+	assert.Equal(t, "单元测试: add", results[0].Name)
+	assert.Equal(t, uint(1), results[0].Case)
+	assert.Equal(t, model.CheckPass, results[0].Status)
+	assert.Equal(t, 2.0, results[0].Points)
+	assert.Equal(t, model.CheckFail, results[1].Status)
+	assert.Equal(t, "expected 1", results[1].Output)
+
+	assert.Equal(t, "c", results[4].Name)
+	assert.Equal(t, uint(2), results[4].Order)
+	assert.Equal(t, model.CheckFail, results[4].Status)
+	assert.Equal(t, 0.5, results[5].MaxPoints)
+
+	assert.Equal(t, uint(0), results[6].Case)
+
+	summary := Summarize(results)
+	assert.Equal(t, 7.0, summary.MaxScore)
+	assert.Equal(t, 4.5, summary.Score)
+}
+
+func TestRunReportMissing(t *testing.T) {
+	manager := &fakeManager{
+		errs: map[string]error{"broken": errors.New("container not running")},
+	}
+	scripts := []model.ContainerScript{
+		{Content: "pytest", ReportFormat: model.ReportJUnit, ReportPath: "/tmp/report.xml", Points: 2},
+		{Content: "broken", ReportFormat: model.ReportTAP, Points: 1},
+	}
+
+	results, err := NewRunner(manager).Run(&model.ContainerInstance{}, scripts, nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, model.CheckFail, results[0].Status)
+	assert.Equal(t, 2.0, results[0].MaxPoints)
+	assert.Contains(t, results[0].Output, "failed to read report")
+	assert.Equal(t, model.CheckError, results[1].Status)
+}
+
+// slowManager 每个脚本执行 sleep 后的毫秒数，记录同时执行的最大数量和每个脚本的执行区间
+type slowManager struct {
+	container.Manager
+	mu      sync.Mutex
+	running int
+	peak    int
+	spans   map[string][2]time.Time
+}
+
+func (m *slowManager) ExecCommand(_ *model.ContainerInstance, script *model.ContainerScript) (*container.ExecResult, error) {
+	m.mu.Lock()
+	m.running++
+	if m.running > m.peak {
+		m.peak = m.running
+	}
+	m.mu.Unlock()
+
+	start := time.Now()
+	var ms int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(script.Content, "sleep "), "%d", &ms); err == nil {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	}
+
+	m.mu.Lock()
+	m.running--
+	m.spans[script.Name] = [2]time.Time{start, time.Now()}
+	m.mu.Unlock()
+	return &container.ExecResult{}, nil
+}
+
+func TestRunConcurrent(t *testing.T) {
+	manager := &slowManager{spans: make(map[string][2]time.Time)}
+	scripts := []model.ContainerScript{
+		{Order: 1, Name: "a", Content: "sleep 60", Parallel: true},
+		{Order: 2, Name: "b", Content: "sleep 10", Parallel: true},
+		{Order: 3, Name: "c", Content: "sleep 10", Parallel: true},
+		{Order: 4, Name: "serial", Content: "sleep 10"},
+		{Order: 5, Name: "d", Content: "sleep 10", Parallel: true},
+		{Order: 6, Name: "e", Content: "sleep 10", Parallel: true},
+	}
+
+	var started, reported []int
+	results, err := NewRunner(manager).RunConcurrent(&model.ContainerInstance{}, scripts, 2, Hooks{
+		OnStart: func(index int, _ *model.ContainerScript) {
+			started = append(started, index)
+		},
+		OnResult: func(index int, result *model.CheckResult) {
+			assert.Equal(t, uint(index+1), result.Order)
+			reported = append(reported, index)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+
+	// 检测点按顺序开始，结果按检测点的顺序输出，即使后面的检测点先完成
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, started)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, reported)
+	for i, result := range results {
+		assert.Equal(t, uint(i+1), result.Order)
+	}
+	assert.Equal(t, 2, manager.peak)
+	assert.True(t, manager.spans["b"][0].Before(manager.spans["a"][1]))
+
+	// 非并行的检测点在前面的检测点全部结束后执行，且后面的检测点等待它结束
+	serial := manager.spans["serial"]
+	for _, name := range []string{"a", "b", "c"} {
+		assert.False(t, serial[0].Before(manager.spans[name][1]), name)
+	}
+	for _, name := range []string{"d", "e"} {
+		assert.False(t, manager.spans[name][0].Before(serial[1]), name)
+	}
+}
+
+func TestRunConcurrentSequentialByDefault(t *testing.T) {
+	manager := &slowManager{spans: make(map[string][2]time.Time)}
+	scripts := []model.ContainerScript{
+		{Order: 1, Name: "a", Content: "sleep 10", Parallel: true},
+		{Order: 2, Name: "b", Content: "sleep 10", Parallel: true},
+	}
+
+	_, err := NewRunner(manager).Run(&model.ContainerInstance{}, scripts, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, manager.peak)
+}
@@ -0,0 +1,79 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"time"
+)
+
+// ErrNoChecks 小节没有任何检测点，无法判断提交是否正确，通常是检测脚本配置有误
+var ErrNoChecks = errors.New("section has no checks")
+
+// Summary 一次提交的统计结果
+type Summary struct {
+	Passed     uint
+	Total      uint
+	Status     string
+	Score      float64
+	MaxScore   float64
+	Percentage float64
+}
+
+// Summarize 统计检测结果，得出提交的最终状态和得分。
+// 任意必须通过的检测点未通过时，整次提交记 0 分；没有检测点时为 error 状态，不计入成绩
+func Summarize(results []*model.CheckResult) Summary {
+	summary := Summary{
+		Total:  uint(len(results)),
+		Status: model.SubmissionPassed,
+	}
+	if len(results) == 0 {
+		summary.Status = model.SubmissionError
+		return summary
+	}
+
+	requiredFailed := false
+	for _, result := range results {
+		summary.MaxScore += result.MaxPoints
+		switch result.Status {
+		case model.CheckPass:
+			summary.Passed++
+			summary.Score += result.Points
+			continue
+		case model.CheckError:
+			summary.Status = model.SubmissionError
+		default:
+			if summary.Status != model.SubmissionError {
+				summary.Status = model.SubmissionFailed
+			}
+		}
+		if result.Required {
+			requiredFailed = true
+		}
+	}
+
+	if requiredFailed {
+		summary.Score = 0
+	}
+	summary.Percentage = Percentage(summary.Score, summary.MaxScore)
+
+	return summary
+}
+
+// Percentage 计算百分比得分，满分为 0 时视为 100
+func Percentage(score, maxScore float64) float64 {
+	if maxScore <= 0 {
+		return 100
+	}
+	return score * 100 / maxScore
+}
+
+// ApplyLatePenalty 按截止时间和迟交策略计算提交的最终得分，submittedAt 为学生提交的时间，
+// 重新评分时为原提交的时间。调用前 RawScore 和 MaxScore 需要已经设置
+func ApplyLatePenalty(submission *model.Submission, deadline *model.Deadline, submittedAt time.Time) {
+	submission.Late, submission.Penalty = false, 0
+	if deadline != nil {
+		submission.Late, submission.Penalty = deadline.Penalty(submittedAt)
+	}
+	submission.Score = submission.RawScore * (100 - submission.Penalty) / 100
+	submission.Percentage = Percentage(submission.Score, submission.MaxScore)
+}
@@ -0,0 +1,81 @@
+package grader
+
+import (
+	"awesomeProject/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize([]*model.CheckResult{
+		{Status: model.CheckPass, Points: 1, MaxPoints: 1},
+		{Status: model.CheckPass, Points: 3, MaxPoints: 3},
+	})
+	assert.Equal(t, uint(2), summary.Passed)
+	assert.Equal(t, uint(2), summary.Total)
+	assert.Equal(t, model.SubmissionPassed, summary.Status)
+	assert.Equal(t, 4.0, summary.Score)
+	assert.Equal(t, 100.0, summary.Percentage)
+
+	summary = Summarize([]*model.CheckResult{
+		{Status: model.CheckPass, Points: 1, MaxPoints: 1},
+		{Status: model.CheckFail, MaxPoints: 3},
+	})
+	assert.Equal(t, model.SubmissionFailed, summary.Status)
+	assert.Equal(t, 1.0, summary.Score)
+	assert.Equal(t, 4.0, summary.MaxScore)
+	assert.Equal(t, 25.0, summary.Percentage)
+
+	summary = Summarize([]*model.CheckResult{
+		{Status: model.CheckError, MaxPoints: 1},
+		{Status: model.CheckFail, MaxPoints: 1},
+	})
+	assert.Equal(t, model.SubmissionError, summary.Status)
+}
+
+func TestSummarizeRequired(t *testing.T) {
+	summary := Summarize([]*model.CheckResult{
+		{Status: model.CheckPass, Points: 3, MaxPoints: 3},
+		{Status: model.CheckFail, MaxPoints: 1, Required: true},
+	})
+	assert.Equal(t, model.SubmissionFailed, summary.Status)
+	assert.Equal(t, uint(1), summary.Passed)
+	assert.Equal(t, 0.0, summary.Score)
+	assert.Equal(t, 0.0, summary.Percentage)
+
+	// 必须通过的检测点通过时正常计分
+	summary = Summarize([]*model.CheckResult{
+		{Status: model.CheckPass, Points: 1, MaxPoints: 1, Required: true},
+		{Status: model.CheckFail, MaxPoints: 1},
+	})
+	assert.Equal(t, 1.0, summary.Score)
+	assert.Equal(t, 50.0, summary.Percentage)
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	// 只有准备脚本或套件为空时不能得满分
+	summary := Summarize(nil)
+	assert.Equal(t, model.SubmissionError, summary.Status)
+	assert.Equal(t, 0.0, summary.Score)
+	assert.Equal(t, 0.0, summary.Percentage)
+}
+
+func TestApplyLatePenalty(t *testing.T) {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	deadline := &model.Deadline{DueAt: &due, LatePolicy: model.LatePenalty, PenaltyPerDay: 20}
+
+	submission := &model.Submission{RawScore: 8, MaxScore: 10}
+	submission.CreatedAt = due.Add(30 * time.Hour)
+	ApplyLatePenalty(submission, deadline, submission.CreatedAt)
+	assert.True(t, submission.Late)
+	assert.Equal(t, 40.0, submission.Penalty)
+	assert.InDelta(t, 4.8, submission.Score, 1e-9)
+	assert.InDelta(t, 48.0, submission.Percentage, 1e-9)
+
+	// 没有截止时间时不扣分
+	ApplyLatePenalty(submission, nil, submission.CreatedAt)
+	assert.False(t, submission.Late)
+	assert.Equal(t, 8.0, submission.Score)
+	assert.Equal(t, 80.0, submission.Percentage)
+}
@@ -1,11 +1,18 @@
 package app
 
 import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quota"
 	"awesomeProject/internal/usecase"
+	"awesomeProject/pkg/message"
+	"errors"
 	"fmt"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -27,8 +34,26 @@ func CreateContainerHandler(c *gin.Context) {
 		return
 	}
 
-	err = usecase.NewContainerService().CreateContainer(userID.(uint), uint(templateID))
+	// 模板被多个小节使用时需要指定小节
+	var sectionID uint64
+	if raw := c.Query("section_id"); raw != "" {
+		if sectionID, err = strconv.ParseUint(raw, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid section_id"})
+			return
+		}
+	}
+
+	err = usecase.NewContainerService().CreateContainer(userID.(uint), uint(templateID), uint(sectionID))
 	if err != nil {
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "scope": exceeded.Scope})
+			return
+		}
+		if errors.Is(err, usecase.ErrAmbiguousSection) || errors.Is(err, usecase.ErrSectionTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -36,6 +61,46 @@ func CreateContainerHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "container creation started"})
 }
 
+// RemoveContainerHandler 停止并移除当前用户的容器
+// DELETE /api/v1/containers/{template_id}
+func RemoveContainerHandler(c *gin.Context) {
+	// Get user ID from context (assuming it's set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template_id"})
+		return
+	}
+
+	err = usecase.NewContainerService().RemoveContainer(userID.(uint), uint(templateID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "container removal started"})
+}
+
+// GetContainerUsageHandler 管理员查看容器资源占用
+// GET /api/v1/admin/containers/usage
+func GetContainerUsageHandler(c *gin.Context) {
+	usage, err := usecase.NewContainerService().GetContainerUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   usage,
+	})
+}
+
 func GetContainerStatusHandler(c *gin.Context) {
 
 	// Get user ID from context (assuming it's set by auth middleware)
@@ -109,6 +174,64 @@ func GetContainerHandler(c *gin.Context) {
 	})
 }
 
+// GetCheckAttemptsHandler 获取当前用户的检测次数状态
+// GET /api/v1/containers/{template_id}/attempts
+func GetCheckAttemptsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template_id"})
+		return
+	}
+
+	attempts, err := usecase.NewContainerService().GetCheckAttempts(userID.(uint), uint(templateID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": attempts})
+}
+
+// GetContainerSudoPasswordHandler 获取当前用户容器的SUDO密码
+// GET /api/v1/containers/{template_id}/sudo-password
+func GetContainerSudoPasswordHandler(c *gin.Context) {
+	// Get user ID from context (assuming it's set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template_id"})
+		return
+	}
+
+	password, err := usecase.NewContainerService().GetContainerSudoPassword(userID.(uint), uint(templateID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, usecase.ErrNoSudoPassword) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 密码不允许被缓存
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"sudo_password": password})
+}
+
+// CheckContainerHandler 提交检测并通过SSE推送检测过程的事件，超出检测次数限制时返回429。
+// 事件依次为 run-started、check-started / check-finished、run-finished，检测被终止时在 run-finished 前推送 error
+// GET /api/v1/containers/{template_id}/check
 func CheckContainerHandler(c *gin.Context) {
 	// Get user ID from context (assuming it's set by auth middleware)
 	userID, exists := c.Get("user_id")
@@ -123,12 +246,31 @@ func CheckContainerHandler(c *gin.Context) {
 		return
 	}
 
-	err = usecase.NewContainerService().CheckContainer(userID.(uint), uint(templateID))
+	attempts, err := usecase.NewContainerService().CheckContainer(userID.(uint), uint(templateID))
 	if err != nil {
+		var limited *quota.AttemptLimitError
+		if errors.As(err, &limited) {
+			if limited.Status.NextAllowedAt != nil {
+				retryAfter := int(math.Ceil(time.Until(*limited.Status.NextAllowedAt).Seconds()))
+				c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			}
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "attempts": limited.Status})
+			return
+		}
+		if errors.Is(err, model.ErrNotOpen) || errors.Is(err, model.ErrClosed) || errors.Is(err, model.ErrLateRejected) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 检测结果通过SSE返回，剩余次数放在响应头中
+	c.Header("X-Attempts-Remaining", strconv.Itoa(attempts.Remaining))
+	if attempts.NextAllowedAt != nil {
+		c.Header("X-Next-Attempt-At", attempts.NextAllowedAt.Format(time.RFC3339))
+	}
+
 	// TODO: 应该先直接获取channel一次，如果失败再重复获取，这样用户请求会更快得到结果
 
 	// 重复获取channel，持续3s,每0.5s获取一次，如果3s内没有获取到，则返回错误
@@ -162,13 +304,21 @@ func CheckContainerHandler(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 	c.Writer.Header().Set("Transfer-Encoding", "chunked")
 
+	// 检测过程的事件使用事件名和ID推送，事件内容为 JSON，通道关闭时检测结束
 	c.Stream(func(w io.Writer) bool {
-		if msg, ok := <-channel; ok {
-			// print time and msg
-			//fmt.Printf("%s: %s\n", time.Now().Format("2006-01-02 15:04:05"), msg)
+		msg, ok := <-channel
+		if !ok {
+			return false
+		}
+		if event, ok := message.ParseEvent(msg); ok {
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatUint(event.ID, 10),
+				Event: event.Name,
+				Data:  []byte(event.Data),
+			})
+		} else {
 			c.SSEvent("message", msg)
-			return true
 		}
-		return false
+		return true
 	})
 }
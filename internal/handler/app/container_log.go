@@ -0,0 +1,105 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"awesomeProject/pkg/container"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// 默认返回最后 200 行日志
+const defaultLogTail = "200"
+
+// GetContainerLogsHandler 以SSE的方式推送当前用户容器的日志
+// GET /api/v1/containers/{template_id}/logs?tail=200&since=10m&follow=true
+func GetContainerLogsHandler(c *gin.Context) {
+	// Get user ID from context (assuming it's set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template_id"})
+		return
+	}
+
+	options, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines, err := usecase.NewContainerService().GetContainerLogs(c.Request.Context(), userID.(uint), uint(templateID), options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamLogs(c, lines)
+}
+
+// GetInstanceLogsHandler 管理员以SSE的方式查看任意容器实例的日志
+// GET /api/v1/admin/containers/{instance_id}/logs?tail=200&since=10m&follow=true
+func GetInstanceLogsHandler(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("instance_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid instance_id"})
+		return
+	}
+
+	options, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines, err := usecase.NewContainerService().GetInstanceLogs(c.Request.Context(), uint(instanceID), options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamLogs(c, lines)
+}
+
+func parseLogOptions(c *gin.Context) (container.LogOptions, error) {
+	tail := c.DefaultQuery("tail", defaultLogTail)
+	if tail != "all" {
+		if _, err := strconv.ParseUint(tail, 10, 32); err != nil {
+			return container.LogOptions{}, errors.New("invalid tail, must be a number or all")
+		}
+	}
+
+	follow, err := strconv.ParseBool(c.DefaultQuery("follow", "true"))
+	if err != nil {
+		return container.LogOptions{}, errors.New("invalid follow")
+	}
+
+	return container.LogOptions{
+		Tail:   tail,
+		Since:  c.Query("since"),
+		Follow: follow,
+	}, nil
+}
+
+func streamLogs(c *gin.Context, lines <-chan container.LogLine) {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("Transfer-Encoding", "chunked")
+
+	// 客户端断开后请求的 context 会被取消，日志通道随之关闭
+	c.Stream(func(w io.Writer) bool {
+		if line, ok := <-lines; ok {
+			c.SSEvent("log", line)
+			return true
+		}
+		return false
+	})
+}
@@ -78,7 +78,13 @@ func GetCourseByIDHandler(c *gin.Context) {
 		return
 	}
 
-	course, err := courseService.GetCourseByID(uint(courseID))
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	course, err := courseService.GetCourseByID(userID.(uint), uint(courseID))
 	if err != nil {
 		// Use a more robust error check, e.g., errors.Is(err, gorm.ErrRecordNotFound)
 		if err.Error() == "record not found" { // Basic check, improve if possible
@@ -160,15 +166,22 @@ func GetCourseReferenceDownloadURLHandler(c *gin.Context) {
 
 // GetCourseExperimentStatusHandler handles the request to get user's course experiment status.
 // GET /api/v1/user/course-experiment-status?course_id={course_id}
-// Response format based on API spec:
+// data 为 model.UserSectionStatus 列表，gorm.Model 的字段保持原来的字段名:
 //
 //	{
 //	  "status": "success",
 //	  "data": [
 //	    {
+//	      "ID": 1,
+//	      "user_id": 1,
 //	      "section_id": 1,
-//	      "section_title": "Section Title",
-//	      "completed": true
+//	      "status": "completed",
+//	      "completed": true,
+//	      "completed_at": "2024-03-01T12:00:00Z",
+//	      "best_score": 8,
+//	      "max_score": 10,
+//	      "percentage": 80,
+//	      ...
 //	    }
 //	  ]
 //	}
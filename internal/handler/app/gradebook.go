@@ -0,0 +1,97 @@
+package app
+
+import (
+	"awesomeProject/internal/gradebook"
+	"awesomeProject/internal/usecase"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var gradebookService usecase.GradebookService
+
+func initGradebookService() {
+	gradebookService = usecase.NewGradebookService()
+}
+
+// ExportGradebookHandler 导出课程成绩册，支持按班级和提交时间筛选，教师只能导出自己授课的课程
+// GET /api/v1/teacher/courses/{course_id}/gradebook?format=csv|xlsx&cohort=&from=&to=
+func ExportGradebookHandler(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("course_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid course ID format"})
+		return
+	}
+	if !authorizeCourse(c, uint(courseID)) {
+		return
+	}
+
+	format := c.DefaultQuery("format", gradebook.FormatCSV)
+	if format != gradebook.FormatCSV && format != gradebook.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Format must be csv or xlsx"})
+		return
+	}
+	from, to, err := gradebook.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	gb, err := gradebookService.GetGradebook(uint(courseID), gradebook.Filter{
+		Cohort: c.Query("cohort"),
+		From:   from,
+		To:     to,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Course not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to build gradebook: " + err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gradebook.Write(&buf, gb, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("course-%d-gradebook.%s", courseID, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, gradebook.ContentType(format), buf.Bytes())
+}
+
+// SetUserCohortHandler 设置用户所属班级
+// PUT /api/v1/admin/users/{user_id}/cohort
+func SetUserCohortHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid user ID format"})
+		return
+	}
+
+	var req CohortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := gradebookService.SetCohort(uint(userID), req.Cohort); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "User not found"})
+		case errors.Is(err, usecase.ErrInvalidCohort):
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to set cohort: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
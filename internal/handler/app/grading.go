@@ -0,0 +1,208 @@
+package app
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quota"
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var gradingService usecase.GradingService
+
+// maxSubmissionBody 人工评分提交请求体的大小上限，在文件大小上限之外为文字作答和表单编码留出空间
+const maxSubmissionBody = usecase.MaxSubmissionFiles*usecase.MaxSubmissionFileSize + 1<<20
+
+func initGradingService() {
+	gradingService = usecase.NewGradingService()
+}
+
+// SubmitSectionHandler 提交人工评分小节的作答，表单字段 content 为文字作答，files 为上传的文件
+// POST /api/v1/sections/{section_id}/submissions
+func SubmitSectionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubmissionBody)
+	var headers []*multipart.FileHeader
+	form, err := c.MultipartForm()
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		headers = form.File["files"]
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "message": "Request body is too large"})
+		return
+	case !errors.Is(err, http.ErrNotMultipart):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid multipart form: " + err.Error()})
+		return
+	}
+
+	// 保存文件之前先检查数量和大小
+	files := make([]usecase.UploadedFile, 0, len(headers))
+	for _, header := range headers {
+		files = append(files, usecase.UploadedFile{Name: header.Filename, Size: header.Size})
+	}
+	if err := usecase.CheckSubmissionFiles(files); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	// 上传的文件先保存到临时目录，再由服务层上传到对象存储
+	dir, err := os.MkdirTemp("", "submission-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to save files: " + err.Error()})
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for i, header := range headers {
+		path := filepath.Join(dir, strconv.Itoa(i))
+		if err := c.SaveUploadedFile(header, path); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to save files: " + err.Error()})
+			return
+		}
+		files[i].Path = path
+	}
+
+	submission, err := gradingService.Submit(userID.(uint), uint(sectionID), c.PostForm("content"), files)
+	if err != nil {
+		var limited *quota.AttemptLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.JSON(http.StatusTooManyRequests, gin.H{"status": "error", "message": err.Error(), "attempts": limited.Status})
+		case errors.Is(err, model.ErrNotOpen) || errors.Is(err, model.ErrClosed) || errors.Is(err, model.ErrLateRejected):
+			c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		default:
+			respondGradingError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submission,
+	})
+}
+
+// GetGradingQueueHandler 获取课程的人工评分队列，status=graded 时返回已评分的提交，只能查看自己授课的课程
+// GET /api/v1/teacher/courses/{course_id}/grading?status=pending|graded
+func GetGradingQueueHandler(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("course_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid course ID format"})
+		return
+	}
+	if !authorizeCourse(c, uint(courseID)) {
+		return
+	}
+
+	var pending bool
+	switch c.DefaultQuery("status", "pending") {
+	case "pending":
+		pending = true
+	case "graded":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid status, expected pending or graded"})
+		return
+	}
+
+	submissions, err := gradingService.GetGradingQueue(uint(courseID), pending)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve grading queue: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submissions,
+	})
+}
+
+// GetGradingSubmissionHandler 教师查看自己授课课程中的提交详情，文件附带下载链接
+// GET /api/v1/teacher/submissions/{submission_id}
+func GetGradingSubmissionHandler(c *gin.Context) {
+	submissionID, err := strconv.ParseUint(c.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid submission ID format"})
+		return
+	}
+	if !authorizeSubmission(c, uint(submissionID)) {
+		return
+	}
+
+	submission, err := gradingService.GetGradingSubmission(uint(submissionID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Submission not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve submission: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submission,
+	})
+}
+
+// GradeSubmissionHandler 教师为自己授课课程中的提交评分并填写评语
+// POST /api/v1/teacher/submissions/{submission_id}/grade
+func GradeSubmissionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	submissionID, err := strconv.ParseUint(c.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid submission ID format"})
+		return
+	}
+
+	if !authorizeSubmission(c, uint(submissionID)) {
+		return
+	}
+
+	var req GradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	submission, err := gradingService.GradeSubmission(userID.(uint), uint(submissionID), req.toGrade())
+	if err != nil {
+		respondGradingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submission,
+	})
+}
+
+func respondGradingError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section or submission not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+}
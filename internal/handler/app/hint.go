@@ -0,0 +1,218 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var hintService usecase.HintService
+
+func initHintService() {
+	hintService = usecase.NewHintService()
+}
+
+// GetSectionHintsHandler 获取小节的提示及解锁状态，查看过的提示包含内容
+// GET /api/v1/sections/{section_id}/hints
+func GetSectionHintsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	hints, err := hintService.GetHints(userID.(uint), uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve hints: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   hints,
+	})
+}
+
+// ViewHintHandler 查看已解锁的提示，查看记录会展示给教师
+// POST /api/v1/sections/{section_id}/hints/{hint_id}/view
+func ViewHintHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+	hintID, err := strconv.ParseUint(c.Param("hint_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid hint ID format"})
+		return
+	}
+
+	hint, err := hintService.ViewHint(userID.(uint), uint(sectionID), uint(hintID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Hint not found"})
+		case errors.Is(err, usecase.ErrHintLocked):
+			c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to view hint: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   hint,
+	})
+}
+
+// GetHintsHandler 教师获取小节的所有提示
+// GET /api/v1/teacher/sections/{section_id}/hints
+func GetHintsHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+	if !authorizeSection(c, uint(sectionID)) {
+		return
+	}
+
+	hints, err := hintService.ListHints(uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve hints: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   hints,
+	})
+}
+
+// CreateHintHandler 为小节或检测点添加提示
+// POST /api/v1/teacher/sections/{section_id}/hints
+func CreateHintHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+	if !authorizeSection(c, uint(sectionID)) {
+		return
+	}
+
+	var req HintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	hint := req.toModel()
+	if err := hintService.CreateHint(uint(sectionID), hint); err != nil {
+		respondHintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   hint,
+	})
+}
+
+// UpdateHintHandler 更新提示
+// PUT /api/v1/teacher/hints/{hint_id}
+func UpdateHintHandler(c *gin.Context) {
+	hintID, err := strconv.ParseUint(c.Param("hint_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid hint ID format"})
+		return
+	}
+	if !authorizeHint(c, uint(hintID)) {
+		return
+	}
+
+	var req HintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	hint, err := hintService.UpdateHint(uint(hintID), req.toModel())
+	if err != nil {
+		respondHintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   hint,
+	})
+}
+
+// DeleteHintHandler 删除提示
+// DELETE /api/v1/teacher/hints/{hint_id}
+func DeleteHintHandler(c *gin.Context) {
+	hintID, err := strconv.ParseUint(c.Param("hint_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid hint ID format"})
+		return
+	}
+	if !authorizeHint(c, uint(hintID)) {
+		return
+	}
+
+	if err := hintService.DeleteHint(uint(hintID)); err != nil {
+		respondHintError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// GetHintUsageHandler 查看小节每个提示被哪些学生查看过
+// GET /api/v1/teacher/sections/{section_id}/hints/usage
+func GetHintUsageHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+	if !authorizeSection(c, uint(sectionID)) {
+		return
+	}
+
+	usage, err := hintService.GetHintUsage(uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve hint usage: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   usage,
+	})
+}
+
+func respondHintError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Hint or section not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+}
@@ -3,4 +3,13 @@ package app
 func InitApp() {
 	initUserService()
 	initCourseService()
+	initSecurityProfileService()
+	initSubmissionService()
+	initRegradeService()
+	initSuiteService()
+	initSimilarityService()
+	initHintService()
+	initGradingService()
+	initQuizService()
+	initGradebookService()
 }
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quota"
+	"awesomeProject/internal/repository"
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var quizService usecase.QuizService
+
+func initQuizService() {
+	quizService = usecase.NewQuizService()
+}
+
+// StartQuizHandler 开始测验，已有未提交的作答时返回该作答
+// POST /api/v1/sections/{section_id}/quiz
+func StartQuizHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	attempt, err := quizService.StartQuiz(userID.(uint), uint(sectionID))
+	if err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   attempt,
+	})
+}
+
+// GetQuizAttemptsHandler 获取当前用户在小节中的测验作答记录
+// GET /api/v1/sections/{section_id}/quiz/attempts
+func GetQuizAttemptsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	attempts, err := quizService.ListAttempts(userID.(uint), uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve quiz attempts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   attempts,
+	})
+}
+
+// GetQuizAttemptHandler 获取测验作答详情，提交后包含评分和解析
+// GET /api/v1/quiz/attempts/{attempt_id}
+func GetQuizAttemptHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	attemptID, err := strconv.ParseUint(c.Param("attempt_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid attempt ID format"})
+		return
+	}
+
+	attempt, err := quizService.GetAttempt(userID.(uint), uint(attemptID))
+	if err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   attempt,
+	})
+}
+
+// SubmitQuizHandler 提交测验作答并返回评分结果
+// POST /api/v1/quiz/attempts/{attempt_id}/submit
+func SubmitQuizHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	attemptID, err := strconv.ParseUint(c.Param("attempt_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid attempt ID format"})
+		return
+	}
+
+	var req SubmitQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	attempt, err := quizService.SubmitQuiz(userID.(uint), uint(attemptID), req.toResponses())
+	if err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   attempt,
+	})
+}
+
+// GetQuizQuestionsHandler 教师获取测验小节的题目及答案
+// GET /api/v1/teacher/sections/{section_id}/questions
+func GetQuizQuestionsHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	questions, err := quizService.ListQuestions(uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve questions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   questions,
+	})
+}
+
+// CreateQuizQuestionHandler 为测验小节添加题目
+// POST /api/v1/teacher/sections/{section_id}/questions
+func CreateQuizQuestionHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	var req QuizQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	question := req.toModel()
+	if err := quizService.CreateQuestion(uint(sectionID), question); err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   question,
+	})
+}
+
+// UpdateQuizQuestionHandler 更新测验题目
+// PUT /api/v1/teacher/questions/{question_id}
+func UpdateQuizQuestionHandler(c *gin.Context) {
+	questionID, err := strconv.ParseUint(c.Param("question_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid question ID format"})
+		return
+	}
+
+	var req QuizQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	question, err := quizService.UpdateQuestion(uint(questionID), req.toModel())
+	if err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   question,
+	})
+}
+
+// DeleteQuizQuestionHandler 删除测验题目
+// DELETE /api/v1/teacher/questions/{question_id}
+func DeleteQuizQuestionHandler(c *gin.Context) {
+	questionID, err := strconv.ParseUint(c.Param("question_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid question ID format"})
+		return
+	}
+
+	if err := quizService.DeleteQuestion(uint(questionID)); err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+func respondQuizError(c *gin.Context, err error) {
+	var limited *quota.AttemptLimitError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section, question or attempt not found"})
+	case errors.As(err, &limited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"status": "error", "message": err.Error(), "attempts": limited.Status})
+	case errors.Is(err, model.ErrNotOpen) || errors.Is(err, model.ErrClosed) || errors.Is(err, model.ErrLateRejected):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, repository.ErrAttemptSubmitted):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var regradeService usecase.RegradeService
+
+func initRegradeService() {
+	regradeService = usecase.NewRegradeService()
+}
+
+// RegradeRequest 发起重新评分的请求体
+type RegradeRequest struct {
+	SectionID uint `json:"section_id" binding:"required"`
+}
+
+// CreateRegradeHandler 修改检测脚本后，用当前脚本重新检测小节内所有学生最近一次的提交
+// POST /api/v1/admin/regrades
+func CreateRegradeHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	var req RegradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	job, err := regradeService.StartRegrade(userID.(uint), req.SectionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section not found"})
+		case errors.Is(err, usecase.ErrSectionNotGradable):
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to start regrade: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   job,
+	})
+}
+
+// GetRegradesHandler 获取所有重新评分任务及其进度
+// GET /api/v1/admin/regrades
+func GetRegradesHandler(c *gin.Context) {
+	jobs, err := regradeService.ListRegradeJobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve regrade jobs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   jobs,
+	})
+}
+
+// GetRegradeHandler 获取重新评分任务的进度和每个学生的得分变化
+// GET /api/v1/admin/regrades/{job_id}
+func GetRegradeHandler(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid job ID format"})
+		return
+	}
+
+	job, err := regradeService.GetRegradeJob(uint(jobID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Regrade job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve regrade job: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   job,
+	})
+}
@@ -1,6 +1,154 @@
 package app
 
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quiz"
+	"awesomeProject/internal/usecase"
+)
+
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+type SecurityProfileRequest struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	CapAdd          string `json:"cap_add"`
+	CapDrop         string `json:"cap_drop"`
+	SeccompProfile  string `json:"seccomp_profile"`
+	AppArmorProfile string `json:"apparmor_profile"`
+	ReadOnlyRootfs  bool   `json:"read_only_rootfs"`
+	NoNewPrivileges bool   `json:"no_new_privileges"`
+	UsernsMode      string `json:"userns_mode"`
+}
+
+func (r *SecurityProfileRequest) toModel() *model.SecurityProfile {
+	return &model.SecurityProfile{
+		Name:            r.Name,
+		Description:     r.Description,
+		CapAdd:          r.CapAdd,
+		CapDrop:         r.CapDrop,
+		SeccompProfile:  r.SeccompProfile,
+		AppArmorProfile: r.AppArmorProfile,
+		ReadOnlyRootfs:  r.ReadOnlyRootfs,
+		NoNewPrivileges: r.NoNewPrivileges,
+		UsernsMode:      r.UsernsMode,
+	}
+}
+
+// HintRequest 创建或更新提示的请求体
+type HintRequest struct {
+	CheckName           string `json:"check_name"`
+	Order               uint   `json:"order"`
+	Title               string `json:"title"`
+	Content             string `json:"content"`
+	UnlockAfterFailures uint   `json:"unlock_after_failures"`
+	UnlockAfterMinutes  uint   `json:"unlock_after_minutes"`
+}
+
+func (r *HintRequest) toModel() *model.Hint {
+	return &model.Hint{
+		CheckName:           r.CheckName,
+		Order:               r.Order,
+		Title:               r.Title,
+		Content:             r.Content,
+		UnlockAfterFailures: r.UnlockAfterFailures,
+		UnlockAfterMinutes:  r.UnlockAfterMinutes,
+	}
+}
+
+// GradeRequest 教师评分的请求体
+type GradeRequest struct {
+	Score    *float64 `json:"score" binding:"required"`
+	Passed   bool     `json:"passed"`
+	Feedback string   `json:"feedback"`
+}
+
+func (r *GradeRequest) toGrade() usecase.Grade {
+	return usecase.Grade{
+		Score:    *r.Score,
+		Passed:   r.Passed,
+		Feedback: r.Feedback,
+	}
+}
+
+// QuizQuestionRequest 创建或更新测验题目的请求体，选项按数组顺序排列。
+// 更新时带 id 的选项原地修改，不带 id 的选项新建，省略的已有选项被删除
+type QuizQuestionRequest struct {
+	Order         uint                `json:"order"`
+	Type          string              `json:"type" binding:"required"`
+	Prompt        string              `json:"prompt" binding:"required"`
+	Answer        string              `json:"answer"`
+	Tolerance     float64             `json:"tolerance"`
+	CaseSensitive bool                `json:"case_sensitive"`
+	Points        *float64            `json:"points"`
+	Explanation   string              `json:"explanation"`
+	Options       []QuizOptionRequest `json:"options"`
+}
+
+type QuizOptionRequest struct {
+	ID      uint   `json:"id"`
+	Content string `json:"content"`
+	Correct bool   `json:"correct"`
+}
+
+func (r *QuizQuestionRequest) toModel() *model.QuizQuestion {
+	question := &model.QuizQuestion{
+		Order:         r.Order,
+		Type:          r.Type,
+		Prompt:        r.Prompt,
+		Answer:        r.Answer,
+		Tolerance:     r.Tolerance,
+		CaseSensitive: r.CaseSensitive,
+		Points:        1,
+		Explanation:   r.Explanation,
+	}
+	if r.Points != nil {
+		question.Points = *r.Points
+	}
+	for i, option := range r.Options {
+		opt := model.QuizOption{
+			Order:   uint(i + 1),
+			Content: option.Content,
+			Correct: option.Correct,
+		}
+		opt.ID = option.ID
+		question.Options = append(question.Options, opt)
+	}
+	return question
+}
+
+// SubmitQuizRequest 提交测验作答的请求体，选择题使用 option_ids，其余题型使用 response
+type SubmitQuizRequest struct {
+	Answers []QuizAnswerRequest `json:"answers"`
+}
+
+type QuizAnswerRequest struct {
+	QuestionID uint   `json:"question_id" binding:"required"`
+	Response   string `json:"response"`
+	OptionIDs  []uint `json:"option_ids"`
+}
+
+func (r *SubmitQuizRequest) toResponses() map[uint]quiz.Response {
+	responses := make(map[uint]quiz.Response, len(r.Answers))
+	for _, answer := range r.Answers {
+		responses[answer.QuestionID] = quiz.Response{Text: answer.Response, OptionIDs: answer.OptionIDs}
+	}
+	return responses
+}
+
+// CohortRequest 设置用户班级的请求体，cohort 为空时清除班级
+type CohortRequest struct {
+	Cohort string `json:"cohort"`
+}
+
+// CourseTeacherRequest 添加课程授课教师的请求体
+type CourseTeacherRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// SolutionFilesRequest 设置小节查重答案文件的请求体，files 为容器中的绝对路径，为空时清除
+type SolutionFilesRequest struct {
+	Files []string `json:"files"`
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var securityProfileService usecase.SecurityProfileService
+
+func initSecurityProfileService() {
+	securityProfileService = usecase.NewSecurityProfileService()
+}
+
+// GetAllSecurityProfilesHandler 获取所有安全配置
+// GET /api/v1/admin/security-profiles
+func GetAllSecurityProfilesHandler(c *gin.Context) {
+	profiles, err := securityProfileService.GetAllProfiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve security profiles: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   profiles,
+	})
+}
+
+// GetSecurityProfileHandler 获取指定安全配置
+// GET /api/v1/admin/security-profiles/{profile_id}
+func GetSecurityProfileHandler(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("profile_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid profile ID format"})
+		return
+	}
+
+	profile, err := securityProfileService.GetProfile(uint(profileID))
+	if err != nil {
+		respondSecurityProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   profile,
+	})
+}
+
+// CreateSecurityProfileHandler 创建安全配置
+// POST /api/v1/admin/security-profiles
+func CreateSecurityProfileHandler(c *gin.Context) {
+	var req SecurityProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	profile := req.toModel()
+	if err := securityProfileService.CreateProfile(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   profile,
+	})
+}
+
+// UpdateSecurityProfileHandler 更新安全配置
+// PUT /api/v1/admin/security-profiles/{profile_id}
+func UpdateSecurityProfileHandler(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("profile_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid profile ID format"})
+		return
+	}
+
+	var req SecurityProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	profile, err := securityProfileService.UpdateProfile(uint(profileID), req.toModel())
+	if err != nil {
+		respondSecurityProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   profile,
+	})
+}
+
+// DeleteSecurityProfileHandler 删除安全配置
+// DELETE /api/v1/admin/security-profiles/{profile_id}
+func DeleteSecurityProfileHandler(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("profile_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid profile ID format"})
+		return
+	}
+
+	if err := securityProfileService.DeleteProfile(uint(profileID)); err != nil {
+		respondSecurityProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+func respondSecurityProfileError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Security profile not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+}
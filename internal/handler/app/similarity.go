@@ -0,0 +1,160 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var similarityService usecase.SimilarityService
+
+func initSimilarityService() {
+	similarityService = usecase.NewSimilarityService()
+}
+
+// CreateSimilarityReportHandler 对小节内所有学生的答案文件发起查重
+// POST /api/v1/admin/sections/{section_id}/similarity
+func CreateSimilarityReportHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	report, err := similarityService.StartAnalysis(userID.(uint), uint(sectionID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section not found"})
+		case errors.Is(err, usecase.ErrSectionNotGradable), errors.Is(err, usecase.ErrNoSolutionFiles):
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to start similarity analysis: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// SetSolutionFilesHandler 设置小节查重时收集的答案文件
+// PUT /api/v1/admin/sections/{section_id}/similarity/files
+func SetSolutionFilesHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	var req SolutionFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request: " + err.Error()})
+		return
+	}
+
+	files, err := similarityService.SetSolutionFiles(uint(sectionID), req.Files)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section not found"})
+		case errors.Is(err, usecase.ErrInvalidSolutionFile):
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to set solution files: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   gin.H{"files": files},
+	})
+}
+
+// GetSimilarityReportsHandler 获取小节的所有查重报告
+// GET /api/v1/admin/sections/{section_id}/similarity
+func GetSimilarityReportsHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	reports, err := similarityService.ListReports(uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve similarity reports: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   reports,
+	})
+}
+
+// GetSimilarityReportHandler 获取查重报告，学生对按相似度从高到低排列
+// GET /api/v1/admin/similarity/{report_id}
+func GetSimilarityReportHandler(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("report_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid report ID format"})
+		return
+	}
+
+	report, err := similarityService.GetReport(uint(reportID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Similarity report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve similarity report: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// GetSimilarityPairHandler 获取两个学生的答案、逐行差异和相同的代码片段，用于并排对比
+// GET /api/v1/admin/similarity/{report_id}/pairs/{pair_id}
+func GetSimilarityPairHandler(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("report_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid report ID format"})
+		return
+	}
+	pairID, err := strconv.ParseUint(c.Param("pair_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid pair ID format"})
+		return
+	}
+
+	pair, err := similarityService.GetPair(uint(reportID), uint(pairID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Similarity pair not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve similarity pair: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   pair,
+	})
+}
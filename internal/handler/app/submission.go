@@ -0,0 +1,78 @@
+package app
+
+import (
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var submissionService usecase.SubmissionService
+
+func initSubmissionService() {
+	submissionService = usecase.NewSubmissionService()
+}
+
+// GetSubmissionsHandler 获取当前用户的检测提交历史
+// GET /api/v1/submissions?section_id={section_id}
+func GetSubmissionsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	var sectionID uint64
+	if value := c.Query("section_id"); value != "" {
+		var err error
+		sectionID, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+			return
+		}
+	}
+
+	submissions, err := submissionService.ListSubmissions(userID.(uint), uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve submissions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submissions,
+	})
+}
+
+// GetSubmissionHandler 获取检测提交详情及各检测点结果
+// GET /api/v1/submissions/{submission_id}
+func GetSubmissionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	submissionID, err := strconv.ParseUint(c.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid submission ID format"})
+		return
+	}
+
+	submission, err := submissionService.GetSubmission(userID.(uint), uint(submissionID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Submission not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve submission: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   submission,
+	})
+}
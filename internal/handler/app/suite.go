@@ -0,0 +1,139 @@
+package app
+
+import (
+	"awesomeProject/internal/suite"
+	"awesomeProject/internal/usecase"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+var suiteService usecase.SuiteService
+
+func initSuiteService() {
+	suiteService = usecase.NewSuiteService()
+}
+
+// ImportSuiteHandler 上传 tar.gz 格式的检测套件并导入到小节，表单字段为 file
+// POST /api/v1/admin/sections/{section_id}/suites
+func ImportSuiteHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return
+	}
+
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Missing suite archive: " + err.Error()})
+		return
+	}
+	if header.Size > suite.MaxArchiveSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "message": suite.ErrArchiveTooLarge.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	defer file.Close()
+	archive, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	imported, err := suiteService.ImportArchive(userID.(uint), uint(sectionID), archive)
+	if err != nil {
+		respondSuiteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   imported,
+	})
+}
+
+// GetSuitesHandler 获取小节所有版本的检测套件
+// GET /api/v1/admin/sections/{section_id}/suites
+func GetSuitesHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	suites, err := suiteService.ListSuites(uint(sectionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve suites: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   suites,
+	})
+}
+
+// ExportSuiteHandler 下载小节的检测套件，version 为空或 0 时导出当前的检测脚本
+// GET /api/v1/admin/sections/{section_id}/suites/export?version={version}
+func ExportSuiteHandler(c *gin.Context) {
+	sectionID, err := strconv.ParseUint(c.Param("section_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid section ID format"})
+		return
+	}
+
+	var version uint64
+	if value := c.Query("version"); value != "" {
+		version, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid version format"})
+			return
+		}
+	}
+
+	exported, err := suiteService.ExportSuite(uint(sectionID), uint(version))
+	if err != nil {
+		respondSuiteError(c, err)
+		return
+	}
+	archive, err := exported.Archive()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("section-%d-suite.tar.gz", sectionID)
+	if version != 0 {
+		filename = fmt.Sprintf("section-%d-suite-v%d.tar.gz", sectionID, version)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/gzip", archive)
+}
+
+// respondSuiteError 将检测套件服务的错误转换为对应的HTTP状态码
+func respondSuiteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Section or suite not found"})
+	case errors.Is(err, suite.ErrArchiveTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, usecase.ErrSectionNotGradable), errors.Is(err, suite.ErrInvalidSuite):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/usecase"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// AddCourseTeacherHandler 添加课程的授课教师
+// POST /api/v1/admin/courses/{course_id}/teachers
+func AddCourseTeacherHandler(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("course_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid course ID format"})
+		return
+	}
+
+	var req CourseTeacherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := courseService.AddCourseTeacher(uint(courseID), req.UserID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Course or user not found"})
+		case errors.Is(err, usecase.ErrNotTeacher):
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to add teacher: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// RemoveCourseTeacherHandler 移除课程的授课教师
+// DELETE /api/v1/admin/courses/{course_id}/teachers/{user_id}
+func RemoveCourseTeacherHandler(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("course_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid course ID format"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid user ID format"})
+		return
+	}
+
+	if err := courseService.RemoveCourseTeacher(uint(courseID), uint(userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to remove teacher: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// authorizeCourse 检查当前用户可以管理课程，管理员可以管理所有课程；不允许时写入响应并返回 false
+func authorizeCourse(c *gin.Context, courseID uint) bool {
+	return authorizeTeacher(c, func(userID uint) error {
+		return courseService.CheckCourseTeacher(userID, courseID)
+	})
+}
+
+// authorizeSection 检查当前用户可以管理小节所属的课程
+func authorizeSection(c *gin.Context, sectionID uint) bool {
+	return authorizeTeacher(c, func(userID uint) error {
+		return courseService.CheckSectionTeacher(userID, sectionID)
+	})
+}
+
+// authorizeHint 检查当前用户可以管理提示所在小节所属的课程
+func authorizeHint(c *gin.Context, hintID uint) bool {
+	return authorizeTeacher(c, func(userID uint) error {
+		hint, err := hintService.GetHint(hintID)
+		if err != nil {
+			return err
+		}
+		return courseService.CheckSectionTeacher(userID, hint.SectionID)
+	})
+}
+
+// authorizeSubmission 检查当前用户可以管理提交所属的课程
+func authorizeSubmission(c *gin.Context, submissionID uint) bool {
+	return authorizeTeacher(c, func(userID uint) error {
+		return courseService.CheckSubmissionTeacher(userID, submissionID)
+	})
+}
+
+// authorizeTeacher 管理员直接放行，教师由 check 判断是否为授课教师，需要在 RoleMiddleware 之后使用
+func authorizeTeacher(c *gin.Context, check func(userID uint) error) bool {
+	if role, _ := c.Get("user_role"); role == model.RoleAdmin {
+		return true
+	}
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Unauthorized"})
+		return false
+	}
+
+	err := check(userID.(uint))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usecase.ErrNotCourseTeacher):
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "Permission denied"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to check permission: " + err.Error()})
+	}
+	return false
+}
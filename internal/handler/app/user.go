@@ -2,12 +2,10 @@ package app
 
 import (
 	"awesomeProject/internal/model"
-	"awesomeProject/internal/repository"
 	"awesomeProject/internal/usecase"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -17,38 +15,6 @@ func initUserService() {
 	userService = usecase.NewUserService()
 }
 
-const bzImage = "/home/skynesser/code/system_call/linux-6.2/arch/x86/boot/bzImage"
-
-// CheckAnswer 检查bzImage是否存在
-func CheckAnswer(c *gin.Context) {
-
-	// 从前端取出sectionID
-	sectionID := c.Query("section_id")
-	var sectionIDUint uint
-	fmt.Sscanf(sectionID, "%d", &sectionIDUint)
-
-	// 检查bzImage是否存在
-	_, err := os.Stat(bzImage)
-	if err != nil {
-		// 不存在则告知用户答案错误，注意请求是合法的
-		c.JSON(http.StatusOK, gin.H{"answer": "false"})
-		return
-	}
-
-	// 存在则告知用户答案正确，注意请求是合法的，并修改userSectionStatus
-	// 修改userSectionStatus，userID为1，sectionID为sectionIDUint
-	err = repository.UpdateUserSectionStatus(&model.UserSectionStatus{
-		UserID:    1,
-		SectionID: sectionIDUint,
-		Completed: true,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"answer": "true"})
-}
-
 // RegisterHandler 用户注册处理函数
 func RegisterHandler(c *gin.Context) {
 	var user model.User
@@ -3,6 +3,7 @@ package handler
 import (
 	"awesomeProject/internal/handler/app"
 	"awesomeProject/internal/handler/middleware"
+	"awesomeProject/internal/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +18,6 @@ func RegisterRoutes(r *gin.Engine) {
 	r.GET("/hello", helloHandler)
 	r.GET("/refresh", middleware.RefreshTokenHandler)
 
-	r.GET("/check", app.CheckAnswer)
-
 	// 用户认证相关路由
 	r.POST("/api/v1/register", app.RegisterHandler) // 用户注册
 	r.POST("/api/v1/login", app.LoginHandler)       // 用户登录
@@ -45,8 +44,79 @@ func RegisterRoutes(r *gin.Engine) {
 	{
 		containerGroup.POST("/create", app.CreateContainerHandler)
 		containerGroup.GET("/:template_id/check", app.CheckContainerHandler)
+		containerGroup.GET("/:template_id/attempts", app.GetCheckAttemptsHandler)
 		containerGroup.GET("/:template_id", app.GetContainerHandler)
+		containerGroup.DELETE("/:template_id", app.RemoveContainerHandler)
 		containerGroup.GET("/:template_id/status", app.GetContainerStatusHandler)
+		containerGroup.GET("/:template_id/sudo-password", app.GetContainerSudoPasswordHandler)
+		containerGroup.GET("/:template_id/logs", app.GetContainerLogsHandler)
+	}
+
+	submissionGroup := auth.Group("/submissions")
+	{
+		submissionGroup.GET("", app.GetSubmissionsHandler)
+		submissionGroup.GET("/:submission_id", app.GetSubmissionHandler)
+	}
+
+	sectionGroup := auth.Group("/sections")
+	{
+		sectionGroup.GET("/:section_id/hints", app.GetSectionHintsHandler)
+		sectionGroup.POST("/:section_id/hints/:hint_id/view", app.ViewHintHandler)
+		sectionGroup.POST("/:section_id/submissions", app.SubmitSectionHandler)
+		sectionGroup.POST("/:section_id/quiz", app.StartQuizHandler)
+		sectionGroup.GET("/:section_id/quiz/attempts", app.GetQuizAttemptsHandler)
+	}
+
+	quizGroup := auth.Group("/quiz")
+	{
+		quizGroup.GET("/attempts/:attempt_id", app.GetQuizAttemptHandler)
+		quizGroup.POST("/attempts/:attempt_id/submit", app.SubmitQuizHandler)
+	}
+
+	// 教师路由，管理员同样可以访问；教师只能管理自己授课的课程
+	teacherGroup := auth.Group("/teacher")
+	teacherGroup.Use(middleware.RoleMiddleware(model.RoleTeacher, model.RoleAdmin))
+	{
+		teacherGroup.GET("/sections/:section_id/hints", app.GetHintsHandler)
+		teacherGroup.POST("/sections/:section_id/hints", app.CreateHintHandler)
+		teacherGroup.GET("/sections/:section_id/hints/usage", app.GetHintUsageHandler)
+		teacherGroup.PUT("/hints/:hint_id", app.UpdateHintHandler)
+		teacherGroup.DELETE("/hints/:hint_id", app.DeleteHintHandler)
+		teacherGroup.GET("/sections/:section_id/questions", app.GetQuizQuestionsHandler)
+		teacherGroup.POST("/sections/:section_id/questions", app.CreateQuizQuestionHandler)
+		teacherGroup.PUT("/questions/:question_id", app.UpdateQuizQuestionHandler)
+		teacherGroup.DELETE("/questions/:question_id", app.DeleteQuizQuestionHandler)
+		teacherGroup.GET("/courses/:course_id/grading", app.GetGradingQueueHandler)
+		teacherGroup.GET("/submissions/:submission_id", app.GetGradingSubmissionHandler)
+		teacherGroup.POST("/submissions/:submission_id/grade", app.GradeSubmissionHandler)
+		teacherGroup.GET("/courses/:course_id/gradebook", app.ExportGradebookHandler)
+	}
+
+	// 管理员路由
+	adminGroup := auth.Group("/admin")
+	adminGroup.Use(middleware.RoleMiddleware(model.RoleAdmin))
+	{
+		adminGroup.GET("/security-profiles", app.GetAllSecurityProfilesHandler)
+		adminGroup.GET("/security-profiles/:profile_id", app.GetSecurityProfileHandler)
+		adminGroup.POST("/security-profiles", app.CreateSecurityProfileHandler)
+		adminGroup.PUT("/security-profiles/:profile_id", app.UpdateSecurityProfileHandler)
+		adminGroup.DELETE("/security-profiles/:profile_id", app.DeleteSecurityProfileHandler)
+		adminGroup.GET("/containers/usage", app.GetContainerUsageHandler)
+		adminGroup.GET("/containers/:instance_id/logs", app.GetInstanceLogsHandler)
+		adminGroup.POST("/regrades", app.CreateRegradeHandler)
+		adminGroup.GET("/regrades", app.GetRegradesHandler)
+		adminGroup.GET("/regrades/:job_id", app.GetRegradeHandler)
+		adminGroup.POST("/sections/:section_id/suites", app.ImportSuiteHandler)
+		adminGroup.GET("/sections/:section_id/suites", app.GetSuitesHandler)
+		adminGroup.GET("/sections/:section_id/suites/export", app.ExportSuiteHandler)
+		adminGroup.POST("/sections/:section_id/similarity", app.CreateSimilarityReportHandler)
+		adminGroup.GET("/sections/:section_id/similarity", app.GetSimilarityReportsHandler)
+		adminGroup.PUT("/sections/:section_id/similarity/files", app.SetSolutionFilesHandler)
+		adminGroup.GET("/similarity/:report_id", app.GetSimilarityReportHandler)
+		adminGroup.GET("/similarity/:report_id/pairs/:pair_id", app.GetSimilarityPairHandler)
+		adminGroup.PUT("/users/:user_id/cohort", app.SetUserCohortHandler)
+		adminGroup.POST("/courses/:course_id/teachers", app.AddCourseTeacherHandler)
+		adminGroup.DELETE("/courses/:course_id/teachers/:user_id", app.RemoveCourseTeacherHandler)
 	}
 
 }
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RoleMiddleware 校验当前用户是否拥有指定角色之一，需要在 JWTAuthMiddleware 之后使用
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		user, err := repository.NewUserRepository(db.DB).GetUserByID(userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Set("user_role", user.Role)
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"message": "Permission denied"})
+		c.Abort()
+	}
+}
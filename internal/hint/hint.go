@@ -0,0 +1,77 @@
+package hint
+
+import (
+	"awesomeProject/internal/model"
+	"sort"
+	"time"
+)
+
+// Progress 用户在小节中的检测情况，用于判断提示是否解锁
+type Progress struct {
+	Failures       int64            // 未通过的提交次数
+	CheckFailures  map[string]int64 // 每个检测点未通过的次数，按检测点名称统计
+	FirstAttemptAt *time.Time       // 第一次提交的时间，没有提交时为空
+}
+
+// Status 单个提示对用户的解锁状态
+type Status struct {
+	Hint              model.Hint
+	Unlocked          bool
+	FailuresRemaining int64      // 还需要失败多少次才能解锁，不按失败次数解锁时为0
+	UnlockAt          *time.Time // 按时间解锁的时间，尚未提交过时为空
+}
+
+// Evaluate 计算每个提示的解锁状态。检测点的提示按该检测点的失败次数计算，小节的提示按提交的失败次数计算；
+// 失败次数和时间任一条件满足即解锁，同一检测点（或小节）的提示按顺序解锁，前一个未解锁时后面的也不解锁
+func Evaluate(hints []model.Hint, progress Progress, now time.Time) []Status {
+	sorted := make([]model.Hint, len(hints))
+	copy(sorted, hints)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CheckName != sorted[j].CheckName {
+			return sorted[i].CheckName < sorted[j].CheckName
+		}
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	statuses := make([]Status, 0, len(sorted))
+	locked := make(map[string]bool) // 检测点名称 -> 前面是否有未解锁的提示
+	for _, h := range sorted {
+		status := evaluateOne(h, progress, now)
+		if locked[h.CheckName] {
+			status.Unlocked = false
+		}
+		if !status.Unlocked {
+			locked[h.CheckName] = true
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func evaluateOne(h model.Hint, progress Progress, now time.Time) Status {
+	status := Status{Hint: h}
+	if h.UnlockAfterFailures == 0 && h.UnlockAfterMinutes == 0 {
+		status.Unlocked = true
+		return status
+	}
+
+	if h.UnlockAfterFailures > 0 {
+		failures := progress.Failures
+		if h.CheckName != "" {
+			failures = progress.CheckFailures[h.CheckName]
+		}
+		status.FailuresRemaining = max(int64(h.UnlockAfterFailures)-failures, 0)
+		if status.FailuresRemaining == 0 {
+			status.Unlocked = true
+		}
+	}
+
+	if h.UnlockAfterMinutes > 0 && progress.FirstAttemptAt != nil {
+		unlockAt := progress.FirstAttemptAt.Add(time.Duration(h.UnlockAfterMinutes) * time.Minute)
+		status.UnlockAt = &unlockAt
+		if !now.Before(unlockAt) {
+			status.Unlocked = true
+		}
+	}
+	return status
+}
@@ -0,0 +1,66 @@
+package hint
+
+import (
+	"awesomeProject/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	hints := []model.Hint{
+		{Order: 2, UnlockAfterFailures: 3},
+		{Order: 1, UnlockAfterFailures: 1},
+		{Order: 3, UnlockAfterMinutes: 60},
+		{CheckName: "fork", Order: 1, UnlockAfterFailures: 2},
+		{CheckName: "wait", Order: 1},
+	}
+	progress := Progress{
+		Failures:       2,
+		CheckFailures:  map[string]int64{"fork": 2},
+		FirstAttemptAt: &first,
+	}
+
+	statuses := Evaluate(hints, progress, first.Add(90*time.Minute))
+	assert.Len(t, statuses, 5)
+
+	// 小节的提示按顺序排列
+	assert.Equal(t, uint(1), statuses[0].Hint.Order)
+	assert.True(t, statuses[0].Unlocked)
+
+	assert.Equal(t, uint(2), statuses[1].Hint.Order)
+	assert.False(t, statuses[1].Unlocked)
+	assert.Equal(t, int64(1), statuses[1].FailuresRemaining)
+
+	// 时间已到，但前一个提示未解锁
+	assert.Equal(t, uint(3), statuses[2].Hint.Order)
+	assert.False(t, statuses[2].Unlocked)
+	assert.Equal(t, first.Add(time.Hour), *statuses[2].UnlockAt)
+
+	// 检测点的提示按该检测点的失败次数计算
+	assert.Equal(t, "fork", statuses[3].Hint.CheckName)
+	assert.True(t, statuses[3].Unlocked)
+
+	// 没有条件的提示直接解锁
+	assert.True(t, statuses[4].Unlocked)
+}
+
+func TestEvaluateTime(t *testing.T) {
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	hints := []model.Hint{{Order: 1, UnlockAfterFailures: 5, UnlockAfterMinutes: 30}}
+
+	// 还没有提交时不开始计时
+	statuses := Evaluate(hints, Progress{}, first)
+	assert.False(t, statuses[0].Unlocked)
+	assert.Nil(t, statuses[0].UnlockAt)
+
+	progress := Progress{Failures: 1, FirstAttemptAt: &first}
+	statuses = Evaluate(hints, progress, first.Add(29*time.Minute))
+	assert.False(t, statuses[0].Unlocked)
+	assert.Equal(t, int64(4), statuses[0].FailuresRemaining)
+
+	// 失败次数未达到，但时间已到
+	statuses = Evaluate(hints, progress, first.Add(30*time.Minute))
+	assert.True(t, statuses[0].Unlocked)
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// 迟交策略
+const (
+	LateAllow   = "allow"   // 允许迟交，不扣分
+	LateReject  = "reject"  // 截止后不能再提交
+	LatePenalty = "penalty" // 按迟交天数扣分
+)
+
+var (
+	ErrNotOpen      = errors.New("section is not open yet")
+	ErrClosed       = errors.New("section is closed")
+	ErrLateRejected = errors.New("deadline has passed and late submissions are not accepted")
+)
+
+// Deadline 截止时间和迟交策略，可以设置在章节或小节上
+type Deadline struct {
+	OpenAt        *time.Time // 开放时间，之前不能提交检测
+	DueAt         *time.Time // 截止时间，之后的提交视为迟交
+	CloseAt       *time.Time // 关闭时间，之后不能再提交
+	LatePolicy    string     `gorm:"type:varchar(20)"` // 迟交策略：allow / reject / penalty，为空时等同于 allow
+	PenaltyPerDay float64    // 每迟交一天（不足一天按一天计）扣除的百分比
+	PenaltyCap    float64    // 最多扣除的百分比，0表示最多扣完
+}
+
+// IsSet 是否设置了任意时间
+func (d *Deadline) IsSet() bool {
+	return d.OpenAt != nil || d.DueAt != nil || d.CloseAt != nil
+}
+
+// EffectiveDeadline 获取小节实际生效的截止时间，小节未设置时使用章节的设置，都未设置时返回 nil
+func EffectiveDeadline(section *Section, chapter *Chapter) *Deadline {
+	if section != nil && section.Deadline.IsSet() {
+		deadline := section.Deadline
+		return &deadline
+	}
+	if chapter != nil && chapter.Deadline.IsSet() {
+		deadline := chapter.Deadline
+		return &deadline
+	}
+	return nil
+}
+
+// Check 检查在 at 时刻是否允许提交
+func (d *Deadline) Check(at time.Time) error {
+	if d.OpenAt != nil && at.Before(*d.OpenAt) {
+		return ErrNotOpen
+	}
+	if d.CloseAt != nil && at.After(*d.CloseAt) {
+		return ErrClosed
+	}
+	if d.LatePolicy == LateReject && d.DueAt != nil && at.After(*d.DueAt) {
+		return ErrLateRejected
+	}
+	return nil
+}
+
+// Penalty 计算在 at 时刻提交的迟交扣分百分比
+func (d *Deadline) Penalty(at time.Time) (late bool, penalty float64) {
+	if d.DueAt == nil || !at.After(*d.DueAt) {
+		return false, 0
+	}
+	if d.LatePolicy != LatePenalty {
+		return true, 0
+	}
+
+	days := math.Ceil(at.Sub(*d.DueAt).Hours() / 24)
+	penalty = days * d.PenaltyPerDay
+
+	limit := 100.0
+	if d.PenaltyCap > 0 && d.PenaltyCap < limit {
+		limit = d.PenaltyCap
+	}
+	return true, math.Min(penalty, limit)
+}
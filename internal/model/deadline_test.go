@@ -0,0 +1,64 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEffectiveDeadline(t *testing.T) {
+	due := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	chapter := &Chapter{Deadline: Deadline{DueAt: &due, LatePolicy: LateReject}}
+
+	assert.Nil(t, EffectiveDeadline(&Section{}, &Chapter{}))
+	assert.Equal(t, LateReject, EffectiveDeadline(&Section{}, chapter).LatePolicy)
+
+	sectionDue := due.Add(24 * time.Hour)
+	section := &Section{Deadline: Deadline{DueAt: &sectionDue}}
+	assert.Equal(t, &sectionDue, EffectiveDeadline(section, chapter).DueAt)
+}
+
+func TestDeadlineCheck(t *testing.T) {
+	open := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	due := open.Add(7 * 24 * time.Hour)
+	closeAt := due.Add(7 * 24 * time.Hour)
+	deadline := Deadline{OpenAt: &open, DueAt: &due, CloseAt: &closeAt}
+
+	assert.ErrorIs(t, deadline.Check(open.Add(-time.Minute)), ErrNotOpen)
+	assert.NoError(t, deadline.Check(open.Add(time.Hour)))
+	assert.NoError(t, deadline.Check(due.Add(time.Hour)))
+	assert.ErrorIs(t, deadline.Check(closeAt.Add(time.Minute)), ErrClosed)
+
+	deadline.LatePolicy = LateReject
+	assert.NoError(t, deadline.Check(due))
+	assert.ErrorIs(t, deadline.Check(due.Add(time.Minute)), ErrLateRejected)
+}
+
+func TestDeadlinePenalty(t *testing.T) {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	deadline := Deadline{DueAt: &due, LatePolicy: LatePenalty, PenaltyPerDay: 10, PenaltyCap: 50}
+
+	late, penalty := deadline.Penalty(due.Add(-time.Hour))
+	assert.False(t, late)
+	assert.Equal(t, 0.0, penalty)
+
+	// 不足一天按一天计
+	late, penalty = deadline.Penalty(due.Add(time.Hour))
+	assert.True(t, late)
+	assert.Equal(t, 10.0, penalty)
+
+	_, penalty = deadline.Penalty(due.Add(49 * time.Hour))
+	assert.Equal(t, 30.0, penalty)
+
+	_, penalty = deadline.Penalty(due.Add(30 * 24 * time.Hour))
+	assert.Equal(t, 50.0, penalty)
+
+	deadline.PenaltyCap = 0
+	_, penalty = deadline.Penalty(due.Add(30 * 24 * time.Hour))
+	assert.Equal(t, 100.0, penalty)
+
+	deadline.LatePolicy = LateAllow
+	late, penalty = deadline.Penalty(due.Add(30 * 24 * time.Hour))
+	assert.True(t, late)
+	assert.Equal(t, 0.0, penalty)
+}
@@ -5,6 +5,13 @@ import (
 	"time"
 )
 
+// 用户角色
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
 // User 用户模型
 type User struct {
 	gorm.Model
@@ -13,18 +20,30 @@ type User struct {
 	Password      string              `gorm:"type:varchar(255);not null"`             // 密码，哈希后的
 	Avatar        string              `gorm:"type:varchar(255)"`                      // 头像URL，可选
 	Bio           string              `gorm:"type:varchar(255)"`                      // 简介，可选
+	Role          string              `gorm:"type:varchar(20);default:'student'"`     // 角色：student / teacher / admin
+	Cohort        string              `gorm:"type:varchar(50);index"`                 // 所属班级，用于按班级导出成绩
 	SectionStatus []UserSectionStatus `gorm:"foreignKey:UserID"`                      // 用户学习状态
 }
 
 // UserSectionStatus 用户小节完成状态模型
 type UserSectionStatus struct {
 	gorm.Model
-	UserID    uint   `gorm:"not null;index"`                        // 用户ID
-	SectionID uint   `gorm:"not null;index"`                        // 小节ID
-	Status    string `gorm:"type:varchar(20);default:'incomplete'"` // 学习状态，例如：incomplete/completed
-	Completed bool
+	UserID      uint       `gorm:"not null;uniqueIndex:idx_user_section" json:"user_id"`    // 用户ID
+	SectionID   uint       `gorm:"not null;uniqueIndex:idx_user_section" json:"section_id"` // 小节ID，每个用户在每个小节只有一条状态
+	Status      string     `gorm:"type:varchar(20);default:'incomplete'" json:"status"`     // 学习状态，例如：incomplete/completed
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"` // 首次完成时间
+	BestScore   float64    `json:"best_score"`   // 历次检测的最好得分
+	MaxScore    float64    `json:"max_score"`    // 取得最好得分时的满分
+	Percentage  float64    `json:"percentage"`   // 最好得分的百分比（0-100）
 }
 
+// 小节学习状态
+const (
+	SectionIncomplete = "incomplete"
+	SectionCompleted  = "completed"
+)
+
 // Course 课程模型
 type Course struct {
 	gorm.Model
@@ -36,14 +55,22 @@ type Course struct {
 	References  []CourseReference `gorm:"foreignKey:CourseID"` // 课程参考资料
 }
 
+// CourseTeacher 课程的授课教师，教师只能管理自己授课的课程，管理员可以管理所有课程
+type CourseTeacher struct {
+	ID       uint `gorm:"primaryKey"`
+	CourseID uint `gorm:"not null;uniqueIndex:idx_course_teacher"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_course_teacher;index"`
+}
+
 // Chapter 章节模型
 type Chapter struct {
 	gorm.Model
-	Title       string    `gorm:"type:varchar(255);not null"` // 章节标题
-	Description string    `gorm:"type:text"`                  // 章节简介
-	Order       uint      `gorm:"not null"`                   // 章节排序编号
-	CourseID    uint      `gorm:"not null;index"`             // 所属课程ID
-	Sections    []Section `gorm:"foreignKey:ChapterID"`       // 关联小节
+	Title       string            `gorm:"type:varchar(255);not null"` // 章节标题
+	Description string            `gorm:"type:text"`                  // 章节简介
+	Order       uint              `gorm:"not null"`                   // 章节排序编号
+	CourseID    uint              `gorm:"not null;index"`             // 所属课程ID
+	Sections    []Section         `gorm:"foreignKey:ChapterID"`       // 关联小节
+	Deadline    `gorm:"embedded"` // 章节的截止时间，小节未设置时使用
 }
 
 // Section 小节模型
@@ -55,8 +82,36 @@ type Section struct {
 	ChapterID  uint                `gorm:"not null;index"`             // 所属章节ID
 	TemplateID uint                `gorm:"index"`                      // （可选）关联的容器模板ID
 	UserStatus []UserSectionStatus `gorm:"foreignKey:SectionID"`       // 用户完成状态
+
+	MaxAttempts        uint // 最多检测次数，0表示不限制
+	MaxAttemptsPerHour uint // 每小时最多检测次数，0表示不限制
+	FailureCooldown    uint // 检测未通过后需要等待的时间（秒），0表示不限制
+
+	SolutionFiles string `gorm:"type:text"` // 查重时从学生容器中收集的文件路径，每行一个
+
+	GradingMode    string  `gorm:"type:varchar(20);not null;default:'auto'"` // 评分方式：auto（脚本检测）/ manual（教师评分）/ quiz（测验）
+	ManualMaxScore float64 `gorm:"not null;default:100"`                     // 教师评分时的满分
+
+	QuizQuestionCount  uint    // 每次测验抽取的题目数量，0表示全部题目
+	QuizShuffle        bool    // 是否打乱题目和选项的顺序
+	QuizPassPercentage float64 `gorm:"not null;default:60"` // 测验通过需要的得分百分比（0-100）
+
+	Parameters  string            `gorm:"type:text"`                 // 为每个学生生成的实验参数，每行一个，格式为 NAME TYPE [ARGS...]
+	ParamSeed   string            `gorm:"type:varchar(64)" json:"-"` // 生成参数的种子，不返回给学生
+	ParamValues map[string]string `gorm:"-"`                         // 当前用户的参数取值，由服务层填充
+	ParamError  string            `gorm:"-"`                         // 参数无法计算时的原因，由服务层填充，此时内容中的占位符不会被替换
+
+	Deadline          `gorm:"embedded"` // 小节的截止时间，优先于章节的设置
+	EffectiveDeadline *Deadline         `gorm:"-"` // 实际生效的截止时间，由服务层填充
 }
 
+// 小节评分方式
+const (
+	GradingAuto   = "auto"
+	GradingManual = "manual"
+	GradingQuiz   = "quiz"
+)
+
 // CourseReference 课程参考资料模型
 type CourseReference struct {
 	gorm.Model
@@ -69,14 +124,49 @@ type CourseReference struct {
 // ContainerTemplate 容器模板模型
 type ContainerTemplate struct {
 	gorm.Model
-	Name        string `gorm:"type:varchar(100);not null"` // 模板名称，例如 "Ubuntu + Docker"
-	Description string `gorm:"type:text"`                  // 模板描述
-	Image       string `gorm:"type:varchar(255);not null"` // 使用的容器镜像名，如 "ubuntu:20.04"
-	DefaultCmd  string `gorm:"type:varchar(255)"`          // 容器默认启动命令，可选
-	Volumes     string `gorm:"type:text"`                  // 挂载的卷，可以是JSON数组表示多个卷
-	Ports       string `gorm:"type:text"`                  // 暴露的端口，JSON数组表示多个端口
-	Envs        string `gorm:"type:text"`                  // 环境变量，JSON数组表示多个环境变量
-	SUDOPass    string `gorm:"type:varchar(255)"`          // SUDO密码（如果有的话）
+	Name        string  `gorm:"type:varchar(100);not null"` // 模板名称，例如 "Ubuntu + Docker"
+	Description string  `gorm:"type:text"`                  // 模板描述
+	Image       string  `gorm:"type:varchar(255);not null"` // 使用的容器镜像名，如 "ubuntu:20.04"
+	DefaultCmd  string  `gorm:"type:varchar(255)"`          // 容器默认启动命令，可选
+	Volumes     string  `gorm:"type:text"`                  // 挂载的卷，可以是JSON数组表示多个卷
+	Ports       string  `gorm:"type:text"`                  // 暴露的端口，JSON数组表示多个端口
+	Envs        string  `gorm:"type:text"`                  // 环境变量，JSON数组表示多个环境变量
+	SUDOPass    string  `gorm:"type:varchar(255)"`          // SUDO密码（如果有的话），加密存储，为空时每个实例随机生成
+	CPULimit    float64 `gorm:"default:0"`                  // CPU核数限制，0表示不限制
+	MemoryLimit int64   `gorm:"default:0"`                  // 内存限制（MB），0表示不限制
+	NodeLabels  string  `gorm:"type:varchar(255)"`          // 节点亲和性，只调度到带有这些标签的节点，格式: key=value;
+
+	GradeMode     string `gorm:"type:varchar(20);default:'inplace'"` // 检测方式：inplace 在学生容器中执行 / isolated 在独立的检测容器中执行
+	GraderImage   string `gorm:"type:varchar(255)"`                  // isolated 模式下检测容器使用的可信镜像
+	WorkspacePath string `gorm:"type:varchar(255)"`                  // 学生工作目录，isolated 模式下以只读方式挂载到检测容器的相同路径
+
+	CheckConcurrency uint `gorm:"default:1"` // 同时执行的并行检测点数量上限，0 或 1 表示依次执行
+
+	SecurityProfile string           `gorm:"type:varchar(100)"` // 安全配置名称，为空时使用默认安全配置
+	Profile         *SecurityProfile `gorm:"-"`                 // 解析后的安全配置，创建容器时由服务层填充
+}
+
+// 检测方式
+const (
+	GradeInplace  = "inplace"
+	GradeIsolated = "isolated"
+)
+
+// DefaultSecurityProfile 模板未指定安全配置时使用的配置名称
+const DefaultSecurityProfile = "default"
+
+// SecurityProfile 容器安全配置模型，由管理员统一管理，模板通过名称引用
+type SecurityProfile struct {
+	gorm.Model
+	Name            string `gorm:"type:varchar(100);uniqueIndex;not null"` // 配置名称，唯一
+	Description     string `gorm:"type:text"`                              // 配置说明
+	CapAdd          string `gorm:"type:text"`                              // 添加的capabilities，格式: SYS_PTRACE;SYS_ADMIN;
+	CapDrop         string `gorm:"type:text"`                              // 移除的capabilities，格式同上，ALL表示全部移除
+	SeccompProfile  string `gorm:"type:text"`                              // seccomp配置：空为Docker默认，unconfined，或JSON格式的配置内容
+	AppArmorProfile string `gorm:"type:varchar(255)"`                      // AppArmor配置名称：空为Docker默认，unconfined，或已加载的配置名
+	ReadOnlyRootfs  bool   // 根文件系统只读
+	NoNewPrivileges bool   // 禁止进程获取新的权限
+	UsernsMode      string `gorm:"type:varchar(50)"` // 用户命名空间模式：空为daemon默认，host为关闭重映射
 }
 
 // TODO: Instance 和 Script模型中的SectionID或者TemplateID只需要保留一个
@@ -89,23 +179,352 @@ type ContainerInstance struct {
 	SectionID   uint      `gorm:"not null;index"`             // 关联的小节ID（在哪一节学习用的）
 	TemplateID  uint      `gorm:"not null;index"`             // 使用的模板ID
 	ContainerID string    `gorm:"type:varchar(255);not null"` // 容器实际ID（Docker/K8S管理用）
-	Status      string    `gorm:"type:varchar(50);not null"`  // 状态：Pending / Running / Stopped / Error
+	Status      string    `gorm:"type:varchar(50);not null"`  // 状态：Pending / Running / Stopped / Removed / Error
 	Name        string    `gorm:"type:varchar(100);not null"` // 容器名称，便于用户识别
 	StartAt     time.Time `gorm:"type:timestamp"`             // 启动时间
 	EndAt       time.Time `gorm:"type:timestamp"`             // 结束/销毁时间
 	IPAddress   string    `gorm:"type:varchar(100)"`          // 容器分配的IP地址（如果有的话）
+	Node        string    `gorm:"type:varchar(100);index"`    // 容器所在的Docker节点
+	Workspace   string    `gorm:"type:varchar(255)"`          // 保存学生工作目录的数据卷名称，isolated 模式下使用
 	Token       string    `gorm:"type:varchar(255)"`          // 容器访问令牌（如果有的话）
+	SUDOPass    string    `gorm:"type:varchar(255)" json:"-"` // 实例的SUDO密码，加密存储，只能通过认证接口获取
+}
+
+// WorkspaceSnapshot 容器移除前保存的学生工作目录，以 tar 格式存放在对象存储中，
+// 容器移除后查重和重新检测使用最新的快照
+type WorkspaceSnapshot struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`             // 关联的用户ID
+	SectionID  uint   `gorm:"not null;index"`             // 关联的小节ID
+	TemplateID uint   `gorm:"not null;index"`             // 使用的模板ID
+	InstanceID uint   `gorm:"not null;index"`             // 保存快照的容器实例ID
+	Path       string `gorm:"type:varchar(255);not null"` // 容器中的工作目录
+	ObjectName string `gorm:"type:varchar(255);not null"` // 对象存储中的名称
+	Size       int64  // 快照大小（字节）
 }
 
 // ContainerScript 容器脚本模型
 type ContainerScript struct {
 	gorm.Model
-	SectionID      uint   `gorm:"not null;index"`            // 关联的小节ID（在哪一节要检测）
-	TemplateID     uint   `gorm:"not null;index"`            // 使用的模板ID
-	Order          uint   `gorm:"not null"`                  // 脚本执行顺序，每一个脚本是一个测试点
-	Content        string `gorm:"type:text;not null"`        // 脚本内容
-	ExpectedOutput string `gorm:"type:text"`                 // 期望输出，比如包含某个文件
-	MatchType      string `gorm:"type:varchar(50);not null"` // 匹配方式：contains / equals / regex
-	Timeout        uint   `gorm:"default:10"`                // 超时时间（秒），默认10秒
-	Description    string `gorm:"type:varchar(255)"`         // 检测说明，可选
+	SectionID      uint    `gorm:"not null;index"`                     // 关联的小节ID（在哪一节要检测）
+	TemplateID     uint    `gorm:"not null;index"`                     // 使用的模板ID
+	Order          uint    `gorm:"not null"`                           // 脚本执行顺序，每一个脚本是一个测试点
+	Name           string  `gorm:"type:varchar(100)"`                  // 检测点名称，展示给学生
+	Phase          string  `gorm:"type:varchar(20);default:'check'"`   // 执行阶段：setup / check / teardown
+	Visibility     string  `gorm:"type:varchar(20);default:'visible'"` // 可见性：visible / hidden，隐藏的检测点只展示是否通过
+	Content        string  `gorm:"type:text;not null"`                 // 脚本内容
+	ExpectedOutput string  `gorm:"type:text"`                          // 期望输出，比如包含某个文件
+	MatchType      string  `gorm:"type:varchar(50);not null"`          // 匹配方式：contains / equals / regex
+	ReportFormat   string  `gorm:"type:varchar(20)"`                   // 测试报告格式：tap / junit，设置后按报告中的每个测试用例生成检测结果
+	ReportPath     string  `gorm:"type:varchar(255)"`                  // 测试报告在容器中的路径，为空时解析脚本输出
+	Timeout        uint    `gorm:"default:10"`                         // 超时时间（秒），默认10秒
+	Description    string  `gorm:"type:varchar(255)"`                  // 检测说明，可选
+	Hint           string  `gorm:"type:varchar(255)"`                  // 未通过时给学生的提示，可选，隐藏的检测点也会展示
+	Points         float64 `gorm:"default:1"`                          // 该检测点的分值，默认1分
+	Required       bool    // 必须通过的检测点，未通过时整次提交记0分
+	Parallel       bool    // 可以与相邻的并行检测点同时执行，要求检测点之间互不依赖
+	SuiteVersion   uint    // 从检测套件导入时对应的套件版本，手动添加的脚本为0
+}
+
+// 测试报告格式
+const (
+	ReportTAP   = "tap"
+	ReportJUnit = "junit"
+)
+
+// 检测脚本执行阶段，setup 在检测前执行，失败时终止本次检测；teardown 总会在最后执行
+const (
+	PhaseSetup    = "setup"
+	PhaseCheck    = "check"
+	PhaseTeardown = "teardown"
+)
+
+// 检测点可见性
+const (
+	VisibilityVisible = "visible"
+	VisibilityHidden  = "hidden"
+)
+
+// 提交状态
+const (
+	SubmissionPending = "pending"
+	SubmissionRunning = "running"
+	SubmissionPassed  = "passed"
+	SubmissionFailed  = "failed"
+	SubmissionError   = "error"
+
+	SubmissionSubmitted = "submitted" // 人工评分的提交等待教师评分
+)
+
+// 提交的触发方式，重新评分产生的提交不计入检测次数
+const (
+	TriggerStudent = "student"
+	TriggerRegrade = "regrade"
+)
+
+// 重新评分任务状态
+const (
+	RegradePending  = "pending"
+	RegradeRunning  = "running"
+	RegradeFinished = "finished"
+)
+
+// 检测点状态
+const (
+	CheckPass  = "pass"
+	CheckFail  = "fail"
+	CheckError = "error"
+)
+
+// Submission 一次检测提交，每次调用检测接口都会产生一条记录
+type Submission struct {
+	gorm.Model
+	UserID       uint          `gorm:"not null;index"`                              // 提交的用户ID
+	SectionID    uint          `gorm:"not null;index"`                              // 检测的小节ID
+	TemplateID   uint          `gorm:"not null;index"`                              // 使用的模板ID
+	InstanceID   uint          `gorm:"index"`                                       // 执行检测的容器实例ID
+	Attempt      uint          `gorm:"not null"`                                    // 该用户在该小节的第几次提交
+	Status       string        `gorm:"type:varchar(20);not null;default:'pending'"` // 状态：pending / running / passed / failed / error
+	Trigger      string        `gorm:"type:varchar(20);not null;default:'student'"` // 触发方式：student / regrade
+	RegradeOf    uint          `gorm:"index"`                                       // 重新评分时对应的原提交ID
+	SuiteVersion uint          // 执行检测时使用的检测套件版本，0表示脚本不是从套件导入的
+	Passed       uint          // 通过的检测点数量
+	Total        uint          // 检测点总数
+	RawScore     float64       // 扣除迟交罚分前的得分
+	Score        float64       // 得分
+	Late         bool          // 是否迟交
+	Penalty      float64       // 迟交扣除的百分比（0-100）
+	MaxScore     float64       // 满分
+	Percentage   float64       // 得分百分比（0-100）
+	Error        string        `gorm:"type:text"` // 检测无法完成时的原因
+	StartedAt    *time.Time    // 开始执行时间
+	FinishedAt   *time.Time    // 执行结束时间
+	Duration     int64         // 耗时（毫秒）
+	Results      []CheckResult `gorm:"foreignKey:SubmissionID"` // 各检测点的结果
+
+	Content  string           `gorm:"type:longtext"`           // 人工评分小节中学生提交的文字作答
+	Files    []SubmissionFile `gorm:"foreignKey:SubmissionID"` // 人工评分小节中学生上传的文件
+	Feedback string           `gorm:"type:text"`               // 教师评语
+	GradedBy uint             // 评分教师ID
+	GradedAt *time.Time       // 评分时间
+}
+
+// AttemptLock 用户在小节的检测次数锁，统计检测次数和创建提交在同一事务中对这一行加锁，
+// 同一用户在同一小节的并发提交依次执行，不会超过次数限制
+type AttemptLock struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_attempt_lock_user_section"`
+	SectionID uint `gorm:"not null;uniqueIndex:idx_attempt_lock_user_section"`
+}
+
+// QuotaLock 容器配额锁，统计资源占用和创建占位的容器记录在同一事务中对这一行加锁，
+// 并发创建容器时依次执行，不会超出配额
+type QuotaLock struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"type:varchar(50);not null;uniqueIndex"`
+}
+
+// SubmissionFile 人工评分提交中上传到对象存储的文件
+type SubmissionFile struct {
+	gorm.Model
+	SubmissionID uint   `gorm:"not null;index"`             // 所属提交ID
+	Name         string `gorm:"type:varchar(255);not null"` // 原始文件名
+	ObjectName   string `gorm:"type:varchar(512);not null"` // 对象存储中的名称
+	Size         int64  // 文件大小（字节）
+	URL          string `gorm:"-"` // 下载链接，由服务层填充
+}
+
+// CheckResult 单个检测点的执行结果
+type CheckResult struct {
+	gorm.Model
+	SubmissionID uint      `gorm:"not null;index"` // 所属提交ID
+	ScriptID     uint      `gorm:"index"`          // 对应的检测脚本ID
+	Order        uint      `gorm:"not null"`       // 检测点顺序
+	Case         uint      // 测试报告中的用例序号，从1开始，普通检测点为0
+	Name         string    `gorm:"type:varchar(255)"` // 检测点名称，测试报告中的用例为 "脚本名称: 用例名称"
+	Description  string    `gorm:"type:varchar(255)"` // 检测说明
+	Hidden       bool      // 检测时该检测点是否对学生隐藏
+	Hint         string    `gorm:"type:varchar(255)"`         // 未通过时给学生的提示
+	Status       string    `gorm:"type:varchar(20);not null"` // 状态：pass / fail / error
+	Points       float64   // 获得的分数
+	MaxPoints    float64   // 该检测点的分值
+	Required     bool      // 是否为必须通过的检测点
+	ExitCode     int       // 脚本退出码
+	Output       string    `gorm:"type:text"` // 输出摘要
+	Diff         string    `gorm:"type:text"` // 期望输出与实际输出的差异，仅 equals 匹配失败时记录
+	Duration     int64     // 耗时（毫秒）
+	StartedAt    time.Time // 开始执行时间
+	FinishedAt   time.Time // 执行结束时间
+}
+
+// GradingSuite 小节的检测套件版本，每次导入内容不同的套件产生一个新版本
+type GradingSuite struct {
+	gorm.Model
+	SectionID  uint   `gorm:"not null;uniqueIndex:idx_suite_section_version"` // 所属小节ID
+	Version    uint   `gorm:"not null;uniqueIndex:idx_suite_section_version"` // 版本号，从1开始递增
+	Name       string `gorm:"type:varchar(255)"`                              // 清单中的套件名称
+	Checksum   string `gorm:"type:varchar(64);not null"`                      // 套件内容的SHA-256摘要
+	Checks     uint   // 检测脚本数量
+	ImportedBy uint   // 导入套件的用户ID，命令行导入时为0
+	Archive    []byte `gorm:"type:longblob" json:"-"` // 导入的套件归档（tar.gz），用于导出历史版本
+}
+
+// Hint 小节或检测点的提示，多次未通过或经过一段时间后按顺序解锁
+type Hint struct {
+	gorm.Model
+	SectionID           uint   `gorm:"not null;index"`          // 所属小节ID
+	CheckName           string `gorm:"type:varchar(100);index"` // 关联的检测点名称，对应检测脚本的名称，重新导入套件后仍然有效；为空表示整个小节的提示
+	Order               uint   `gorm:"not null"`                // 同一检测点（或小节）内的解锁顺序
+	Title               string `gorm:"type:varchar(255)"`       // 提示标题，未解锁时也会展示
+	Content             string `gorm:"type:text;not null"`      // 提示内容，解锁并查看后才返回
+	UnlockAfterFailures uint   // 未通过多少次后解锁，检测点的提示按该检测点计算，0表示不按失败次数
+	UnlockAfterMinutes  uint   // 第一次提交多少分钟后解锁，0表示不按时间
+}
+
+// HintUsage 用户查看提示的记录
+type HintUsage struct {
+	gorm.Model
+	UserID    uint `gorm:"not null;uniqueIndex:idx_hint_usage_user_hint"` // 查看提示的用户ID
+	HintID    uint `gorm:"not null;uniqueIndex:idx_hint_usage_user_hint"` // 提示ID
+	SectionID uint `gorm:"not null;index"`                                // 提示所属小节ID
+}
+
+// 测验题目类型
+const (
+	QuestionSingle   = "single"   // 单选题
+	QuestionMultiple = "multiple" // 多选题，选中的选项与正确选项完全一致才得分
+	QuestionNumeric  = "numeric"  // 数值题，与答案的差不超过 Tolerance 即得分
+	QuestionText     = "text"     // 简答题，去掉首尾空白后与答案一致即得分
+	QuestionRegex    = "regex"    // 简答题，整个回答匹配正则表达式即得分
+)
+
+// QuizQuestion 测验小节的题目
+type QuizQuestion struct {
+	gorm.Model
+	SectionID     uint         `gorm:"not null;index"`            // 所属小节ID
+	Order         uint         `gorm:"not null"`                  // 题目顺序，不打乱时按该顺序展示
+	Type          string       `gorm:"type:varchar(20);not null"` // 题目类型：single / multiple / numeric / text / regex
+	Prompt        string       `gorm:"type:text;not null"`        // 题干
+	Answer        string       `gorm:"type:text"`                 // 数值题、简答题的答案或正则表达式，选择题为空
+	Tolerance     float64      // 数值题允许的误差
+	CaseSensitive bool         // 简答题是否区分大小写
+	Points        float64      `gorm:"not null;default:1"` // 分值
+	Explanation   string       `gorm:"type:text"`          // 提交后展示的解析
+	Options       []QuizOption `gorm:"foreignKey:QuestionID"`
+}
+
+// QuizOption 选择题的选项
+type QuizOption struct {
+	gorm.Model
+	QuestionID uint   `gorm:"not null;index"`     // 所属题目ID
+	Order      uint   `gorm:"not null"`           // 选项顺序
+	Content    string `gorm:"type:text;not null"` // 选项内容
+	Correct    bool   // 是否为正确选项
+}
+
+// 测验作答状态
+const (
+	QuizInProgress = "in_progress"
+	QuizSubmitted  = "submitted"
+)
+
+// QuizAttempt 用户的一次测验作答，抽取的题目和顺序在开始时确定
+type QuizAttempt struct {
+	gorm.Model
+	UserID       uint         `gorm:"not null;index"`                                  // 作答的用户ID
+	SectionID    uint         `gorm:"not null;index"`                                  // 测验小节ID
+	Status       string       `gorm:"type:varchar(20);not null;default:'in_progress'"` // 状态：in_progress / submitted
+	Seed         int64        // 随机种子，决定选项的顺序
+	QuestionIDs  string       `gorm:"type:text"` // 抽取的题目ID，按展示顺序以逗号分隔
+	SubmissionID uint         `gorm:"index"`     // 提交后对应的提交记录ID
+	SubmittedAt  *time.Time   // 提交时间
+	Answers      []QuizAnswer `gorm:"foreignKey:AttemptID"` // 各题的作答
+}
+
+// QuizAnswer 单道题目的作答及评分
+type QuizAnswer struct {
+	gorm.Model
+	AttemptID  uint    `gorm:"not null;index"`    // 所属作答ID
+	QuestionID uint    `gorm:"not null;index"`    // 题目ID
+	Response   string  `gorm:"type:text"`         // 数值题、简答题的回答
+	OptionIDs  string  `gorm:"type:varchar(255)"` // 选择题选中的选项ID，以逗号分隔
+	Correct    bool    // 是否正确
+	Score      float64 // 得分
+}
+
+// 查重报告状态
+const (
+	SimilarityPending  = "pending"
+	SimilarityRunning  = "running"
+	SimilarityFinished = "finished"
+	SimilarityFailed   = "failed"
+)
+
+// SimilarityReport 小节的查重报告，比较每个学生容器或工作目录快照中的答案文件
+type SimilarityReport struct {
+	gorm.Model
+	SectionID   uint             `gorm:"not null;index"`                              // 查重的小节ID
+	RequestedBy uint             `gorm:"not null"`                                    // 发起查重的管理员ID
+	Status      string           `gorm:"type:varchar(20);not null;default:'pending'"` // 状态：pending / running / finished / failed
+	Students    uint             // 参与比较的学生数量
+	Skipped     uint             // 无法收集答案文件的学生数量
+	Error       string           `gorm:"type:text"` // 查重失败的原因
+	FinishedAt  *time.Time       // 完成时间
+	Pairs       []SimilarityPair `gorm:"foreignKey:ReportID"` // 相似度较高的学生对，按相似度从高到低排列
+}
+
+// SimilarityPair 查重报告中两个学生的比较结果
+type SimilarityPair struct {
+	gorm.Model
+	ReportID uint              `gorm:"not null;index"` // 所属查重报告ID
+	UserA    uint              `gorm:"not null"`       // 学生A的ID
+	UserB    uint              `gorm:"not null"`       // 学生B的ID
+	Score    float64           // 相似度（0-100）
+	Shared   uint              // 共同指纹数量
+	SourceA  string            `gorm:"type:longtext"`     // 学生A的答案文件，多个文件按路径拼接
+	SourceB  string            `gorm:"type:longtext"`     // 学生B的答案文件
+	Diff     string            `gorm:"type:text"`         // 两份答案的逐行差异
+	Matches  []SimilarityMatch `gorm:"foreignKey:PairID"` // 相同的代码片段
+}
+
+// SimilarityMatch 两份答案中相同的代码片段，行号从1开始
+type SimilarityMatch struct {
+	gorm.Model
+	PairID uint `gorm:"not null;index"` // 所属比较结果ID
+	StartA uint // 片段在学生A答案中的起始行
+	EndA   uint // 片段在学生A答案中的结束行
+	StartB uint // 片段在学生B答案中的起始行
+	EndB   uint // 片段在学生B答案中的结束行
+}
+
+// RegradeJob 重新评分任务，修改检测脚本后用当前脚本重新检测小节内所有学生的最近一次提交
+type RegradeJob struct {
+	gorm.Model
+	SectionID   uint            `gorm:"not null;index"`                              // 重新评分的小节ID
+	TemplateID  uint            `gorm:"not null"`                                    // 小节关联的模板ID
+	RequestedBy uint            `gorm:"not null"`                                    // 发起任务的管理员ID
+	Status      string          `gorm:"type:varchar(20);not null;default:'pending'"` // 状态：pending / running / finished
+	Total       uint            // 需要重新评分的学生数量
+	Done        uint            // 已完成重新评分的数量
+	Skipped     uint            // 无法重新评分的数量，例如学生的容器已被移除
+	Improved    uint            // 得分提高的数量
+	Regressed   uint            // 得分降低的数量
+	FinishedAt  *time.Time      // 全部完成的时间
+	Results     []RegradeResult `gorm:"foreignKey:JobID"` // 每个学生的重新评分结果
+}
+
+// RegradeResult 单个学生的重新评分结果
+type RegradeResult struct {
+	gorm.Model
+	JobID                uint    `gorm:"not null;index"` // 所属的重新评分任务ID
+	UserID               uint    `gorm:"not null;index"` // 学生ID
+	PreviousSubmissionID uint    `gorm:"not null"`       // 原提交ID
+	SubmissionID         uint    // 重新评分产生的提交ID，跳过时为0
+	PreviousStatus       string  `gorm:"type:varchar(20)"` // 原提交状态
+	Status               string  `gorm:"type:varchar(20)"` // 重新评分后的状态，跳过时为空
+	PreviousScore        float64 // 原得分
+	Score                float64 // 重新评分后的得分
+	Delta                float64 // 得分变化
+	FromSnapshot         bool    // 学生的容器已被移除，在恢复了最新工作目录快照的临时容器中重新检测
+	Error                string  `gorm:"type:text"` // 跳过或失败的原因
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// NewParamSeed 生成随机的实验参数种子，64 位十六进制字符串
+func NewParamSeed() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate parameter seed: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// BeforeCreate 创建小节时生成实验参数种子，学生的参数取值无法从小节ID推测
+func (s *Section) BeforeCreate(tx *gorm.DB) error {
+	if s.ParamSeed != "" {
+		return nil
+	}
+	seed, err := NewParamSeed()
+	if err != nil {
+		return err
+	}
+	s.ParamSeed = seed
+	return nil
+}
+
+// SolutionPaths 返回查重时收集的答案文件路径，忽略空行
+func (s *Section) SolutionPaths() []string {
+	var paths []string
+	for _, line := range strings.Split(s.SolutionFiles, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
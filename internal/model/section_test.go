@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSectionBeforeCreate(t *testing.T) {
+	section := &Section{}
+	assert.NoError(t, section.BeforeCreate(nil))
+	assert.Len(t, section.ParamSeed, 64)
+
+	// 已经设置的种子保持不变
+	other := &Section{ParamSeed: section.ParamSeed}
+	assert.NoError(t, other.BeforeCreate(nil))
+	assert.Equal(t, section.ParamSeed, other.ParamSeed)
+
+	again := &Section{}
+	assert.NoError(t, again.BeforeCreate(nil))
+	assert.NotEqual(t, section.ParamSeed, again.ParamSeed)
+}
+
+func TestSectionSolutionPaths(t *testing.T) {
+	section := &Section{SolutionFiles: "/work/main.c\n\n  /work/util.c \n"}
+	assert.Equal(t, []string{"/work/main.c", "/work/util.c"}, section.SolutionPaths())
+	assert.Empty(t, (&Section{}).SolutionPaths())
+}
@@ -0,0 +1,284 @@
+package params
+
+import (
+	"awesomeProject/internal/model"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidParameter 参数声明的格式不正确
+	ErrInvalidParameter = errors.New("invalid parameter")
+	// ErrNoSeed 小节声明了参数但没有种子
+	ErrNoSeed = errors.New("section has no parameter seed")
+)
+
+// 参数类型
+const (
+	TypeInt    = "int"    // int MIN MAX，闭区间内的整数
+	TypePort   = "port"   // port，20000-59999 之间的端口号
+	TypeHex    = "hex"    // hex [LEN]，十六进制字符串，默认 8 位
+	TypeWord   = "word"   // word [LEN]，小写字母组成的单词，默认 8 位
+	TypeChoice = "choice" // choice A,B,C，从逗号分隔的值中选择一个
+)
+
+// 端口参数的取值范围，避开常用端口和系统的临时端口
+const (
+	minPort = 20000
+	maxPort = 59999
+)
+
+var nameRegexp = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// 容器中已经使用的环境变量，参数不能覆盖
+var reserved = map[string]bool{
+	"SUDO_PASSWORD":    true,
+	"CONNECTION_TOKEN": true,
+	"PATH":             true,
+	"HOME":             true,
+}
+
+// Definition 一个参数声明
+type Definition struct {
+	Name string
+	Type string
+	Args []string
+}
+
+// Parse 解析参数声明，每行一个参数，格式为 "NAME TYPE [ARGS...]"，空行和 # 开头的行被忽略
+func Parse(spec string) ([]Definition, error) {
+	var definitions []Definition
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%w: line %d: expected NAME TYPE", ErrInvalidParameter, i+1)
+		}
+		d := Definition{Name: fields[0], Type: fields[1], Args: fields[2:]}
+		if !nameRegexp.MatchString(d.Name) || reserved[d.Name] {
+			return nil, fmt.Errorf("%w: line %d: invalid name %q", ErrInvalidParameter, i+1, d.Name)
+		}
+		if seen[d.Name] {
+			return nil, fmt.Errorf("%w: line %d: duplicate name %q", ErrInvalidParameter, i+1, d.Name)
+		}
+		seen[d.Name] = true
+		if err := d.validate(); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidParameter, i+1, err)
+		}
+		definitions = append(definitions, d)
+	}
+	return definitions, nil
+}
+
+func (d Definition) validate() error {
+	switch d.Type {
+	case TypeInt:
+		if len(d.Args) != 2 {
+			return errors.New("int expects MIN MAX")
+		}
+		low, high, err := d.intRange()
+		if err != nil {
+			return err
+		}
+		if low > high {
+			return errors.New("MIN must not be greater than MAX")
+		}
+	case TypePort:
+		if len(d.Args) != 0 {
+			return errors.New("port takes no arguments")
+		}
+	case TypeHex, TypeWord:
+		if len(d.Args) > 1 {
+			return fmt.Errorf("%s expects at most one LEN", d.Type)
+		}
+		if _, err := d.length(); err != nil {
+			return err
+		}
+	case TypeChoice:
+		if len(d.choices()) == 0 {
+			return errors.New("choice expects a comma separated list")
+		}
+		// 取值会写入容器的环境变量配置，不能包含分隔符
+		if strings.Contains(strings.Join(d.Args, " "), ";") {
+			return errors.New("choice must not contain ';'")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", d.Type)
+	}
+	return nil
+}
+
+func (d Definition) intRange() (int64, int64, error) {
+	low, err := strconv.ParseInt(d.Args[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid MIN %q", d.Args[0])
+	}
+	high, err := strconv.ParseInt(d.Args[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid MAX %q", d.Args[1])
+	}
+	return low, high, nil
+}
+
+func (d Definition) length() (int, error) {
+	if len(d.Args) == 0 {
+		return 8, nil
+	}
+	n, err := strconv.Atoi(d.Args[0])
+	if err != nil || n < 1 || n > 64 {
+		return 0, fmt.Errorf("invalid LEN %q", d.Args[0])
+	}
+	return n, nil
+}
+
+func (d Definition) choices() []string {
+	var choices []string
+	for _, choice := range strings.Split(strings.Join(d.Args, " "), ",") {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// Derive 计算参数对用户的取值，取值由种子、用户ID和参数名通过 HMAC-SHA256 确定，
+// 同一用户多次计算结果相同，不知道种子时无法从其他用户的取值推出
+func Derive(definitions []Definition, seed string, userID uint) map[string]string {
+	values := make(map[string]string, len(definitions))
+	for _, d := range definitions {
+		r := newStream(seed, userID, d.Name)
+		var value string
+		switch d.Type {
+		case TypeInt:
+			low, high, _ := d.intRange()
+			value = strconv.FormatInt(low+int64(r.next()%uint64(high-low+1)), 10)
+		case TypePort:
+			value = strconv.Itoa(minPort + int(r.next()%(maxPort-minPort+1)))
+		case TypeHex:
+			n, _ := d.length()
+			value = r.text("0123456789abcdef", n)
+		case TypeWord:
+			n, _ := d.length()
+			value = r.text("abcdefghijklmnopqrstuvwxyz", n)
+		case TypeChoice:
+			choices := d.choices()
+			value = choices[r.next()%uint64(len(choices))]
+		}
+		values[d.Name] = value
+	}
+	return values
+}
+
+// ForSection 计算小节参数对用户的取值，种子在创建小节时生成；声明了参数但没有种子时返回 ErrNoSeed，
+// 不使用可以被推测的替代值
+func ForSection(section *model.Section, userID uint) (map[string]string, error) {
+	definitions, err := Parse(section.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) > 0 && section.ParamSeed == "" {
+		return nil, fmt.Errorf("%w: section %d", ErrNoSeed, section.ID)
+	}
+	return Derive(definitions, section.ParamSeed, userID), nil
+}
+
+// stream 由 HMAC 计数器产生的确定性随机数
+type stream struct {
+	key     []byte
+	message string
+	counter uint64
+}
+
+func newStream(seed string, userID uint, name string) *stream {
+	return &stream{key: []byte(seed), message: fmt.Sprintf("%d:%s", userID, name)}
+}
+
+func (s *stream) next() uint64 {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s:%d", s.message, s.counter)
+	s.counter++
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+func (s *stream) text(alphabet string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(alphabet[s.next()%uint64(len(alphabet))])
+	}
+	return b.String()
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{\{\s*([A-Z_][A-Z0-9_]*)\s*\}\}`)
+
+// Render 将文本中的 {{NAME}} 替换为参数的取值，未声明的占位符保持不变
+func Render(text string, values map[string]string) string {
+	if len(values) == 0 {
+		return text
+	}
+	return placeholderRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderRegexp.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// AppendEnvs 将参数追加到模板的环境变量配置（格式: key=value;）
+func AppendEnvs(envs string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)+1)
+	if envs = strings.TrimSpace(envs); envs != "" {
+		pairs = append(pairs, strings.TrimSuffix(envs, ";"))
+	}
+	for _, name := range names(values) {
+		pairs = append(pairs, name+"="+values[name])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// Scripts 返回注入参数后的检测脚本：脚本开头导出参数为环境变量，脚本内容和期望输出中的占位符被替换
+func Scripts(scripts []model.ContainerScript, values map[string]string) []model.ContainerScript {
+	if len(values) == 0 {
+		return scripts
+	}
+
+	var exports strings.Builder
+	for _, name := range names(values) {
+		fmt.Fprintf(&exports, "export %s=%s\n", name, quote(values[name]))
+	}
+
+	injected := make([]model.ContainerScript, len(scripts))
+	for i, script := range scripts {
+		script.Content = exports.String() + Render(script.Content, values)
+		script.ExpectedOutput = Render(script.ExpectedOutput, values)
+		injected[i] = script
+	}
+	return injected
+}
+
+// names 返回排序后的参数名，保证生成的配置和脚本稳定
+func names(values map[string]string) []string {
+	result := make([]string, 0, len(values))
+	for name := range values {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// quote 用单引号包裹 shell 参数
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
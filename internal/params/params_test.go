@@ -0,0 +1,140 @@
+package params
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+const testSpec = `
+# 每个学生的服务端口和魔数
+PORT port
+MAGIC int 1000 9999
+FILE word 6
+KEY hex
+COLOR choice red, green ,blue
+`
+
+func TestParse(t *testing.T) {
+	definitions, err := Parse(testSpec)
+	assert.NoError(t, err)
+	assert.Len(t, definitions, 5)
+	assert.Equal(t, Definition{Name: "MAGIC", Type: TypeInt, Args: []string{"1000", "9999"}}, definitions[1])
+
+	definitions, err = Parse("")
+	assert.NoError(t, err)
+	assert.Empty(t, definitions)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"PORT",
+		"port port",
+		"PATH word",
+		"A port\nA port",
+		"A int 10",
+		"A int 10 1",
+		"A int x 1",
+		"A port 80",
+		"A hex 0",
+		"A word 4 5",
+		"A choice",
+		"A choice a;b",
+		"A float",
+	}
+	for _, spec := range tests {
+		_, err := Parse(spec)
+		assert.True(t, errors.Is(err, ErrInvalidParameter), "%q: got %v", spec, err)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	definitions, err := Parse(testSpec)
+	assert.NoError(t, err)
+
+	values := Derive(definitions, "seed", 42)
+	assert.Len(t, values, 5)
+
+	port, err := strconv.Atoi(values["PORT"])
+	assert.NoError(t, err)
+	assert.True(t, port >= minPort && port <= maxPort)
+
+	magic, err := strconv.Atoi(values["MAGIC"])
+	assert.NoError(t, err)
+	assert.True(t, magic >= 1000 && magic <= 9999)
+
+	assert.Regexp(t, regexp.MustCompile(`^[a-z]{6}$`), values["FILE"])
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}$`), values["KEY"])
+	assert.Contains(t, []string{"red", "green", "blue"}, values["COLOR"])
+
+	// 相同的种子和用户得到相同的取值，不同用户或种子的取值不同
+	assert.Equal(t, values, Derive(definitions, "seed", 42))
+	assert.NotEqual(t, values, Derive(definitions, "seed", 43))
+	assert.NotEqual(t, values, Derive(definitions, "other", 42))
+}
+
+func TestDeriveSpread(t *testing.T) {
+	definitions, err := Parse("N int 1 10")
+	assert.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for user := uint(1); user <= 200; user++ {
+		seen[Derive(definitions, "seed", user)["N"]] = true
+	}
+	assert.Len(t, seen, 10)
+}
+
+func TestForSection(t *testing.T) {
+	section := &model.Section{Model: gorm.Model{ID: 3}, Parameters: "PORT port", ParamSeed: "seed"}
+	values, err := ForSection(section, 1)
+	assert.NoError(t, err)
+
+	definitions, _ := Parse(section.Parameters)
+	assert.Equal(t, Derive(definitions, "seed", 1), values)
+
+	// 没有种子时不使用可以推测的替代值
+	section.ParamSeed = ""
+	_, err = ForSection(section, 1)
+	assert.ErrorIs(t, err, ErrNoSeed)
+	_, err = ForSection(&model.Section{}, 1)
+	assert.NoError(t, err)
+
+	section.ParamSeed = "seed"
+
+	section.Parameters = "PORT"
+	_, err = ForSection(section, 1)
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	values := map[string]string{"PORT": "23456", "FILE": "notes"}
+	text := "Listen on {{PORT}} and write {{ FILE }}.txt, keep {{OTHER}} and {{port}}"
+	assert.Equal(t, "Listen on 23456 and write notes.txt, keep {{OTHER}} and {{port}}", Render(text, values))
+	assert.Equal(t, text, Render(text, nil))
+}
+
+func TestAppendEnvs(t *testing.T) {
+	values := map[string]string{"PORT": "23456", "FILE": "notes"}
+	assert.Equal(t, "A=1;B=2;FILE=notes;PORT=23456", AppendEnvs("A=1;B=2;", values))
+	assert.Equal(t, "FILE=notes;PORT=23456", AppendEnvs("", values))
+	assert.Equal(t, "A=1", AppendEnvs("A=1", nil))
+}
+
+func TestScripts(t *testing.T) {
+	scripts := []model.ContainerScript{
+		{Content: "curl localhost:$PORT", ExpectedOutput: "port {{PORT}}"},
+	}
+	values := map[string]string{"PORT": "23456", "NAME": "it's"}
+
+	injected := Scripts(scripts, values)
+	assert.Equal(t, "export NAME='it'\\''s'\nexport PORT='23456'\ncurl localhost:$PORT", injected[0].Content)
+	assert.Equal(t, "port 23456", injected[0].ExpectedOutput)
+
+	// 不修改原来的脚本
+	assert.Equal(t, "curl localhost:$PORT", scripts[0].Content)
+	assert.Equal(t, scripts, Scripts(scripts, nil))
+}
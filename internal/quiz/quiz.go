@@ -0,0 +1,209 @@
+package quiz
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidQuestion 题目的设置不正确
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// Response 用户对一道题目的回答，选择题使用 OptionIDs，其余题型使用 Text
+type Response struct {
+	Text      string
+	OptionIDs []uint
+}
+
+// Validate 校验题目的类型、答案和选项
+func Validate(q model.QuizQuestion) error {
+	if strings.TrimSpace(q.Prompt) == "" {
+		return fmt.Errorf("%w: prompt is required", ErrInvalidQuestion)
+	}
+	if q.Points < 0 {
+		return fmt.Errorf("%w: points must not be negative", ErrInvalidQuestion)
+	}
+
+	switch q.Type {
+	case model.QuestionSingle, model.QuestionMultiple:
+		if len(q.Options) < 2 {
+			return fmt.Errorf("%w: choice question needs at least 2 options", ErrInvalidQuestion)
+		}
+		correct := 0
+		for _, option := range q.Options {
+			if option.Correct {
+				correct++
+			}
+		}
+		if correct == 0 || (q.Type == model.QuestionSingle && correct != 1) {
+			return fmt.Errorf("%w: %s question has %d correct options", ErrInvalidQuestion, q.Type, correct)
+		}
+	case model.QuestionNumeric:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(q.Answer), 64); err != nil {
+			return fmt.Errorf("%w: answer is not a number", ErrInvalidQuestion)
+		}
+		if q.Tolerance < 0 {
+			return fmt.Errorf("%w: tolerance must not be negative", ErrInvalidQuestion)
+		}
+	case model.QuestionText:
+		if strings.TrimSpace(q.Answer) == "" {
+			return fmt.Errorf("%w: answer is required", ErrInvalidQuestion)
+		}
+	case model.QuestionRegex:
+		if _, err := compile(q); err != nil {
+			return fmt.Errorf("%w: invalid regex: %v", ErrInvalidQuestion, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown question type %q", ErrInvalidQuestion, q.Type)
+	}
+
+	if q.Type != model.QuestionSingle && q.Type != model.QuestionMultiple && len(q.Options) > 0 {
+		return fmt.Errorf("%w: %s question must not have options", ErrInvalidQuestion, q.Type)
+	}
+	return nil
+}
+
+// Grade 判断回答是否正确，正确时得到题目的全部分值，否则为 0 分
+func Grade(q model.QuizQuestion, response Response) (bool, float64) {
+	if correct(q, response) {
+		return true, q.Points
+	}
+	return false, 0
+}
+
+func correct(q model.QuizQuestion, response Response) bool {
+	switch q.Type {
+	case model.QuestionSingle, model.QuestionMultiple:
+		expected := make(map[uint]bool)
+		for _, option := range q.Options {
+			if option.Correct {
+				expected[option.ID] = true
+			}
+		}
+		selected := make(map[uint]bool)
+		for _, id := range response.OptionIDs {
+			selected[id] = true
+		}
+		if len(selected) != len(expected) {
+			return false
+		}
+		for id := range selected {
+			if !expected[id] {
+				return false
+			}
+		}
+		return true
+	case model.QuestionNumeric:
+		answer, err := strconv.ParseFloat(strings.TrimSpace(q.Answer), 64)
+		if err != nil {
+			return false
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(response.Text), 64)
+		if err != nil {
+			return false
+		}
+		// 留出浮点误差，避免 0.1+0.2 这类答案因精度被判错
+		return math.Abs(value-answer) <= q.Tolerance+1e-9
+	case model.QuestionText:
+		answer, text := strings.TrimSpace(q.Answer), strings.TrimSpace(response.Text)
+		if q.CaseSensitive {
+			return text == answer
+		}
+		return strings.EqualFold(text, answer)
+	case model.QuestionRegex:
+		re, err := compile(q)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(strings.TrimSpace(response.Text))
+	}
+	return false
+}
+
+// compile 编译正则题的答案，要求整个回答匹配
+func compile(q model.QuizQuestion) (*regexp.Regexp, error) {
+	pattern := "^(?:" + q.Answer + ")$"
+	if !q.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Select 按种子抽取题目，返回题目ID。count 为 0 或不少于题目数量时使用全部题目；
+// 不打乱时抽到的题目仍按 Order 排列，相同的种子总是得到相同的结果
+func Select(questions []model.QuizQuestion, count int, shuffle bool, seed int64) []uint {
+	sorted := make([]model.QuizQuestion, len(questions))
+	copy(sorted, questions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Order != sorted[j].Order {
+			return sorted[i].Order < sorted[j].Order
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	indexes := make([]int, len(sorted))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	r := rand.New(rand.NewSource(seed))
+	if shuffle || (count > 0 && count < len(sorted)) {
+		r.Shuffle(len(indexes), func(i, j int) {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		})
+	}
+	if count > 0 && count < len(indexes) {
+		indexes = indexes[:count]
+	}
+	if !shuffle {
+		sort.Ints(indexes)
+	}
+
+	ids := make([]uint, 0, len(indexes))
+	for _, i := range indexes {
+		ids = append(ids, sorted[i].ID)
+	}
+	return ids
+}
+
+// Options 返回题目的选项，打乱时按种子和题目ID确定顺序，同一次作答中多次获取顺序不变
+func Options(q model.QuizQuestion, shuffle bool, seed int64) []model.QuizOption {
+	options := make([]model.QuizOption, len(q.Options))
+	copy(options, q.Options)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Order < options[j].Order
+	})
+	if shuffle {
+		r := rand.New(rand.NewSource(seed ^ int64(q.ID)))
+		r.Shuffle(len(options), func(i, j int) {
+			options[i], options[j] = options[j], options[i]
+		})
+	}
+	return options
+}
+
+// FormatIDs 将ID列表保存为逗号分隔的字符串
+func FormatIDs(ids []uint) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseIDs 解析 FormatIDs 保存的ID列表，忽略无法解析的部分
+func ParseIDs(s string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
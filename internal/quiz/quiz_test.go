@@ -0,0 +1,180 @@
+package quiz
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func question(id uint, typ string) model.QuizQuestion {
+	return model.QuizQuestion{Model: gorm.Model{ID: id}, Order: id, Type: typ, Prompt: "?", Points: 2}
+}
+
+func option(id uint, correct bool) model.QuizOption {
+	return model.QuizOption{Model: gorm.Model{ID: id}, Order: id, Content: "option", Correct: correct}
+}
+
+func TestGradeChoice(t *testing.T) {
+	single := question(1, model.QuestionSingle)
+	single.Options = []model.QuizOption{option(1, false), option(2, true), option(3, false)}
+
+	ok, score := Grade(single, Response{OptionIDs: []uint{2}})
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, score)
+
+	ok, score = Grade(single, Response{OptionIDs: []uint{2, 3}})
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, score)
+
+	multiple := question(2, model.QuestionMultiple)
+	multiple.Options = []model.QuizOption{option(4, true), option(5, false), option(6, true)}
+
+	// 选项顺序和重复不影响结果，少选或多选都不得分
+	ok, _ = Grade(multiple, Response{OptionIDs: []uint{6, 4, 6}})
+	assert.True(t, ok)
+	ok, _ = Grade(multiple, Response{OptionIDs: []uint{4}})
+	assert.False(t, ok)
+	ok, _ = Grade(multiple, Response{OptionIDs: []uint{4, 5, 6}})
+	assert.False(t, ok)
+}
+
+func TestGradeNumeric(t *testing.T) {
+	q := question(1, model.QuestionNumeric)
+	q.Answer = "0.3"
+
+	tests := []struct {
+		text      string
+		tolerance float64
+		want      bool
+	}{
+		{"0.3", 0, true},
+		{" 0.30 ", 0, true},
+		{"0.31", 0, false},
+		{"0.31", 0.01, true},
+		{"0.32", 0.01, false},
+		{"3e-1", 0, true},
+		{"abc", 1, false},
+		{"", 1, false},
+	}
+	for _, tt := range tests {
+		q.Tolerance = tt.tolerance
+		ok, _ := Grade(q, Response{Text: tt.text})
+		assert.Equal(t, tt.want, ok, "%q with tolerance %v", tt.text, tt.tolerance)
+	}
+}
+
+func TestGradeText(t *testing.T) {
+	q := question(1, model.QuestionText)
+	q.Answer = "Kernel"
+
+	ok, _ := Grade(q, Response{Text: "  kernel\n"})
+	assert.True(t, ok)
+
+	q.CaseSensitive = true
+	ok, _ = Grade(q, Response{Text: "kernel"})
+	assert.False(t, ok)
+	ok, _ = Grade(q, Response{Text: "Kernel"})
+	assert.True(t, ok)
+}
+
+func TestGradeRegex(t *testing.T) {
+	q := question(1, model.QuestionRegex)
+	q.Answer = `fork|clone(\(\))?`
+
+	ok, _ := Grade(q, Response{Text: "FORK"})
+	assert.True(t, ok)
+	ok, _ = Grade(q, Response{Text: "clone()"})
+	assert.True(t, ok)
+
+	// 必须整个回答匹配
+	ok, _ = Grade(q, Response{Text: "vfork"})
+	assert.False(t, ok)
+	ok, _ = Grade(q, Response{Text: "fork and exec"})
+	assert.False(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	valid := question(1, model.QuestionSingle)
+	valid.Options = []model.QuizOption{option(1, true), option(2, false)}
+	assert.NoError(t, Validate(valid))
+
+	tests := []struct {
+		name   string
+		modify func(q *model.QuizQuestion)
+	}{
+		{"unknown type", func(q *model.QuizQuestion) { q.Type = "essay" }},
+		{"empty prompt", func(q *model.QuizQuestion) { q.Prompt = " " }},
+		{"negative points", func(q *model.QuizQuestion) { q.Points = -1 }},
+		{"single with two correct", func(q *model.QuizQuestion) { q.Options[1].Correct = true }},
+		{"no correct option", func(q *model.QuizQuestion) {
+			q.Type = model.QuestionMultiple
+			q.Options[0].Correct = false
+		}},
+		{"too few options", func(q *model.QuizQuestion) { q.Options = q.Options[:1] }},
+		{"numeric answer", func(q *model.QuizQuestion) {
+			q.Type, q.Options, q.Answer = model.QuestionNumeric, nil, "ten"
+		}},
+		{"invalid regex", func(q *model.QuizQuestion) {
+			q.Type, q.Options, q.Answer = model.QuestionRegex, nil, "("
+		}},
+		{"text with options", func(q *model.QuizQuestion) {
+			q.Type, q.Answer = model.QuestionText, "answer"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid
+			q.Options = append([]model.QuizOption(nil), valid.Options...)
+			tt.modify(&q)
+			assert.True(t, errors.Is(Validate(q), ErrInvalidQuestion))
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var questions []model.QuizQuestion
+	for i := uint(1); i <= 10; i++ {
+		questions = append(questions, question(i, model.QuestionText))
+	}
+
+	// 不打乱也不抽取时按顺序返回全部题目
+	assert.Equal(t, []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, Select(questions, 0, false, 42))
+
+	// 相同的种子得到相同的结果
+	shuffled := Select(questions, 0, true, 42)
+	assert.Equal(t, shuffled, Select(questions, 0, true, 42))
+	assert.ElementsMatch(t, []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, shuffled)
+	assert.NotEqual(t, shuffled, Select(questions, 0, true, 43))
+
+	// 抽取部分题目时不打乱仍按顺序排列
+	picked := Select(questions, 4, false, 7)
+	assert.Len(t, picked, 4)
+	assert.IsIncreasing(t, picked)
+
+	assert.Len(t, Select(questions, 20, true, 7), 10)
+}
+
+func TestOptions(t *testing.T) {
+	q := question(3, model.QuestionSingle)
+	for i := uint(6); i >= 1; i-- {
+		q.Options = append(q.Options, option(i, i == 1))
+	}
+
+	ordered := Options(q, false, 1)
+	for i, o := range ordered {
+		assert.Equal(t, uint(i+1), o.Order)
+	}
+
+	shuffled := Options(q, true, 1)
+	assert.Equal(t, shuffled, Options(q, true, 1))
+	assert.ElementsMatch(t, ordered, shuffled)
+}
+
+func TestIDs(t *testing.T) {
+	assert.Equal(t, "3,1,20", FormatIDs([]uint{3, 1, 20}))
+	assert.Equal(t, []uint{3, 1, 20}, ParseIDs("3,1,20"))
+	assert.Nil(t, ParseIDs(""))
+	assert.Equal(t, []uint{4}, ParseIDs("x, 4"))
+}
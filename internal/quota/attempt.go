@@ -0,0 +1,89 @@
+package quota
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"fmt"
+	"time"
+)
+
+// AttemptWindow 每小时检测次数限制的统计窗口
+const AttemptWindow = time.Hour
+
+// 检测次数受限的原因
+const (
+	AttemptReasonMaxAttempts = "max_attempts"
+	AttemptReasonHourly      = "max_attempts_per_hour"
+	AttemptReasonCooldown    = "failure_cooldown"
+)
+
+// AttemptStatus 用户在小节的检测次数状态
+type AttemptStatus struct {
+	Limited       bool       `json:"limited"`
+	Reason        string     `json:"reason,omitempty"`
+	Remaining     int        `json:"remaining"`                 // 剩余可用次数，-1 表示不限制
+	NextAllowedAt *time.Time `json:"next_allowed_at,omitempty"` // 下一次允许检测的时间，次数用尽时为空
+}
+
+// AttemptLimitError 检测次数受限时返回的错误
+type AttemptLimitError struct {
+	Status AttemptStatus
+}
+
+func (e *AttemptLimitError) Error() string {
+	if e.Status.NextAllowedAt == nil {
+		return fmt.Sprintf("check attempt limited: %s", e.Status.Reason)
+	}
+	return fmt.Sprintf("check attempt limited: %s, next attempt allowed at %s", e.Status.Reason, e.Status.NextAllowedAt.Format(time.RFC3339))
+}
+
+// CheckAttempts 返回检查检测次数的函数，受限时返回 AttemptLimitError，用于 SubmissionRepository.ReserveAttempt
+func CheckAttempts(section *model.Section, now time.Time) func(*repository.AttemptStats) error {
+	return func(stats *repository.AttemptStats) error {
+		if status := EvaluateAttempts(section, stats, now); status.Limited {
+			return &AttemptLimitError{Status: status}
+		}
+		return nil
+	}
+}
+
+// EvaluateAttempts 根据小节的限制和用户的提交统计计算检测次数状态
+func EvaluateAttempts(section *model.Section, stats *repository.AttemptStats, now time.Time) AttemptStatus {
+	status := AttemptStatus{Remaining: -1}
+
+	if section.MaxAttempts > 0 {
+		status.Remaining = int(int64(section.MaxAttempts) - stats.Total)
+		if status.Remaining <= 0 {
+			// 次数用尽后无法再检测
+			return AttemptStatus{Limited: true, Reason: AttemptReasonMaxAttempts, Remaining: 0}
+		}
+	}
+
+	if section.MaxAttemptsPerHour > 0 {
+		hourly := int(int64(section.MaxAttemptsPerHour) - stats.Recent)
+		if status.Remaining < 0 || hourly < status.Remaining {
+			status.Remaining = max(hourly, 0)
+		}
+		if hourly <= 0 {
+			status.Limited = true
+			status.Reason = AttemptReasonHourly
+			if stats.OldestRecent != nil {
+				next := stats.OldestRecent.Add(AttemptWindow)
+				status.NextAllowedAt = &next
+			}
+		}
+	}
+
+	if section.FailureCooldown > 0 && stats.LastFailedAt != nil {
+		until := stats.LastFailedAt.Add(time.Duration(section.FailureCooldown) * time.Second)
+		if now.Before(until) {
+			status.Limited = true
+			if status.NextAllowedAt == nil || until.After(*status.NextAllowedAt) {
+				status.Reason = AttemptReasonCooldown
+				status.NextAllowedAt = &until
+			}
+		}
+	}
+
+	return status
+}
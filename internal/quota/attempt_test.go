@@ -0,0 +1,61 @@
+package quota
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvaluateAttempts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	oldest := now.Add(-40 * time.Minute)
+	failedAt := now.Add(-30 * time.Second)
+
+	tests := []struct {
+		name      string
+		section   model.Section
+		stats     repository.AttemptStats
+		limited   bool
+		reason    string
+		remaining int
+		next      *time.Time
+	}{
+		{"不限制", model.Section{}, repository.AttemptStats{Total: 100}, false, "", -1, nil},
+		{"总次数剩余", model.Section{MaxAttempts: 5}, repository.AttemptStats{Total: 3}, false, "", 2, nil},
+		{"总次数用尽", model.Section{MaxAttempts: 5, MaxAttemptsPerHour: 10}, repository.AttemptStats{Total: 5}, true, AttemptReasonMaxAttempts, 0, nil},
+		{"每小时次数剩余更少", model.Section{MaxAttempts: 5, MaxAttemptsPerHour: 3}, repository.AttemptStats{Total: 2, Recent: 2}, false, "", 1, nil},
+		{"每小时次数用尽", model.Section{MaxAttemptsPerHour: 3}, repository.AttemptStats{Total: 3, Recent: 3, OldestRecent: &oldest}, true, AttemptReasonHourly, 0, ptr(oldest.Add(time.Hour))},
+		{"失败冷却中", model.Section{FailureCooldown: 60}, repository.AttemptStats{Total: 1, LastFailedAt: &failedAt}, true, AttemptReasonCooldown, -1, ptr(failedAt.Add(time.Minute))},
+		{"冷却结束", model.Section{FailureCooldown: 10}, repository.AttemptStats{Total: 1, LastFailedAt: &failedAt}, false, "", -1, nil},
+		{"取更晚的允许时间", model.Section{MaxAttemptsPerHour: 1, FailureCooldown: 3600}, repository.AttemptStats{Total: 1, Recent: 1, OldestRecent: &oldest, LastFailedAt: &failedAt}, true, AttemptReasonCooldown, 0, ptr(failedAt.Add(time.Hour))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := EvaluateAttempts(&tt.section, &tt.stats, now)
+			assert.Equal(t, tt.limited, status.Limited)
+			assert.Equal(t, tt.reason, status.Reason)
+			assert.Equal(t, tt.remaining, status.Remaining)
+			assert.Equal(t, tt.next, status.NextAllowedAt)
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestCheckAttempts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	check := CheckAttempts(&model.Section{MaxAttempts: 2}, now)
+
+	assert.NoError(t, check(&repository.AttemptStats{Total: 1}))
+
+	err := check(&repository.AttemptStats{Total: 2})
+	var limitErr *AttemptLimitError
+	if assert.ErrorAs(t, err, &limitErr) {
+		assert.Equal(t, AttemptReasonMaxAttempts, limitErr.Status.Reason)
+	}
+}
@@ -0,0 +1,171 @@
+package quota
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/configs"
+	"awesomeProject/pkg/db"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+var (
+	checkerInstance Checker
+	once            sync.Once
+
+	_ Checker = (*checker)(nil)
+
+	// ErrHostFull 全局资源不足，开启排队时任务会等待重试
+	ErrHostFull = errors.New("host is full")
+)
+
+const (
+	ScopeUser   = "user"
+	ScopeCourse = "course"
+	ScopeGlobal = "global"
+
+	defaultQueueRetryInterval = 30 * time.Second
+)
+
+// Limits 容器配额，数值为 0 表示不限制
+type Limits struct {
+	MaxContainersPerUser   int           `json:"max_containers_per_user"`
+	MaxContainersPerCourse int           `json:"max_containers_per_course"`
+	MaxContainers          int           `json:"max_containers"`
+	MaxCPUs                float64       `json:"max_cpus"`
+	MaxMemory              int64         `json:"max_memory"`
+	QueueWhenFull          bool          `json:"queue_when_full"`
+	QueueRetryInterval     time.Duration `json:"queue_retry_interval"`
+	QueueMaxRetry          int           `json:"queue_max_retry"`
+}
+
+// ExceededError 超出配额时返回的错误
+type ExceededError struct {
+	Scope    string // user / course / global
+	Resource string // containers / cpus / memory
+	Limit    float64
+	Current  float64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("container quota exceeded: %s %s limit is %v, currently %v in use", e.Scope, e.Resource, e.Limit, e.Current)
+}
+
+// Unwrap 全局配额不足视为主机已满
+func (e *ExceededError) Unwrap() error {
+	if e.Scope == ScopeGlobal {
+		return ErrHostFull
+	}
+	return nil
+}
+
+// Checker 在创建容器前检查并占用配额
+type Checker interface {
+	Reserve(instance *model.ContainerInstance, template *model.ContainerTemplate) error
+	Limits() Limits
+}
+
+func NewChecker() Checker {
+	once.Do(func() {
+		checkerInstance = &checker{
+			instanceRepo: repository.NewInstanceRepository(db.DB),
+			courseRepo:   repository.NewCourseRepository(db.DB),
+			limits:       parseQuotaConfig(),
+		}
+	})
+	return checkerInstance
+}
+
+type checker struct {
+	instanceRepo repository.InstanceRepository
+	courseRepo   repository.CourseRepository
+	limits       Limits
+}
+
+func (c *checker) Limits() Limits {
+	return c.limits
+}
+
+// Reserve 检查配额并为用户的容器创建占位记录，检查和创建在同一事务中进行，并发创建容器时不会超出配额
+func (c *checker) Reserve(instance *model.ContainerInstance, template *model.ContainerTemplate) error {
+	return c.instanceRepo.ReserveInstance(instance, func(instanceRepo repository.InstanceRepository) error {
+		return c.check(instanceRepo, instance.UserID, template)
+	})
+}
+
+// check 依次检查用户、课程和全局配额
+func (c *checker) check(instanceRepo repository.InstanceRepository, userID uint, template *model.ContainerTemplate) error {
+	if c.limits.MaxContainersPerUser > 0 {
+		usage, err := instanceRepo.GetRunningUsageByUserID(userID)
+		if err != nil {
+			return err
+		}
+		if usage.Containers >= int64(c.limits.MaxContainersPerUser) {
+			return &ExceededError{Scope: ScopeUser, Resource: "containers", Limit: float64(c.limits.MaxContainersPerUser), Current: float64(usage.Containers)}
+		}
+	}
+
+	if c.limits.MaxContainersPerCourse > 0 {
+		courseID, err := c.courseRepo.GetCourseIDByTemplateID(template.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// 模板没有关联课程时不做课程级限制
+		if err == nil {
+			usage, err := instanceRepo.GetRunningUsageByCourseID(courseID)
+			if err != nil {
+				return err
+			}
+			if usage.Containers >= int64(c.limits.MaxContainersPerCourse) {
+				return &ExceededError{Scope: ScopeCourse, Resource: "containers", Limit: float64(c.limits.MaxContainersPerCourse), Current: float64(usage.Containers)}
+			}
+		}
+	}
+
+	if c.limits.MaxContainers > 0 || c.limits.MaxCPUs > 0 || c.limits.MaxMemory > 0 {
+		usage, err := instanceRepo.GetRunningUsage()
+		if err != nil {
+			return err
+		}
+		return c.checkGlobal(usage, template)
+	}
+
+	return nil
+}
+
+// checkGlobal 检查加上新容器后是否超出全局配额
+func (c *checker) checkGlobal(usage *repository.InstanceUsage, template *model.ContainerTemplate) error {
+	if c.limits.MaxContainers > 0 && usage.Containers >= int64(c.limits.MaxContainers) {
+		return &ExceededError{Scope: ScopeGlobal, Resource: "containers", Limit: float64(c.limits.MaxContainers), Current: float64(usage.Containers)}
+	}
+	if c.limits.MaxCPUs > 0 && usage.CPUs+template.CPULimit > c.limits.MaxCPUs {
+		return &ExceededError{Scope: ScopeGlobal, Resource: "cpus", Limit: c.limits.MaxCPUs, Current: usage.CPUs}
+	}
+	if c.limits.MaxMemory > 0 && usage.Memory+template.MemoryLimit > c.limits.MaxMemory {
+		return &ExceededError{Scope: ScopeGlobal, Resource: "memory", Limit: float64(c.limits.MaxMemory), Current: float64(usage.Memory)}
+	}
+	return nil
+}
+
+func parseQuotaConfig() Limits {
+	cfg := configs.GetConfig().Quota
+
+	interval, err := time.ParseDuration(cfg.QueueRetryInterval)
+	if err != nil {
+		interval = defaultQueueRetryInterval
+	}
+
+	return Limits{
+		MaxContainersPerUser:   cfg.MaxContainersPerUser,
+		MaxContainersPerCourse: cfg.MaxContainersPerCourse,
+		MaxContainers:          cfg.MaxContainers,
+		MaxCPUs:                cfg.MaxCPUs,
+		MaxMemory:              cfg.MaxMemory,
+		QueueWhenFull:          cfg.QueueWhenFull,
+		QueueRetryInterval:     interval,
+		QueueMaxRetry:          cfg.QueueMaxRetry,
+	}
+}
@@ -0,0 +1,52 @@
+package quota
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChecker_CheckGlobal(t *testing.T) {
+	c := &checker{
+		limits: Limits{
+			MaxContainers: 10,
+			MaxCPUs:       8,
+			MaxMemory:     4096,
+		},
+	}
+	template := &model.ContainerTemplate{CPULimit: 2, MemoryLimit: 1024}
+
+	tests := []struct {
+		name     string
+		usage    repository.InstanceUsage
+		resource string
+	}{
+		{"资源充足", repository.InstanceUsage{Containers: 3, CPUs: 6, Memory: 3072}, ""},
+		{"容器数量已满", repository.InstanceUsage{Containers: 10}, "containers"},
+		{"CPU不足", repository.InstanceUsage{Containers: 3, CPUs: 7}, "cpus"},
+		{"内存不足", repository.InstanceUsage{Containers: 3, Memory: 3584}, "memory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.checkGlobal(&tt.usage, template)
+			if tt.resource == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var exceeded *ExceededError
+			assert.True(t, errors.As(err, &exceeded))
+			assert.Equal(t, tt.resource, exceeded.Resource)
+			assert.True(t, errors.Is(err, ErrHostFull), "全局配额不足应视为主机已满")
+		})
+	}
+}
+
+func TestExceededError_UserScopeIsNotHostFull(t *testing.T) {
+	err := &ExceededError{Scope: ScopeUser, Resource: "containers", Limit: 2, Current: 2}
+	assert.False(t, errors.Is(err, ErrHostFull))
+	assert.Contains(t, err.Error(), "user containers limit is 2")
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"awesomeProject/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"sync"
 )
 
@@ -13,9 +14,35 @@ var (
 	_ InstanceRepository = (*InstanceRepositoryImpl)(nil)
 )
 
+// InstanceReserved 已占用配额、容器还没有创建的实例状态
+const InstanceReserved = "Reserved"
+
+// 占用资源的容器状态
+var activeInstanceStatus = []string{InstanceReserved, "Pending", "Running"}
+
+// 不对用户展示的容器状态
+var hiddenInstanceStatus = []string{InstanceReserved, "Removed"}
+
+// InstanceUsage 运行中容器的资源占用
+type InstanceUsage struct {
+	UserID     uint    `json:"user_id,omitempty"`
+	Containers int64   `json:"containers"`
+	CPUs       float64 `json:"cpus"`
+	Memory     int64   `json:"memory"` // MB
+}
+
 type InstanceRepository interface {
 	CreateInstance(*model.ContainerInstance) error
+	ReserveInstance(instance *model.ContainerInstance, check func(InstanceRepository) error) error
+	UpdateInstance(*model.ContainerInstance) error
+	UpdateInstanceStatus(*model.ContainerInstance) error
+	GetInstanceByID(id uint) (*model.ContainerInstance, error)
 	GetInstanceByUserIDAndTemplateID(userID, templateID uint) (*model.ContainerInstance, error)
+	GetLatestInstancesByTemplateID(templateID uint) ([]model.ContainerInstance, error)
+	GetRunningUsage() (*InstanceUsage, error)
+	GetRunningUsageByUserID(userID uint) (*InstanceUsage, error)
+	GetRunningUsageByCourseID(courseID uint) (*InstanceUsage, error)
+	GetRunningUsageGroupByUser() ([]InstanceUsage, error)
 }
 
 func NewInstanceRepository(db *gorm.DB) InstanceRepository {
@@ -31,12 +58,119 @@ type InstanceRepositoryImpl struct {
 	DB *gorm.DB
 }
 
+// GetInstanceByID 根据ID获取容器实例
+func (r *InstanceRepositoryImpl) GetInstanceByID(id uint) (*model.ContainerInstance, error) {
+	var instance model.ContainerInstance
+	result := r.DB.First(&instance, id)
+	return &instance, result.Error
+}
+
+// GetInstanceByUserIDAndTemplateID 获取用户在该模板下最新的、已创建且未被移除的容器实例
 func (r *InstanceRepositoryImpl) GetInstanceByUserIDAndTemplateID(userID, templateID uint) (*model.ContainerInstance, error) {
 	var instance model.ContainerInstance
-	result := r.DB.Where("user_id = ? AND template_id = ?", userID, templateID).First(&instance)
+	result := r.DB.Where("user_id = ? AND template_id = ? AND status NOT IN ?", userID, templateID, hiddenInstanceStatus).
+		Order("id DESC").
+		First(&instance)
 	return &instance, result.Error
 }
 
+// GetLatestInstancesByTemplateID 获取该模板下每个用户最新的、已创建且未被移除的容器实例
+func (r *InstanceRepositoryImpl) GetLatestInstancesByTemplateID(templateID uint) ([]model.ContainerInstance, error) {
+	latest := r.DB.Model(&model.ContainerInstance{}).
+		Select("MAX(id)").
+		Where("template_id = ? AND status NOT IN ?", templateID, hiddenInstanceStatus).
+		Group("user_id")
+
+	var instances []model.ContainerInstance
+	result := r.DB.Where("id IN (?)", latest).Order("user_id ASC").Find(&instances)
+	return instances, result.Error
+}
+
 func (r *InstanceRepositoryImpl) CreateInstance(instance *model.ContainerInstance) error {
 	return r.DB.Create(instance).Error
 }
+
+// ReserveInstance 在同一事务中检查配额并创建 Reserved 状态的占位记录，check 通过事务内的仓库统计资源占用。
+// 占位记录计入资源占用，创建容器后用 UpdateInstance 保存容器信息
+func (r *InstanceRepositoryImpl) ReserveInstance(instance *model.ContainerInstance, check func(InstanceRepository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockQuota(tx); err != nil {
+			return err
+		}
+		if err := check(&InstanceRepositoryImpl{DB: tx}); err != nil {
+			return err
+		}
+		instance.Status = InstanceReserved
+		return tx.Create(instance).Error
+	})
+}
+
+// lockQuota 对容器配额行加锁直到事务结束，行不存在时先创建
+func lockQuota(tx *gorm.DB) error {
+	lock := model.QuotaLock{Name: "containers"}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("name = ?", lock.Name).
+		First(&lock).Error
+}
+
+func (r *InstanceRepositoryImpl) UpdateInstance(instance *model.ContainerInstance) error {
+	return r.DB.Save(instance).Error
+}
+
+// UpdateInstanceStatus 只更新容器的状态和结束时间，任务载荷中的实例不包含加密的SUDO密码等字段，不能整体保存
+func (r *InstanceRepositoryImpl) UpdateInstanceStatus(instance *model.ContainerInstance) error {
+	return r.DB.Model(&model.ContainerInstance{}).
+		Where("id = ?", instance.ID).
+		Updates(map[string]any{"status": instance.Status, "end_at": instance.EndAt}).Error
+}
+
+// GetRunningUsage 统计全局运行中容器的资源占用
+func (r *InstanceRepositoryImpl) GetRunningUsage() (*InstanceUsage, error) {
+	var usage InstanceUsage
+	result := r.usageQuery().Scan(&usage)
+	return &usage, result.Error
+}
+
+// GetRunningUsageByUserID 统计用户运行中容器的资源占用
+func (r *InstanceRepositoryImpl) GetRunningUsageByUserID(userID uint) (*InstanceUsage, error) {
+	var usage InstanceUsage
+	result := r.usageQuery().Where("container_instances.user_id = ?", userID).Scan(&usage)
+	return &usage, result.Error
+}
+
+// GetRunningUsageByCourseID 统计课程下运行中容器的资源占用，课程通过小节关联的模板确定
+func (r *InstanceRepositoryImpl) GetRunningUsageByCourseID(courseID uint) (*InstanceUsage, error) {
+	templateIDs := r.DB.Table("sections").
+		Select("sections.template_id").
+		Joins("JOIN chapters ON sections.chapter_id = chapters.id").
+		Where("chapters.course_id = ? AND sections.deleted_at IS NULL", courseID)
+
+	var usage InstanceUsage
+	result := r.usageQuery().Where("container_instances.template_id IN (?)", templateIDs).Scan(&usage)
+	return &usage, result.Error
+}
+
+// GetRunningUsageGroupByUser 按用户统计运行中容器的资源占用
+func (r *InstanceRepositoryImpl) GetRunningUsageGroupByUser() ([]InstanceUsage, error) {
+	var usages []InstanceUsage
+	result := r.usageQuery().
+		Select("container_instances.user_id AS user_id, " + usageColumns).
+		Group("container_instances.user_id").
+		Order("containers DESC").
+		Scan(&usages)
+	return usages, result.Error
+}
+
+const usageColumns = "COUNT(*) AS containers, " +
+	"COALESCE(SUM(container_templates.cpu_limit), 0) AS cpus, " +
+	"COALESCE(SUM(container_templates.memory_limit), 0) AS memory"
+
+func (r *InstanceRepositoryImpl) usageQuery() *gorm.DB {
+	return r.DB.Model(&model.ContainerInstance{}).
+		Select(usageColumns).
+		Joins("JOIN container_templates ON container_instances.template_id = container_templates.id").
+		Where("container_instances.status IN ?", activeInstanceStatus)
+}
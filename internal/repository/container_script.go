@@ -15,6 +15,7 @@ var (
 
 type ContainerScript interface {
 	GetScriptsByTemplateID(templateID uint) ([]*model.ContainerScript, error)
+	GetScriptsBySectionID(sectionID uint) ([]*model.ContainerScript, error)
 }
 
 func NewContainerScript(db *gorm.DB) ContainerScript {
@@ -38,6 +39,13 @@ func (r *ContainerScriptImpl) GetScriptsByTemplateID(templateID uint) ([]*model.
 	return scripts, result.Error
 }
 
+// GetScriptsBySectionID 获取小节的检测脚本，共用模板的小节各自有独立的检测脚本
+func (r *ContainerScriptImpl) GetScriptsBySectionID(sectionID uint) ([]*model.ContainerScript, error) {
+	var scripts []*model.ContainerScript
+	result := r.DB.Where("section_id = ?", sectionID).Order("`order` ASC").Find(&scripts)
+	return scripts, result.Error
+}
+
 type DevScriptRepositoryImpl struct {
 	DB *gorm.DB
 }
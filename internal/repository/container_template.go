@@ -15,6 +15,7 @@ var (
 
 type TemplateRepository interface {
 	GetTemplateByID(id uint) (*model.ContainerTemplate, error)
+	UpdateTemplateSUDOPass(id uint, encryptedPass string) error
 }
 
 func NewTemplateRepository(db *gorm.DB) TemplateRepository {
@@ -36,6 +37,18 @@ func (r *TemplateRepositoryImpl) GetTemplateByID(id uint) (*model.ContainerTempl
 	return &template, result.Error
 }
 
+// UpdateTemplateSUDOPass 更新模板的SUDO密码，传入的密码必须是加密后的
+func (r *TemplateRepositoryImpl) UpdateTemplateSUDOPass(id uint, encryptedPass string) error {
+	result := r.DB.Model(&model.ContainerTemplate{}).Where("id = ?", id).Update("sudo_pass", encryptedPass)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 type DevRepositoryImpl struct {
 	DB *gorm.DB
 }
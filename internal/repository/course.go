@@ -3,6 +3,7 @@ package repository
 import (
 	"awesomeProject/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"sync"
 )
 
@@ -20,6 +21,12 @@ type CourseRepository interface {
 	GetCourseReferencesByCourseID(courseID uint) ([]model.CourseReference, error)
 	GetCourseReferenceByID(referenceID uint) (model.CourseReference, error)
 	GetCourseStatusByCourseID(userID, courseID uint) ([]model.UserSectionStatus, error)
+	GetCourseIDByTemplateID(templateID uint) (uint, error)
+	GetChapterByID(id uint) (*model.Chapter, error)
+	GetCourseIDBySectionID(sectionID uint) (uint, error)
+	IsCourseTeacher(courseID, userID uint) (bool, error)
+	AddCourseTeacher(courseID, userID uint) error
+	RemoveCourseTeacher(courseID, userID uint) error
 }
 
 func NewCourseRepository(db *gorm.DB) CourseRepository {
@@ -87,3 +94,66 @@ func (r *CourseRepositoryImpl) GetCourseStatusByCourseID(userID, courseID uint)
 	}
 	return statuses, nil
 }
+
+// GetCourseIDByTemplateID 根据容器模板ID获取使用该模板的课程ID
+func (r *CourseRepositoryImpl) GetCourseIDByTemplateID(templateID uint) (uint, error) {
+	var courseIDs []uint
+	err := r.DB.Table("chapters").
+		Select("chapters.course_id").
+		Joins("JOIN sections ON sections.chapter_id = chapters.id").
+		Where("sections.template_id = ? AND sections.deleted_at IS NULL", templateID).
+		Limit(1).
+		Scan(&courseIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(courseIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return courseIDs[0], nil
+}
+
+// GetChapterByID 根据章节ID获取章节信息
+func (r *CourseRepositoryImpl) GetChapterByID(id uint) (*model.Chapter, error) {
+	var chapter model.Chapter
+	result := r.DB.First(&chapter, id)
+	return &chapter, result.Error
+}
+
+// GetCourseIDBySectionID 根据小节ID获取所属课程ID
+func (r *CourseRepositoryImpl) GetCourseIDBySectionID(sectionID uint) (uint, error) {
+	var courseIDs []uint
+	err := r.DB.Table("chapters").
+		Select("chapters.course_id").
+		Joins("JOIN sections ON sections.chapter_id = chapters.id").
+		Where("sections.id = ? AND sections.deleted_at IS NULL AND chapters.deleted_at IS NULL", sectionID).
+		Limit(1).
+		Scan(&courseIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(courseIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return courseIDs[0], nil
+}
+
+// IsCourseTeacher 判断用户是否为课程的授课教师
+func (r *CourseRepositoryImpl) IsCourseTeacher(courseID, userID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.CourseTeacher{}).
+		Where("course_id = ? AND user_id = ?", courseID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AddCourseTeacher 添加课程的授课教师，已经是授课教师时不做修改
+func (r *CourseRepositoryImpl) AddCourseTeacher(courseID, userID uint) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.CourseTeacher{CourseID: courseID, UserID: userID}).Error
+}
+
+// RemoveCourseTeacher 移除课程的授课教师
+func (r *CourseRepositoryImpl) RemoveCourseTeacher(courseID, userID uint) error {
+	return r.DB.Where("course_id = ? AND user_id = ?", courseID, userID).Delete(&model.CourseTeacher{}).Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+var (
+	gradebookRepositoryInstance GradebookRepository
+	gradebookSyncOnce           sync.Once
+
+	_ GradebookRepository = (*GradebookRepositoryImpl)(nil)
+)
+
+// GradebookRepository 定义成绩册仓库接口
+type GradebookRepository interface {
+	GetStudents(sectionIDs []uint, cohort string) ([]model.User, error)
+	GetSubmissions(sectionIDs []uint, from, to *time.Time) ([]model.Submission, error)
+	GetCheckPoints(sectionIDs []uint) (map[uint]float64, error)
+	GetQuestionPoints(sectionIDs []uint) (map[uint][]float64, error)
+	UpdateCohort(userID uint, cohort string) error
+}
+
+func NewGradebookRepository(db *gorm.DB) GradebookRepository {
+	gradebookSyncOnce.Do(func() {
+		gradebookRepositoryInstance = &GradebookRepositoryImpl{
+			DB: db,
+		}
+	})
+	return gradebookRepositoryInstance
+}
+
+type GradebookRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// GetStudents 获取参与了这些小节的学生，即在小节中创建过容器、有提交记录或学习状态的学生，
+// cohort 不为空时只返回该班级的学生
+func (r *GradebookRepositoryImpl) GetStudents(sectionIDs []uint, cohort string) ([]model.User, error) {
+	var users []model.User
+	if len(sectionIDs) == 0 {
+		return users, nil
+	}
+	query := r.DB.Where("role = ?", model.RoleStudent).Where(
+		r.DB.Where("id IN (?)", r.DB.Model(&model.Submission{}).Select("user_id").Where("section_id IN ?", sectionIDs)).
+			Or("id IN (?)", r.DB.Model(&model.UserSectionStatus{}).Select("user_id").Where("section_id IN ?", sectionIDs)).
+			Or("id IN (?)", r.DB.Model(&model.ContainerInstance{}).Select("user_id").Where("section_id IN ?", sectionIDs)),
+	)
+	if cohort != "" {
+		query = query.Where("cohort = ?", cohort)
+	}
+	result := query.Omit("password").Order("id").Find(&users)
+	return users, result.Error
+}
+
+// GetSubmissions 获取小节在时间范围 [from, to) 内的提交记录，不包括提交内容和检测结果
+func (r *GradebookRepositoryImpl) GetSubmissions(sectionIDs []uint, from, to *time.Time) ([]model.Submission, error) {
+	var submissions []model.Submission
+	if len(sectionIDs) == 0 {
+		return submissions, nil
+	}
+	query := r.DB.Omit("content").Where("section_id IN ?", sectionIDs)
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+	result := query.Order("id").Find(&submissions)
+	return submissions, result.Error
+}
+
+// GetCheckPoints 获取每个小节所有检测点的分值之和，不包括准备和清理脚本
+func (r *GradebookRepositoryImpl) GetCheckPoints(sectionIDs []uint) (map[uint]float64, error) {
+	points := make(map[uint]float64)
+	if len(sectionIDs) == 0 {
+		return points, nil
+	}
+	var rows []struct {
+		SectionID uint
+		Points    float64
+	}
+	result := r.DB.Model(&model.ContainerScript{}).
+		Select("section_id, SUM(points) AS points").
+		Where("section_id IN ? AND phase NOT IN ?", sectionIDs, []string{model.PhaseSetup, model.PhaseTeardown}).
+		Group("section_id").
+		Scan(&rows)
+	for _, row := range rows {
+		points[row.SectionID] = row.Points
+	}
+	return points, result.Error
+}
+
+// GetQuestionPoints 获取每个小节测验题目的分值
+func (r *GradebookRepositoryImpl) GetQuestionPoints(sectionIDs []uint) (map[uint][]float64, error) {
+	points := make(map[uint][]float64)
+	if len(sectionIDs) == 0 {
+		return points, nil
+	}
+	var questions []model.QuizQuestion
+	result := r.DB.Select("section_id", "points").Where("section_id IN ?", sectionIDs).Find(&questions)
+	for _, question := range questions {
+		points[question.SectionID] = append(points[question.SectionID], question.Points)
+	}
+	return points, result.Error
+}
+
+// UpdateCohort 设置用户所属班级
+func (r *GradebookRepositoryImpl) UpdateCohort(userID uint, cohort string) error {
+	var user model.User
+	if err := r.DB.Select("id").First(&user, userID).Error; err != nil {
+		return err
+	}
+	return r.DB.Model(&user).Update("cohort", cohort).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	hintRepositoryInstance HintRepository
+	hintSyncOnce           sync.Once
+
+	_ HintRepository = (*HintRepositoryImpl)(nil)
+)
+
+// HintRepository 定义提示仓库接口
+type HintRepository interface {
+	CreateHint(hint *model.Hint) error
+	UpdateHint(hint *model.Hint) error
+	DeleteHint(id uint) error
+	GetHintByID(id uint) (*model.Hint, error)
+	GetHintsBySectionID(sectionID uint) ([]model.Hint, error)
+	RecordUsage(usage *model.HintUsage) error
+	GetUsagesByUserID(userID, sectionID uint) ([]model.HintUsage, error)
+	GetUsagesBySectionID(sectionID uint) ([]model.HintUsage, error)
+}
+
+func NewHintRepository(db *gorm.DB) HintRepository {
+	hintSyncOnce.Do(func() {
+		hintRepositoryInstance = &HintRepositoryImpl{
+			DB: db,
+		}
+	})
+	return hintRepositoryInstance
+}
+
+type HintRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateHint 创建提示
+func (r *HintRepositoryImpl) CreateHint(hint *model.Hint) error {
+	return r.DB.Create(hint).Error
+}
+
+// UpdateHint 更新提示
+func (r *HintRepositoryImpl) UpdateHint(hint *model.Hint) error {
+	return r.DB.Save(hint).Error
+}
+
+// DeleteHint 删除提示
+func (r *HintRepositoryImpl) DeleteHint(id uint) error {
+	result := r.DB.Delete(&model.Hint{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetHintByID 根据ID获取提示
+func (r *HintRepositoryImpl) GetHintByID(id uint) (*model.Hint, error) {
+	var hint model.Hint
+	result := r.DB.First(&hint, id)
+	return &hint, result.Error
+}
+
+// GetHintsBySectionID 获取小节的所有提示，包括各检测点的提示
+func (r *HintRepositoryImpl) GetHintsBySectionID(sectionID uint) ([]model.Hint, error) {
+	var hints []model.Hint
+	result := r.DB.Where("section_id = ?", sectionID).Order("check_name ASC, `order` ASC").Find(&hints)
+	return hints, result.Error
+}
+
+// RecordUsage 记录用户查看了提示，重复查看只保留第一次的记录
+func (r *HintRepositoryImpl) RecordUsage(usage *model.HintUsage) error {
+	return r.DB.Where(model.HintUsage{UserID: usage.UserID, HintID: usage.HintID}).FirstOrCreate(usage).Error
+}
+
+// GetUsagesByUserID 获取用户在小节中查看过的提示
+func (r *HintRepositoryImpl) GetUsagesByUserID(userID, sectionID uint) ([]model.HintUsage, error) {
+	var usages []model.HintUsage
+	result := r.DB.Where("user_id = ? AND section_id = ?", userID, sectionID).Find(&usages)
+	return usages, result.Error
+}
+
+// GetUsagesBySectionID 获取小节所有提示的查看记录，按查看时间排序
+func (r *HintRepositoryImpl) GetUsagesBySectionID(sectionID uint) ([]model.HintUsage, error) {
+	var usages []model.HintUsage
+	result := r.DB.Where("section_id = ?", sectionID).Order("created_at ASC").Find(&usages)
+	return usages, result.Error
+}
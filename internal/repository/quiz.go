@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+var (
+	quizRepositoryInstance QuizRepository
+	quizSyncOnce           sync.Once
+
+	_ QuizRepository = (*QuizRepositoryImpl)(nil)
+)
+
+// ErrAttemptSubmitted 测验作答已经提交过
+var ErrAttemptSubmitted = errors.New("quiz attempt already submitted")
+
+// QuizRepository 定义测验仓库接口
+type QuizRepository interface {
+	CreateQuestion(question *model.QuizQuestion) error
+	UpdateQuestion(question *model.QuizQuestion) error
+	DeleteQuestion(id uint) error
+	GetQuestionByID(id uint) (*model.QuizQuestion, error)
+	GetQuestionsBySectionID(sectionID uint) ([]model.QuizQuestion, error)
+	StartAttempt(attempt *model.QuizAttempt, since time.Time, check func(*AttemptStats) error) (*model.QuizAttempt, error)
+	GetAttemptByID(id uint) (*model.QuizAttempt, error)
+	GetOpenAttempt(userID, sectionID uint) (*model.QuizAttempt, error)
+	GetAttemptsByUserID(userID, sectionID uint) ([]model.QuizAttempt, error)
+	SubmitAttempt(attempt *model.QuizAttempt, submission *model.Submission) error
+}
+
+func NewQuizRepository(db *gorm.DB) QuizRepository {
+	quizSyncOnce.Do(func() {
+		quizRepositoryInstance = &QuizRepositoryImpl{
+			DB: db,
+		}
+	})
+	return quizRepositoryInstance
+}
+
+type QuizRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateQuestion 创建题目及其选项
+func (r *QuizRepositoryImpl) CreateQuestion(question *model.QuizQuestion) error {
+	return r.DB.Create(question).Error
+}
+
+// UpdateQuestion 更新题目。带ID的选项原地更新，没有ID的选项新建，不在题目中的选项被删除，
+// 保留下来的选项ID不变，作答记录和进行中的作答引用的选项仍然有效
+func (r *QuizRepositoryImpl) UpdateQuestion(question *model.QuizQuestion) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Options").Save(question).Error; err != nil {
+			return err
+		}
+
+		kept := make([]uint, 0, len(question.Options))
+		for i := range question.Options {
+			option := &question.Options[i]
+			option.QuestionID = question.ID
+			if option.ID == 0 {
+				if err := tx.Create(option).Error; err != nil {
+					return err
+				}
+			} else {
+				err := tx.Model(option).Where("question_id = ?", question.ID).
+					Select("order", "content", "correct").Updates(option).Error
+				if err != nil {
+					return err
+				}
+			}
+			kept = append(kept, option.ID)
+		}
+
+		query := tx.Where("question_id = ?", question.ID)
+		if len(kept) > 0 {
+			query = query.Where("id NOT IN ?", kept)
+		}
+		return query.Delete(&model.QuizOption{}).Error
+	})
+}
+
+// DeleteQuestion 删除题目，已有作答中的题目保留原来的评分
+func (r *QuizRepositoryImpl) DeleteQuestion(id uint) error {
+	result := r.DB.Delete(&model.QuizQuestion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetQuestionByID 根据ID获取题目及其选项
+func (r *QuizRepositoryImpl) GetQuestionByID(id uint) (*model.QuizQuestion, error) {
+	var question model.QuizQuestion
+	result := r.DB.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("`order` ASC")
+	}).First(&question, id)
+	return &question, result.Error
+}
+
+// GetQuestionsBySectionID 获取小节的所有题目及其选项
+func (r *QuizRepositoryImpl) GetQuestionsBySectionID(sectionID uint) ([]model.QuizQuestion, error) {
+	var questions []model.QuizQuestion
+	result := r.DB.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("`order` ASC")
+	}).Where("section_id = ?", sectionID).Order("`order` ASC, id ASC").Find(&questions)
+	return questions, result.Error
+}
+
+// StartAttempt 在检测次数锁内创建测验作答：用户已有未提交的作答时返回该作答，
+// 否则统计检测次数交给 check 判断，通过后创建 attempt，并发开始测验不会超过次数限制
+func (r *QuizRepositoryImpl) StartAttempt(attempt *model.QuizAttempt, since time.Time, check func(*AttemptStats) error) (*model.QuizAttempt, error) {
+	started := attempt
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAttempts(tx, attempt.UserID, attempt.SectionID); err != nil {
+			return err
+		}
+
+		open, err := (&QuizRepositoryImpl{DB: tx}).GetOpenAttempt(attempt.UserID, attempt.SectionID)
+		if err == nil {
+			started = open
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		stats, err := (&SubmissionRepositoryImpl{DB: tx}).GetAttemptStats(attempt.UserID, attempt.SectionID, since)
+		if err != nil {
+			return err
+		}
+		if err := check(stats); err != nil {
+			return err
+		}
+		return tx.Create(attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return started, nil
+}
+
+// GetAttemptByID 根据ID获取测验作答及各题的作答
+func (r *QuizRepositoryImpl) GetAttemptByID(id uint) (*model.QuizAttempt, error) {
+	var attempt model.QuizAttempt
+	result := r.DB.Preload("Answers").First(&attempt, id)
+	return &attempt, result.Error
+}
+
+// GetOpenAttempt 获取用户在小节中尚未提交的作答
+func (r *QuizRepositoryImpl) GetOpenAttempt(userID, sectionID uint) (*model.QuizAttempt, error) {
+	var attempt model.QuizAttempt
+	result := r.DB.Where("user_id = ? AND section_id = ? AND status = ?", userID, sectionID, model.QuizInProgress).
+		Order("id DESC").
+		First(&attempt)
+	return &attempt, result.Error
+}
+
+// GetAttemptsByUserID 获取用户在小节中的所有作答，最近的在前
+func (r *QuizRepositoryImpl) GetAttemptsByUserID(userID, sectionID uint) ([]model.QuizAttempt, error) {
+	var attempts []model.QuizAttempt
+	result := r.DB.Where("user_id = ? AND section_id = ?", userID, sectionID).Order("id DESC").Find(&attempts)
+	return attempts, result.Error
+}
+
+// SubmitAttempt 在同一事务中创建提交记录并保存作答，提交序号在检测次数锁内分配，
+// 作答已经提交过时返回 ErrAttemptSubmitted
+func (r *QuizRepositoryImpl) SubmitAttempt(attempt *model.QuizAttempt, submission *model.Submission) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAttempts(tx, submission.UserID, submission.SectionID); err != nil {
+			return err
+		}
+
+		// 条件更新，避免同一次作答被并发提交两次
+		result := tx.Model(&model.QuizAttempt{}).
+			Where("id = ? AND status = ?", attempt.ID, model.QuizInProgress).
+			Update("status", model.QuizSubmitted)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAttemptSubmitted
+		}
+
+		count, err := (&SubmissionRepositoryImpl{DB: tx}).CountSubmissions(submission.UserID, submission.SectionID)
+		if err != nil {
+			return err
+		}
+		submission.Attempt = uint(count) + 1
+		if err := tx.Create(submission).Error; err != nil {
+			return err
+		}
+
+		attempt.Status = model.QuizSubmitted
+		attempt.SubmissionID = submission.ID
+		for i := range attempt.Answers {
+			attempt.Answers[i].AttemptID = attempt.ID
+		}
+		return tx.Save(attempt).Error
+	})
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+var (
+	regradeRepositoryInstance RegradeRepository
+	regradeSyncOnce           sync.Once
+
+	_ RegradeRepository = (*RegradeRepositoryImpl)(nil)
+)
+
+// RegradeRepository 定义重新评分任务仓库接口
+type RegradeRepository interface {
+	CreateJob(job *model.RegradeJob) error
+	UpdateJob(job *model.RegradeJob) error
+	GetJobByID(id uint) (*model.RegradeJob, error)
+	GetJobs() ([]model.RegradeJob, error)
+	RecordResult(result *model.RegradeResult) error
+}
+
+func NewRegradeRepository(db *gorm.DB) RegradeRepository {
+	regradeSyncOnce.Do(func() {
+		regradeRepositoryInstance = &RegradeRepositoryImpl{
+			DB: db,
+		}
+	})
+	return regradeRepositoryInstance
+}
+
+type RegradeRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateJob 创建重新评分任务
+func (r *RegradeRepositoryImpl) CreateJob(job *model.RegradeJob) error {
+	return r.DB.Create(job).Error
+}
+
+// UpdateJob 更新重新评分任务，不会修改关联的结果
+func (r *RegradeRepositoryImpl) UpdateJob(job *model.RegradeJob) error {
+	return r.DB.Omit("Results").Save(job).Error
+}
+
+// GetJobByID 根据ID获取重新评分任务及每个学生的结果
+func (r *RegradeRepositoryImpl) GetJobByID(id uint) (*model.RegradeJob, error) {
+	var job model.RegradeJob
+	result := r.DB.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("user_id ASC")
+	}).First(&job, id)
+	return &job, result.Error
+}
+
+// GetJobs 获取所有重新评分任务，不包括每个学生的结果
+func (r *RegradeRepositoryImpl) GetJobs() ([]model.RegradeJob, error) {
+	var jobs []model.RegradeJob
+	result := r.DB.Order("id DESC").Find(&jobs)
+	return jobs, result.Error
+}
+
+// RecordResult 保存单个学生的重新评分结果并更新任务进度，全部完成时将任务标记为 finished
+func (r *RegradeRepositoryImpl) RecordResult(result *model.RegradeResult) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"status": model.RegradeRunning,
+			"done":   gorm.Expr("done + 1"),
+		}
+		switch {
+		case result.Status == "" || result.Status == model.SubmissionError:
+			updates["skipped"] = gorm.Expr("skipped + 1")
+		case result.Delta > 0:
+			updates["improved"] = gorm.Expr("improved + 1")
+		case result.Delta < 0:
+			updates["regressed"] = gorm.Expr("regressed + 1")
+		}
+		if err := tx.Model(&model.RegradeJob{}).Where("id = ?", result.JobID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RegradeJob{}).
+			Where("id = ? AND done >= total", result.JobID).
+			Updates(map[string]interface{}{"status": model.RegradeFinished, "finished_at": time.Now()}).Error
+	})
+}
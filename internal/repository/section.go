@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+var (
+	sectionRepositoryInstance SectionRepository
+	sectionSyncOnce           sync.Once
+
+	_ SectionRepository = (*SectionRepositoryImpl)(nil)
+)
+
+// SectionRepository 定义小节仓库接口
+type SectionRepository interface {
+	GetSectionByID(id uint) (*model.Section, error)
+	GetSectionsByTemplateID(templateID uint) ([]model.Section, error)
+	RecordSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error)
+	SetSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error)
+	UpdateSolutionFiles(sectionID uint, files string) error
+}
+
+func NewSectionRepository(db *gorm.DB) SectionRepository {
+	sectionSyncOnce.Do(func() {
+		sectionRepositoryInstance = &SectionRepositoryImpl{
+			DB: db,
+		}
+	})
+	return sectionRepositoryInstance
+}
+
+type SectionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// GetSectionByID 根据小节ID获取小节信息
+func (r *SectionRepositoryImpl) GetSectionByID(id uint) (*model.Section, error) {
+	var section model.Section
+	result := r.DB.First(&section, id)
+	return &section, result.Error
+}
+
+// GetSectionsByTemplateID 获取使用容器模板的所有小节
+func (r *SectionRepositoryImpl) GetSectionsByTemplateID(templateID uint) ([]model.Section, error) {
+	var sections []model.Section
+	result := r.DB.Where("template_id = ?", templateID).Order("id ASC").Find(&sections)
+	return sections, result.Error
+}
+
+// UpdateSolutionFiles 更新查重时收集的答案文件，每行一个路径
+func (r *SectionRepositoryImpl) UpdateSolutionFiles(sectionID uint, files string) error {
+	result := r.DB.Model(&model.Section{}).Where("id = ?", sectionID).Update("solution_files", files)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecordSectionResult 记录用户在小节的检测结果，状态不存在时自动创建；
+// 已完成的小节不会因为之后的失败而变回未完成，按得分百分比保留最好成绩
+func (r *SectionRepositoryImpl) RecordSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error) {
+	return r.updateSectionStatus(userID, sectionID, func(status *model.UserSectionStatus, created bool) {
+		percentage := scorePercentage(score, maxScore)
+		if created || percentage > status.Percentage {
+			status.BestScore = score
+			status.MaxScore = maxScore
+			status.Percentage = percentage
+		}
+		if completed && !status.Completed {
+			now := time.Now()
+			status.Completed = true
+			status.Status = model.SectionCompleted
+			status.CompletedAt = &now
+		}
+	})
+}
+
+// SetSectionResult 直接写入用户在小节的成绩和完成状态，状态不存在时自动创建；
+// 用于人工评分，重新评分可以降低成绩或把小节变回未完成
+func (r *SectionRepositoryImpl) SetSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error) {
+	return r.updateSectionStatus(userID, sectionID, func(status *model.UserSectionStatus, created bool) {
+		status.BestScore = score
+		status.MaxScore = maxScore
+		status.Percentage = scorePercentage(score, maxScore)
+		switch {
+		case completed && !status.Completed:
+			now := time.Now()
+			status.Completed = true
+			status.Status = model.SectionCompleted
+			status.CompletedAt = &now
+		case !completed:
+			status.Completed = false
+			status.Status = model.SectionIncomplete
+			status.CompletedAt = nil
+		}
+	})
+}
+
+// updateSectionStatus 锁定用户在小节的状态后交给 update 修改并保存，状态不存在时先创建，created 表示状态是这次创建的。
+// 依靠 (user_id, section_id) 的唯一索引，并发的第一次提交也只会创建一条状态
+func (r *SectionRepositoryImpl) updateSectionStatus(userID, sectionID uint, update func(status *model.UserSectionStatus, created bool)) (*model.UserSectionStatus, error) {
+	var status model.UserSectionStatus
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		initial := model.UserSectionStatus{
+			UserID:    userID,
+			SectionID: sectionID,
+			Status:    model.SectionIncomplete,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&initial)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND section_id = ?", userID, sectionID).
+			First(&status).Error
+		if err != nil {
+			return err
+		}
+
+		update(&status, result.RowsAffected > 0)
+		return tx.Save(&status).Error
+	})
+	return &status, err
+}
+
+// scorePercentage 计算得分百分比，满分为 0 时视为满分
+func scorePercentage(score, maxScore float64) float64 {
+	if maxScore > 0 {
+		return score * 100 / maxScore
+	}
+	return 100
+}
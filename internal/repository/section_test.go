@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/db"
+	"sync"
+	"testing"
+)
+
+func TestRecordSectionResult(t *testing.T) {
+	// Setup - 创建测试数据
+	user := generateMockUser()
+	course := generateMockCourse(true)
+	defer cleanMockUser(user)
+	defer cleanMockCourse(course)
+	section := course.Chapters[0].Sections[0]
+	repo := NewSectionRepository(db.DB)
+
+	// Execute - 第一次检测失败时创建状态
+	status, err := repo.RecordSectionResult(user.ID, section.ID, 40, 100, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.ID == 0 || status.Completed || status.Status != model.SectionIncomplete {
+		t.Errorf("Expected a new incomplete status, got %+v", status)
+	}
+	if status.BestScore != 40 || status.Percentage != 40 {
+		t.Errorf("Expected best score 40, got %v (%v%%)", status.BestScore, status.Percentage)
+	}
+
+	// 通过后标记完成并记录完成时间
+	status, err = repo.RecordSectionResult(user.ID, section.ID, 90, 100, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !status.Completed || status.Status != model.SectionCompleted || status.CompletedAt == nil {
+		t.Errorf("Expected a completed status, got %+v", status)
+	}
+	completedAt := *status.CompletedAt
+
+	// 之后的失败和更低的得分不会改变完成状态和最好成绩
+	status, err = repo.RecordSectionResult(user.ID, section.ID, 10, 100, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !status.Completed || !status.CompletedAt.Equal(completedAt) {
+		t.Errorf("Expected the section to stay completed at %v, got %+v", completedAt, status)
+	}
+	if status.BestScore != 90 {
+		t.Errorf("Expected best score 90, got %v", status.BestScore)
+	}
+
+	// Verify - 每个用户在每个小节只有一条状态
+	var count int64
+	db.DB.Model(&model.UserSectionStatus{}).Where("user_id = ? AND section_id = ?", user.ID, section.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 status, got %v", count)
+	}
+}
+
+func TestRecordSectionResultConcurrently(t *testing.T) {
+	// Setup - 创建测试数据
+	user := generateMockUser()
+	course := generateMockCourse(true)
+	defer cleanMockUser(user)
+	defer cleanMockCourse(course)
+	section := course.Chapters[0].Sections[0]
+	repo := NewSectionRepository(db.DB)
+
+	// Execute - 并发的第一次检测
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(score float64) {
+			defer wg.Done()
+			if _, err := repo.RecordSectionResult(user.ID, section.ID, score, 100, false); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}(float64(i * 10))
+	}
+	wg.Wait()
+
+	// Verify - 只创建一条状态并保留最好成绩
+	var statuses []model.UserSectionStatus
+	db.DB.Where("user_id = ? AND section_id = ?", user.ID, section.ID).Find(&statuses)
+	if len(statuses) != 1 {
+		t.Fatalf("Expected 1 status, got %v", len(statuses))
+	}
+	if statuses[0].BestScore != 40 {
+		t.Errorf("Expected best score 40, got %v", statuses[0].BestScore)
+	}
+}
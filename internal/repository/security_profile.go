@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	securityProfileRepositoryInstance SecurityProfileRepository
+	securityProfileSyncOnce           sync.Once
+
+	_ SecurityProfileRepository = (*SecurityProfileRepositoryImpl)(nil)
+)
+
+// SecurityProfileRepository 定义安全配置仓库接口
+type SecurityProfileRepository interface {
+	GetAllProfiles() ([]model.SecurityProfile, error)
+	GetProfileByID(id uint) (*model.SecurityProfile, error)
+	GetProfileByName(name string) (*model.SecurityProfile, error)
+	CreateProfile(profile *model.SecurityProfile) error
+	UpdateProfile(profile *model.SecurityProfile) error
+	DeleteProfile(id uint) error
+	CountTemplatesByProfile(name string) (int64, error)
+}
+
+func NewSecurityProfileRepository(db *gorm.DB) SecurityProfileRepository {
+	securityProfileSyncOnce.Do(func() {
+		securityProfileRepositoryInstance = &SecurityProfileRepositoryImpl{
+			DB: db,
+		}
+	})
+	return securityProfileRepositoryInstance
+}
+
+type SecurityProfileRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// GetAllProfiles 获取所有安全配置
+func (r *SecurityProfileRepositoryImpl) GetAllProfiles() ([]model.SecurityProfile, error) {
+	var profiles []model.SecurityProfile
+	result := r.DB.Order("name ASC").Find(&profiles)
+	return profiles, result.Error
+}
+
+// GetProfileByID 根据ID获取安全配置
+func (r *SecurityProfileRepositoryImpl) GetProfileByID(id uint) (*model.SecurityProfile, error) {
+	var profile model.SecurityProfile
+	result := r.DB.First(&profile, id)
+	return &profile, result.Error
+}
+
+// GetProfileByName 根据名称获取安全配置
+func (r *SecurityProfileRepositoryImpl) GetProfileByName(name string) (*model.SecurityProfile, error) {
+	var profile model.SecurityProfile
+	result := r.DB.Where("name = ?", name).First(&profile)
+	return &profile, result.Error
+}
+
+// CreateProfile 创建安全配置
+func (r *SecurityProfileRepositoryImpl) CreateProfile(profile *model.SecurityProfile) error {
+	return r.DB.Create(profile).Error
+}
+
+// UpdateProfile 更新安全配置
+func (r *SecurityProfileRepositoryImpl) UpdateProfile(profile *model.SecurityProfile) error {
+	return r.DB.Save(profile).Error
+}
+
+// DeleteProfile 删除安全配置
+func (r *SecurityProfileRepositoryImpl) DeleteProfile(id uint) error {
+	return r.DB.Delete(&model.SecurityProfile{}, id).Error
+}
+
+// CountTemplatesByProfile 统计引用了该安全配置的模板数量
+func (r *SecurityProfileRepositoryImpl) CountTemplatesByProfile(name string) (int64, error) {
+	var count int64
+	result := r.DB.Model(&model.ContainerTemplate{}).Where("security_profile = ?", name).Count(&count)
+	return count, result.Error
+}
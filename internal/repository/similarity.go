@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	similarityRepositoryInstance SimilarityRepository
+	similaritySyncOnce           sync.Once
+
+	_ SimilarityRepository = (*SimilarityRepositoryImpl)(nil)
+)
+
+// SimilarityRepository 定义查重报告仓库接口
+type SimilarityRepository interface {
+	CreateReport(report *model.SimilarityReport) error
+	UpdateReport(report *model.SimilarityReport) error
+	GetReportByID(id uint) (*model.SimilarityReport, error)
+	GetReportsBySectionID(sectionID uint) ([]model.SimilarityReport, error)
+	CreatePairs(pairs []model.SimilarityPair) error
+	GetPairByID(reportID, pairID uint) (*model.SimilarityPair, error)
+}
+
+func NewSimilarityRepository(db *gorm.DB) SimilarityRepository {
+	similaritySyncOnce.Do(func() {
+		similarityRepositoryInstance = &SimilarityRepositoryImpl{
+			DB: db,
+		}
+	})
+	return similarityRepositoryInstance
+}
+
+type SimilarityRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateReport 创建查重报告
+func (r *SimilarityRepositoryImpl) CreateReport(report *model.SimilarityReport) error {
+	return r.DB.Create(report).Error
+}
+
+// UpdateReport 更新查重报告，不会修改关联的比较结果
+func (r *SimilarityRepositoryImpl) UpdateReport(report *model.SimilarityReport) error {
+	return r.DB.Omit("Pairs").Save(report).Error
+}
+
+// GetReportByID 根据ID获取查重报告，比较结果按相似度从高到低排列，不包括答案内容
+func (r *SimilarityRepositoryImpl) GetReportByID(id uint) (*model.SimilarityReport, error) {
+	var report model.SimilarityReport
+	result := r.DB.Preload("Pairs", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("source_a", "source_b", "diff").Order("score DESC")
+	}).First(&report, id)
+	return &report, result.Error
+}
+
+// GetReportsBySectionID 获取小节的所有查重报告，不包括比较结果
+func (r *SimilarityRepositoryImpl) GetReportsBySectionID(sectionID uint) ([]model.SimilarityReport, error) {
+	var reports []model.SimilarityReport
+	result := r.DB.Where("section_id = ?", sectionID).Order("id DESC").Find(&reports)
+	return reports, result.Error
+}
+
+// CreatePairs 保存比较结果及其相同的代码片段
+func (r *SimilarityRepositoryImpl) CreatePairs(pairs []model.SimilarityPair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	return r.DB.Create(&pairs).Error
+}
+
+// GetPairByID 获取查重报告中的一个比较结果，包括两份答案和相同的代码片段
+func (r *SimilarityRepositoryImpl) GetPairByID(reportID, pairID uint) (*model.SimilarityPair, error) {
+	var pair model.SimilarityPair
+	result := r.DB.Preload("Matches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_a ASC")
+	}).Where("report_id = ?", reportID).First(&pair, pairID)
+	return &pair, result.Error
+}
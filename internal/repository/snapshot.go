@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	snapshotRepositoryInstance SnapshotRepository
+	snapshotSyncOnce           sync.Once
+
+	_ SnapshotRepository = (*SnapshotRepositoryImpl)(nil)
+)
+
+// SnapshotRepository 定义工作目录快照仓库接口
+type SnapshotRepository interface {
+	CreateSnapshot(snapshot *model.WorkspaceSnapshot) error
+	GetLatestSnapshot(userID, sectionID uint) (*model.WorkspaceSnapshot, error)
+	GetLatestSnapshotsBySectionID(sectionID uint) ([]model.WorkspaceSnapshot, error)
+}
+
+func NewSnapshotRepository(db *gorm.DB) SnapshotRepository {
+	snapshotSyncOnce.Do(func() {
+		snapshotRepositoryInstance = &SnapshotRepositoryImpl{
+			DB: db,
+		}
+	})
+	return snapshotRepositoryInstance
+}
+
+type SnapshotRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateSnapshot 记录保存到对象存储的工作目录快照
+func (r *SnapshotRepositoryImpl) CreateSnapshot(snapshot *model.WorkspaceSnapshot) error {
+	return r.DB.Create(snapshot).Error
+}
+
+// GetLatestSnapshot 获取用户在小节最新的工作目录快照
+func (r *SnapshotRepositoryImpl) GetLatestSnapshot(userID, sectionID uint) (*model.WorkspaceSnapshot, error) {
+	var snapshot model.WorkspaceSnapshot
+	result := r.DB.Where("user_id = ? AND section_id = ?", userID, sectionID).
+		Order("id DESC").
+		First(&snapshot)
+	return &snapshot, result.Error
+}
+
+// GetLatestSnapshotsBySectionID 获取小节中每个用户最新的工作目录快照
+func (r *SnapshotRepositoryImpl) GetLatestSnapshotsBySectionID(sectionID uint) ([]model.WorkspaceSnapshot, error) {
+	latest := r.DB.Model(&model.WorkspaceSnapshot{}).
+		Select("MAX(id)").
+		Where("section_id = ?", sectionID).
+		Group("user_id")
+
+	var snapshots []model.WorkspaceSnapshot
+	result := r.DB.Where("id IN (?)", latest).Order("user_id ASC").Find(&snapshots)
+	return snapshots, result.Error
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+var (
+	submissionRepositoryInstance SubmissionRepository
+	submissionSyncOnce           sync.Once
+
+	_ SubmissionRepository = (*SubmissionRepositoryImpl)(nil)
+)
+
+// SubmissionRepository 定义检测提交仓库接口
+type SubmissionRepository interface {
+	CreateSubmission(submission *model.Submission) error
+	ReserveAttempt(submission *model.Submission, since time.Time, check func(*AttemptStats) error) (*AttemptStats, error)
+	UpdateSubmission(submission *model.Submission) error
+	GetSubmissionByID(id uint) (*model.Submission, error)
+	GetSubmissionsByUserID(userID, sectionID uint) ([]model.Submission, error)
+	CountSubmissions(userID, sectionID uint) (int64, error)
+	GetLatestSubmissions(sectionID uint) ([]model.Submission, error)
+	GetAttemptStats(userID, sectionID uint, since time.Time) (*AttemptStats, error)
+	GetFailureStats(userID, sectionID uint) (*FailureStats, error)
+	CreateCheckResult(result *model.CheckResult) error
+	CreateSubmissionFile(file *model.SubmissionFile) error
+	GetGradingQueue(courseID uint, pending bool) ([]model.Submission, error)
+}
+
+// AttemptStats 用户在小节的检测次数统计，因平台原因失败（error）的提交和重新评分产生的提交不计入
+type AttemptStats struct {
+	Total        int64      // 提交总数
+	Recent       int64      // 统计开始时间之后的提交数
+	OldestRecent *time.Time // 统计开始时间之后最早的提交时间
+	LastFailedAt *time.Time // 最近一次提交未通过时的完成时间
+}
+
+// FailureStats 用户在小节的未通过情况，只统计学生自己的提交
+type FailureStats struct {
+	Failed        int64            // 未通过的提交次数
+	CheckFailures map[string]int64 // 检测点名称 -> 该检测点未通过的提交次数
+	FirstAt       *time.Time       // 第一次提交的时间
+}
+
+func NewSubmissionRepository(db *gorm.DB) SubmissionRepository {
+	submissionSyncOnce.Do(func() {
+		submissionRepositoryInstance = &SubmissionRepositoryImpl{
+			DB: db,
+		}
+	})
+	return submissionRepositoryInstance
+}
+
+type SubmissionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// CreateSubmission 创建提交记录
+func (r *SubmissionRepositoryImpl) CreateSubmission(submission *model.Submission) error {
+	return r.DB.Create(submission).Error
+}
+
+// ReserveAttempt 在同一事务中锁定用户在小节的检测次数，统计后交给 check 判断，通过后创建提交记录并分配提交序号。
+// check 返回错误时不创建提交，返回的统计不包括本次提交
+func (r *SubmissionRepositoryImpl) ReserveAttempt(submission *model.Submission, since time.Time, check func(*AttemptStats) error) (*AttemptStats, error) {
+	var stats *AttemptStats
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAttempts(tx, submission.UserID, submission.SectionID); err != nil {
+			return err
+		}
+
+		repo := &SubmissionRepositoryImpl{DB: tx}
+		var err error
+		if stats, err = repo.GetAttemptStats(submission.UserID, submission.SectionID, since); err != nil {
+			return err
+		}
+		if err := check(stats); err != nil {
+			return err
+		}
+
+		count, err := repo.CountSubmissions(submission.UserID, submission.SectionID)
+		if err != nil {
+			return err
+		}
+		submission.Attempt = uint(count) + 1
+		return tx.Create(submission).Error
+	})
+	return stats, err
+}
+
+// lockAttempts 对用户在小节的检测次数行加锁直到事务结束，行不存在时先创建
+func lockAttempts(tx *gorm.DB, userID, sectionID uint) error {
+	lock := model.AttemptLock{UserID: userID, SectionID: sectionID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND section_id = ?", userID, sectionID).
+		First(&lock).Error
+}
+
+// UpdateSubmission 更新提交记录，不会修改关联的检测结果和文件
+func (r *SubmissionRepositoryImpl) UpdateSubmission(submission *model.Submission) error {
+	return r.DB.Omit("Results", "Files").Save(submission).Error
+}
+
+// GetSubmissionByID 根据ID获取提交记录及其检测结果
+func (r *SubmissionRepositoryImpl) GetSubmissionByID(id uint) (*model.Submission, error) {
+	var submission model.Submission
+	result := r.DB.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("`order` ASC, `case` ASC")
+	}).Preload("Files").First(&submission, id)
+	return &submission, result.Error
+}
+
+// GetSubmissionsByUserID 获取用户的提交记录，sectionID 为 0 时返回所有小节的记录
+func (r *SubmissionRepositoryImpl) GetSubmissionsByUserID(userID, sectionID uint) ([]model.Submission, error) {
+	var submissions []model.Submission
+	query := r.DB.Where("user_id = ?", userID)
+	if sectionID != 0 {
+		query = query.Where("section_id = ?", sectionID)
+	}
+	result := query.Order("id DESC").Find(&submissions)
+	return submissions, result.Error
+}
+
+// CountSubmissions 统计用户在小节的提交次数，不包括重新评分产生的提交
+func (r *SubmissionRepositoryImpl) CountSubmissions(userID, sectionID uint) (int64, error) {
+	var count int64
+	result := r.DB.Model(&model.Submission{}).
+		Where("user_id = ? AND section_id = ? AND `trigger` = ?", userID, sectionID, model.TriggerStudent).
+		Count(&count)
+	return count, result.Error
+}
+
+// GetLatestSubmissions 获取小节内每个学生最近一次已完成的提交，不包括重新评分产生的提交
+func (r *SubmissionRepositoryImpl) GetLatestSubmissions(sectionID uint) ([]model.Submission, error) {
+	finished := []string{model.SubmissionPassed, model.SubmissionFailed}
+	latest := r.DB.Model(&model.Submission{}).
+		Select("MAX(id)").
+		Where("section_id = ? AND `trigger` = ? AND status IN ?", sectionID, model.TriggerStudent, finished).
+		Group("user_id")
+
+	var submissions []model.Submission
+	result := r.DB.Where("id IN (?)", latest).Order("user_id ASC").Find(&submissions)
+	return submissions, result.Error
+}
+
+// CreateCheckResult 保存单个检测点的结果
+func (r *SubmissionRepositoryImpl) CreateCheckResult(result *model.CheckResult) error {
+	return r.DB.Create(result).Error
+}
+
+// CreateSubmissionFile 保存人工评分提交中上传的文件信息
+func (r *SubmissionRepositoryImpl) CreateSubmissionFile(file *model.SubmissionFile) error {
+	return r.DB.Create(file).Error
+}
+
+// GetGradingQueue 获取课程中人工评分小节的提交，pending 为 true 时只返回等待评分的提交，
+// 等待评分的提交按提交时间从早到晚排序，其余按评分时间从近到远排序
+func (r *SubmissionRepositoryImpl) GetGradingQueue(courseID uint, pending bool) ([]model.Submission, error) {
+	query := r.DB.Model(&model.Submission{}).
+		Joins("JOIN sections ON sections.id = submissions.section_id AND sections.deleted_at IS NULL").
+		Joins("JOIN chapters ON chapters.id = sections.chapter_id AND chapters.deleted_at IS NULL").
+		Where("chapters.course_id = ? AND sections.grading_mode = ?", courseID, model.GradingManual).
+		Preload("Files")
+	if pending {
+		query = query.Where("submissions.status = ?", model.SubmissionSubmitted).Order("submissions.id ASC")
+	} else {
+		query = query.Where("submissions.graded_at IS NOT NULL").Order("submissions.graded_at DESC")
+	}
+
+	var submissions []model.Submission
+	result := query.Find(&submissions)
+	return submissions, result.Error
+}
+
+// GetAttemptStats 统计用户在小节的检测次数，since 之后的提交计入 Recent
+func (r *SubmissionRepositoryImpl) GetAttemptStats(userID, sectionID uint, since time.Time) (*AttemptStats, error) {
+	stats := &AttemptStats{}
+	query := func() *gorm.DB {
+		return r.DB.Model(&model.Submission{}).
+			Where("user_id = ? AND section_id = ? AND status <> ? AND `trigger` = ?", userID, sectionID, model.SubmissionError, model.TriggerStudent)
+	}
+
+	if err := query().Count(&stats.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Where("created_at >= ?", since).Count(&stats.Recent).Error; err != nil {
+		return nil, err
+	}
+
+	var oldest []model.Submission
+	if err := query().Where("created_at >= ?", since).Order("created_at ASC").Limit(1).Find(&oldest).Error; err != nil {
+		return nil, err
+	}
+	if len(oldest) > 0 {
+		stats.OldestRecent = &oldest[0].CreatedAt
+	}
+
+	var last []model.Submission
+	if err := query().Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return nil, err
+	}
+	if len(last) > 0 && last[0].Status == model.SubmissionFailed {
+		stats.LastFailedAt = last[0].FinishedAt
+	}
+
+	return stats, nil
+}
+
+// GetFailureStats 统计用户在小节中未通过的提交次数和每个检测点未通过的次数
+func (r *SubmissionRepositoryImpl) GetFailureStats(userID, sectionID uint) (*FailureStats, error) {
+	stats := &FailureStats{CheckFailures: make(map[string]int64)}
+	query := func() *gorm.DB {
+		return r.DB.Model(&model.Submission{}).
+			Where("user_id = ? AND section_id = ? AND status <> ? AND `trigger` = ?", userID, sectionID, model.SubmissionError, model.TriggerStudent)
+	}
+
+	if err := query().Where("status = ?", model.SubmissionFailed).Count(&stats.Failed).Error; err != nil {
+		return nil, err
+	}
+
+	var first []model.Submission
+	if err := query().Order("created_at ASC").Limit(1).Find(&first).Error; err != nil {
+		return nil, err
+	}
+	if len(first) > 0 {
+		stats.FirstAt = &first[0].CreatedAt
+	}
+
+	// 测试报告会为一个脚本生成多个结果，按提交去重；重新导入套件后脚本ID会变化，
+	// 按脚本名称统计，已被替换（软删除）的脚本也计入
+	var rows []struct {
+		Name     string
+		Failures int64
+	}
+	err := r.DB.Model(&model.CheckResult{}).
+		Select("container_scripts.name, COUNT(DISTINCT check_results.submission_id) AS failures").
+		Joins("JOIN submissions ON submissions.id = check_results.submission_id").
+		Joins("JOIN container_scripts ON container_scripts.id = check_results.script_id").
+		Where("submissions.user_id = ? AND submissions.section_id = ? AND submissions.`trigger` = ?", userID, sectionID, model.TriggerStudent).
+		Where("submissions.deleted_at IS NULL AND check_results.status = ?", model.CheckFail).
+		Group("container_scripts.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		stats.CheckFailures[row.Name] = row.Failures
+	}
+
+	return stats, nil
+}
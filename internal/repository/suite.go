@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"awesomeProject/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+var (
+	suiteRepositoryInstance SuiteRepository
+	suiteSyncOnce           sync.Once
+
+	_ SuiteRepository = (*SuiteRepositoryImpl)(nil)
+)
+
+// SuiteRepository 定义检测套件仓库接口
+type SuiteRepository interface {
+	ImportSuite(suite *model.GradingSuite, scripts []model.ContainerScript) error
+	RestoreSuite(suite *model.GradingSuite, scripts []model.ContainerScript) error
+	GetLatestSuite(sectionID uint) (*model.GradingSuite, error)
+	GetSuite(sectionID, version uint) (*model.GradingSuite, error)
+	GetSuites(sectionID uint) ([]model.GradingSuite, error)
+}
+
+func NewSuiteRepository(db *gorm.DB) SuiteRepository {
+	suiteSyncOnce.Do(func() {
+		suiteRepositoryInstance = &SuiteRepositoryImpl{
+			DB: db,
+		}
+	})
+	return suiteRepositoryInstance
+}
+
+type SuiteRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// ImportSuite 保存新版本的检测套件，并用套件中的脚本替换小节原有的检测脚本，共用模板的其他小节不受影响。
+// 版本号在事务中分配，脚本的 SuiteVersion 会被设置为新版本号
+func (r *SuiteRepositoryImpl) ImportSuite(suite *model.GradingSuite, scripts []model.ContainerScript) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var latest []model.GradingSuite
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("section_id = ?", suite.SectionID).
+			Order("version DESC").Limit(1).
+			Find(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		suite.Version = 1
+		if len(latest) > 0 {
+			suite.Version = latest[0].Version + 1
+		}
+		if err := tx.Create(suite).Error; err != nil {
+			return err
+		}
+		return replaceScripts(tx, suite, scripts)
+	})
+}
+
+// RestoreSuite 用已有版本的脚本替换小节当前的检测脚本，丢弃手动修改，不产生新版本
+func (r *SuiteRepositoryImpl) RestoreSuite(suite *model.GradingSuite, scripts []model.ContainerScript) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceScripts(tx, suite, scripts)
+	})
+}
+
+// replaceScripts 删除小节原有的检测脚本并写入套件的脚本
+func replaceScripts(tx *gorm.DB, suite *model.GradingSuite, scripts []model.ContainerScript) error {
+	if err := tx.Where("section_id = ?", suite.SectionID).Delete(&model.ContainerScript{}).Error; err != nil {
+		return err
+	}
+	if len(scripts) == 0 {
+		return nil
+	}
+	for i := range scripts {
+		scripts[i].SectionID = suite.SectionID
+		scripts[i].SuiteVersion = suite.Version
+	}
+	return tx.Create(&scripts).Error
+}
+
+// GetLatestSuite 获取小节最新版本的检测套件
+func (r *SuiteRepositoryImpl) GetLatestSuite(sectionID uint) (*model.GradingSuite, error) {
+	var suite model.GradingSuite
+	result := r.DB.Where("section_id = ?", sectionID).Order("version DESC").First(&suite)
+	return &suite, result.Error
+}
+
+// GetSuite 获取小节指定版本的检测套件
+func (r *SuiteRepositoryImpl) GetSuite(sectionID, version uint) (*model.GradingSuite, error) {
+	var suite model.GradingSuite
+	result := r.DB.Where("section_id = ? AND version = ?", sectionID, version).First(&suite)
+	return &suite, result.Error
+}
+
+// GetSuites 获取小节所有版本的检测套件，不包括归档内容
+func (r *SuiteRepositoryImpl) GetSuites(sectionID uint) ([]model.GradingSuite, error) {
+	var suites []model.GradingSuite
+	result := r.DB.Omit("Archive").Where("section_id = ?", sectionID).Order("version DESC").Find(&suites)
+	return suites, result.Error
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"awesomeProject/internal/model"
-	"awesomeProject/pkg/db"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"sync"
@@ -78,15 +77,3 @@ func (r *UserRepositoryImpl) CheckUserExists(username, email string) (bool, erro
 	}
 	return count > 0, nil
 }
-
-// UpdateUserSectionStatus 更新用户小节完成状态
-func UpdateUserSectionStatus(status *model.UserSectionStatus) error {
-	// 先根据userId和sectionId查询到主键，然后更新
-	s := model.UserSectionStatus{UserID: status.UserID, SectionID: status.SectionID}
-	if err := db.DB.Where("user_id = ? AND section_id = ?", status.UserID, status.SectionID).First(&s).Error; err != nil {
-		return err
-	}
-	status.ID = s.ID
-	status.CreatedAt = s.CreatedAt
-	return db.DB.Save(status).Error
-}
@@ -0,0 +1,290 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Token 源代码中的一个词法单元，标识符、数字和字符串被归一化，改名不影响比较结果
+type Token struct {
+	Text string
+	Line int
+}
+
+// 常见语言的关键字，保留原文，其余标识符统一为 V
+var keywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true, "switch": true, "case": true,
+	"default": true, "break": true, "continue": true, "return": true, "goto": true, "func": true,
+	"def": true, "class": true, "struct": true, "type": true, "var": true, "const": true, "let": true,
+	"import": true, "from": true, "package": true, "in": true, "not": true, "and": true, "or": true,
+	"try": true, "except": true, "catch": true, "finally": true, "raise": true, "throw": true,
+	"new": true, "delete": true, "static": true, "void": true, "int": true, "char": true,
+	"long": true, "float": true, "double": true, "unsigned": true, "sizeof": true, "typedef": true,
+	"enum": true, "union": true, "then": true, "fi": true, "done": true, "esac": true, "elif": true,
+	"with": true, "as": true, "lambda": true, "yield": true, "go": true, "defer": true, "select": true,
+	"range": true, "map": true, "chan": true, "interface": true, "true": true, "false": true,
+	"null": true, "nil": true, "none": true,
+}
+
+// Tokenize 将源代码切分为词法单元，忽略空白和 //、#、/* */ 注释
+func Tokenize(source string) []Token {
+	var tokens []Token
+	runes := []rune(source)
+	line := 1
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || (r == '/' && i+1 < len(runes) && runes[i+1] == '/'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'' || r == '`':
+			start := line
+			i++
+			for i < len(runes) && runes[i] != r && runes[i] != '\n' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, Token{Text: "S", Line: start})
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, Token{Text: "N", Line: line})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if !keywords[word] {
+				word = "V"
+			}
+			tokens = append(tokens, Token{Text: word, Line: line})
+		default:
+			tokens = append(tokens, Token{Text: string(r), Line: line})
+			i++
+		}
+	}
+	return tokens
+}
+
+// Fingerprint 连续 k 个词法单元的哈希，记录其在源代码中的起止行
+type Fingerprint struct {
+	Hash      uint64
+	StartLine int
+	EndLine   int
+}
+
+// Winnow 用 winnowing 算法选取指纹：对每 w 个连续的 k-gram 哈希选取最小值（相同时取最右侧），
+// 长度不少于 w+k-1 个词法单元的相同片段一定能被检测到
+func Winnow(tokens []Token, k, w int) []Fingerprint {
+	if len(tokens) < k {
+		return nil
+	}
+
+	grams := make([]Fingerprint, len(tokens)-k+1)
+	for i := range grams {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+k] {
+			h.Write([]byte(token.Text))
+			h.Write([]byte{0})
+		}
+		grams[i] = Fingerprint{Hash: h.Sum64(), StartLine: tokens[i].Line, EndLine: tokens[i+k-1].Line}
+	}
+
+	var fingerprints []Fingerprint
+	last := -1
+	for start := 0; start == 0 || start+w <= len(grams); start++ {
+		end := min(start+w, len(grams))
+		selected := start
+		for i := start; i < end; i++ {
+			if grams[i].Hash <= grams[selected].Hash {
+				selected = i
+			}
+		}
+		if selected != last {
+			fingerprints = append(fingerprints, grams[selected])
+			last = selected
+		}
+	}
+	return fingerprints
+}
+
+// Options 相似度分析参数
+type Options struct {
+	K           int      // k-gram 包含的词法单元数量
+	Window      int      // winnowing 窗口大小
+	Boilerplate []string // 下发给学生的初始代码，其中的指纹不参与比较
+	CommonRatio float64  // 超过该比例的学生都有的指纹视为模板代码，不参与比较
+	CommonMin   int      // 共有指纹的学生不超过该数量时不视为模板代码，小班级中几个学生抄袭的代码不会被去掉
+	MinScore    float64  // 只保留相似度不低于该值（0-100）的结果
+	MaxPairs    int      // 最多保留的结果数量，0表示不限制
+}
+
+// DefaultOptions 默认分析参数
+func DefaultOptions() Options {
+	return Options{
+		K:           12,
+		Window:      8,
+		CommonRatio: 0.5,
+		CommonMin:   10,
+		MinScore:    30,
+		MaxPairs:    100,
+	}
+}
+
+// Document 一个学生的代码
+type Document struct {
+	ID     uint
+	Source string
+}
+
+// Match 两份代码中相同的片段，行号从1开始
+type Match struct {
+	StartA int
+	EndA   int
+	StartB int
+	EndB   int
+}
+
+// Pair 两份代码的比较结果
+type Pair struct {
+	A       uint    // 文档A的ID
+	B       uint    // 文档B的ID
+	Score   float64 // 相似度（0-100），共同指纹占较少一方指纹的比例
+	Shared  int     // 共同指纹数量
+	Matches []Match // 相同的片段，按 A 中的行号排序
+}
+
+// Analyze 两两比较所有文档，返回按相似度从高到低排序的结果
+func Analyze(documents []Document, options Options) []Pair {
+	fingerprints := make([]map[uint64]Fingerprint, len(documents))
+	frequency := make(map[uint64]int)
+	for i, document := range documents {
+		fingerprints[i] = make(map[uint64]Fingerprint)
+		for _, fp := range Winnow(Tokenize(document.Source), options.K, options.Window) {
+			if _, ok := fingerprints[i][fp.Hash]; !ok {
+				fingerprints[i][fp.Hash] = fp
+				frequency[fp.Hash]++
+			}
+		}
+	}
+
+	// 去掉初始代码和大多数学生都有的指纹
+	boilerplate := make(map[uint64]bool)
+	for _, source := range options.Boilerplate {
+		for _, fp := range Winnow(Tokenize(source), options.K, options.Window) {
+			boilerplate[fp.Hash] = true
+		}
+	}
+	common := max(options.CommonMin, int(options.CommonRatio*float64(len(documents))))
+	for i := range fingerprints {
+		for hash := range fingerprints[i] {
+			if boilerplate[hash] || frequency[hash] > common {
+				delete(fingerprints[i], hash)
+			}
+		}
+	}
+
+	// 倒排索引，只比较至少有一个共同指纹的文档
+	index := make(map[uint64][]int)
+	for i := range fingerprints {
+		for hash := range fingerprints[i] {
+			index[hash] = append(index[hash], i)
+		}
+	}
+	shared := make(map[[2]int][]uint64)
+	for hash, docs := range index {
+		for x := 0; x < len(docs); x++ {
+			for y := x + 1; y < len(docs); y++ {
+				a, b := min(docs[x], docs[y]), max(docs[x], docs[y])
+				shared[[2]int{a, b}] = append(shared[[2]int{a, b}], hash)
+			}
+		}
+	}
+
+	var pairs []Pair
+	for key, hashes := range shared {
+		a, b := fingerprints[key[0]], fingerprints[key[1]]
+		score := float64(len(hashes)) / float64(min(len(a), len(b))) * 100
+		if score < options.MinScore {
+			continue
+		}
+
+		matches := make([]Match, 0, len(hashes))
+		for _, hash := range hashes {
+			matches = append(matches, Match{
+				StartA: a[hash].StartLine,
+				EndA:   a[hash].EndLine,
+				StartB: b[hash].StartLine,
+				EndB:   b[hash].EndLine,
+			})
+		}
+		pairs = append(pairs, Pair{
+			A:       documents[key[0]].ID,
+			B:       documents[key[1]].ID,
+			Score:   score,
+			Shared:  len(hashes),
+			Matches: mergeMatches(matches),
+		})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	if options.MaxPairs > 0 && len(pairs) > options.MaxPairs {
+		pairs = pairs[:options.MaxPairs]
+	}
+	return pairs
+}
+
+// mergeMatches 合并两边都重叠或相邻的片段
+func mergeMatches(matches []Match) []Match {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].StartA != matches[j].StartA {
+			return matches[i].StartA < matches[j].StartA
+		}
+		return matches[i].StartB < matches[j].StartB
+	})
+
+	var merged []Match
+	for _, m := range matches {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if m.StartA <= last.EndA+1 && m.StartB <= last.EndB+1 && m.EndB >= last.StartB-1 {
+				last.EndA = max(last.EndA, m.EndA)
+				last.StartB = min(last.StartB, m.StartB)
+				last.EndB = max(last.EndB, m.EndB)
+				continue
+			}
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
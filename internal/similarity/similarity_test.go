@@ -0,0 +1,166 @@
+package similarity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const original = `#include <stdio.h>
+
+// 计算阶乘
+int factorial(int n) {
+    if (n <= 1) {
+        return 1;
+    }
+    return n * factorial(n - 1);
+}
+
+int main() {
+    int total = 0;
+    for (int i = 0; i < 10; i++) {
+        total += factorial(i);
+    }
+    printf("%d\n", total);
+    return 0;
+}
+`
+
+// 只修改了变量名、字符串和注释
+const renamed = `#include <stdio.h>
+
+/* recursive */
+int fact(int x) {
+    if (x <= 1) {
+        return 1;
+    }
+    return x * fact(x - 1);
+}
+
+int main() {
+    int sum = 0;
+    for (int k = 0; k < 10; k++) {
+        sum += fact(k);
+    }
+    printf("sum=%d\n", sum);
+    return 0;
+}
+`
+
+const different = `import sys
+
+def main():
+    words = sys.stdin.read().split()
+    counts = {}
+    for word in words:
+        counts[word] = counts.get(word, 0) + 1
+    print(len(counts))
+
+main()
+`
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("int count = 42; // comment\nreturn \"a \\\" b\";")
+	texts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		texts = append(texts, token.Text)
+	}
+	assert.Equal(t, []string{"int", "V", "=", "N", ";", "return", "S", ";"}, texts)
+	assert.Equal(t, 1, tokens[0].Line)
+	assert.Equal(t, 2, tokens[5].Line)
+}
+
+func TestTokenizeBlockComment(t *testing.T) {
+	tokens := Tokenize("a /* one\ntwo\n*/ b")
+	assert.Len(t, tokens, 2)
+	assert.Equal(t, 3, tokens[1].Line)
+}
+
+func TestWinnow(t *testing.T) {
+	tokens := Tokenize(original)
+	fingerprints := Winnow(tokens, 5, 4)
+	assert.NotEmpty(t, fingerprints)
+
+	// 每个窗口至少选取一个指纹，相邻指纹之间的距离不超过窗口大小
+	assert.GreaterOrEqual(t, len(fingerprints), (len(tokens)-5+1)/4)
+
+	// 改名后的代码指纹完全相同
+	assert.Equal(t, hashes(fingerprints), hashes(Winnow(Tokenize(renamed), 5, 4)))
+
+	assert.Nil(t, Winnow(tokens[:3], 5, 4))
+}
+
+func TestAnalyze(t *testing.T) {
+	options := DefaultOptions()
+	options.MinScore = 0
+	pairs := Analyze([]Document{
+		{ID: 1, Source: original},
+		{ID: 2, Source: different},
+		{ID: 3, Source: renamed},
+	}, options)
+
+	assert.NotEmpty(t, pairs)
+	assert.Equal(t, uint(1), pairs[0].A)
+	assert.Equal(t, uint(3), pairs[0].B)
+	assert.Equal(t, 100.0, pairs[0].Score)
+	assert.NotEmpty(t, pairs[0].Matches)
+	for _, pair := range pairs[1:] {
+		assert.Less(t, pair.Score, 50.0)
+	}
+
+	// 相同的片段合并为连续的行
+	match := pairs[0].Matches[0]
+	assert.LessOrEqual(t, match.StartA, match.EndA)
+	assert.LessOrEqual(t, match.StartB, match.EndB)
+}
+
+func TestAnalyzeCommonCode(t *testing.T) {
+	// 小班级中多个学生相同的代码仍然计入相似度
+	documents := []Document{
+		{ID: 1, Source: original},
+		{ID: 2, Source: renamed},
+		{ID: 3, Source: original},
+	}
+	pairs := Analyze(documents, DefaultOptions())
+	assert.Len(t, pairs, 3)
+
+	// 超过半数且超过 CommonMin 个学生都有的代码视为模板代码
+	options := DefaultOptions()
+	options.CommonMin = 2
+	assert.Empty(t, Analyze(documents, options))
+}
+
+func TestAnalyzeBoilerplate(t *testing.T) {
+	// 初始代码中的片段不计入相似度
+	documents := []Document{
+		{ID: 1, Source: original},
+		{ID: 2, Source: renamed},
+		{ID: 3, Source: different},
+	}
+	options := DefaultOptions()
+	options.Boilerplate = []string{original}
+	assert.Empty(t, Analyze(documents, options))
+}
+
+func TestAnalyzeMaxPairs(t *testing.T) {
+	documents := []Document{
+		{ID: 1, Source: original},
+		{ID: 2, Source: renamed},
+		{ID: 3, Source: different},
+		{ID: 4, Source: different},
+		{ID: 5, Source: ""},
+		{ID: 6, Source: ""},
+		{ID: 7, Source: ""},
+	}
+	options := DefaultOptions()
+	options.MaxPairs = 1
+	pairs := Analyze(documents, options)
+	assert.Len(t, pairs, 1)
+}
+
+func hashes(fingerprints []Fingerprint) []uint64 {
+	result := make([]uint64, 0, len(fingerprints))
+	for _, fp := range fingerprints {
+		result = append(result, fp.Hash)
+	}
+	return result
+}
@@ -0,0 +1,196 @@
+package suite
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxArchiveSize 检测套件解压后的最大总大小
+const MaxArchiveSize = 10 << 20
+
+// ErrArchiveTooLarge 检测套件超过大小限制
+var ErrArchiveTooLarge = fmt.Errorf("suite exceeds %d bytes", MaxArchiveSize)
+
+// LoadDir 从目录加载检测套件
+func LoadDir(dir string) (*Suite, error) {
+	files := make(map[string][]byte)
+	var total int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// 跳过版本控制等隐藏目录
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		if total > MaxArchiveSize {
+			return ErrArchiveTooLarge
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Parse(files)
+}
+
+// ReadArchive 从 tar.gz 归档加载检测套件，清单可以位于归档根目录或唯一的顶层目录中
+func ReadArchive(r io.Reader) (*Suite, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSuite, err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSuite, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := cleanPath(header.Name)
+		if name == "" || name != strings.TrimPrefix(path.Clean(header.Name), "./") {
+			return nil, fmt.Errorf("%w: invalid path %s", ErrInvalidSuite, header.Name)
+		}
+
+		total += header.Size
+		if total > MaxArchiveSize {
+			return nil, ErrArchiveTooLarge
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+
+	return Parse(stripTopDir(files))
+}
+
+// stripTopDir 清单位于唯一的顶层目录中时，去掉该目录前缀
+func stripTopDir(files map[string][]byte) map[string][]byte {
+	if _, ok := files[ManifestFile]; ok {
+		return files
+	}
+
+	var top string
+	for name := range files {
+		dir, _, found := strings.Cut(name, "/")
+		if !found || (top != "" && dir != top) {
+			return files
+		}
+		top = dir
+	}
+
+	stripped := make(map[string][]byte, len(files))
+	for name, data := range files {
+		stripped[strings.TrimPrefix(name, top+"/")] = data
+	}
+	return stripped
+}
+
+// WriteArchive 将检测套件写为 tar.gz 归档，文件按路径排序，内容相同时归档相同
+func WriteArchive(w io.Writer, s *Suite) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := s.Files[name]
+		mode := int64(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+		header := &tar.Header{
+			Name:    name,
+			Mode:    mode,
+			Size:    int64(len(data)),
+			ModTime: time.Unix(0, 0),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Archive 返回检测套件的 tar.gz 归档内容
+func (s *Suite) Archive() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteDir 将检测套件写入目录
+func WriteDir(dir string, s *Suite) error {
+	for name, data := range s.Files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+		if err := os.WriteFile(p, data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
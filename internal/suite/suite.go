@@ -0,0 +1,289 @@
+package suite
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ManifestFile 检测套件清单的文件名，位于套件根目录
+const ManifestFile = "suite.yaml"
+
+// 检测点未设置时的默认值，与 ContainerScript 的数据库默认值一致
+const (
+	defaultTimeout = 10
+	defaultPoints  = 1
+)
+
+// maxHintLength 检测点提示的最大长度，与 ContainerScript.Hint 的列宽一致
+const maxHintLength = 255
+
+// ErrInvalidSuite 检测套件的格式或内容不正确
+var ErrInvalidSuite = errors.New("invalid suite")
+
+// Manifest 检测套件清单，checks 的顺序即检测点的执行顺序
+type Manifest struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description,omitempty"`
+	Checks      []Check `yaml:"checks"`
+}
+
+// Check 清单中的一个检测脚本，script 和 expected_file 是相对套件根目录的路径
+type Check struct {
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description,omitempty"`
+	Phase        string   `yaml:"phase,omitempty"`
+	Visibility   string   `yaml:"visibility,omitempty"`
+	Hint         string   `yaml:"hint,omitempty"`
+	Script       string   `yaml:"script"`
+	Match        string   `yaml:"match,omitempty"`
+	Expected     string   `yaml:"expected,omitempty"`
+	ExpectedFile string   `yaml:"expected_file,omitempty"`
+	Timeout      uint     `yaml:"timeout,omitempty"`
+	Points       *float64 `yaml:"points,omitempty"`
+	Required     bool     `yaml:"required,omitempty"`
+	Parallel     bool     `yaml:"parallel,omitempty"`
+	Report       *Report  `yaml:"report,omitempty"`
+}
+
+// Report 检测脚本产生的测试报告
+type Report struct {
+	Format string `yaml:"format"`
+	Path   string `yaml:"path,omitempty"`
+}
+
+// Suite 检测套件，由清单和清单引用的文件组成
+type Suite struct {
+	Manifest Manifest
+	Files    map[string][]byte // 相对套件根目录的路径 -> 文件内容，包括清单本身
+}
+
+// Parse 从文件集合中解析并校验检测套件
+func Parse(files map[string][]byte) (*Suite, error) {
+	data, ok := files[ManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not found", ErrInvalidSuite, ManifestFile)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSuite, ManifestFile, err)
+	}
+
+	s := &Suite{Manifest: manifest, Files: files}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSuite, err)
+	}
+	return s, nil
+}
+
+// Validate 校验清单中的取值和引用的文件
+func (s *Suite) Validate() error {
+	if len(s.Manifest.Checks) == 0 {
+		return errors.New("suite has no checks")
+	}
+
+	names := make(map[string]bool)
+	for i, check := range s.Manifest.Checks {
+		where := fmt.Sprintf("check %d", i+1)
+		if check.Name != "" {
+			where = fmt.Sprintf("check %q", check.Name)
+			if names[check.Name] {
+				return fmt.Errorf("%s: duplicate name", where)
+			}
+			names[check.Name] = true
+		}
+
+		if _, ok := s.Files[cleanPath(check.Script)]; !ok || check.Script == "" {
+			return fmt.Errorf("%s: script %q not found", where, check.Script)
+		}
+		if check.ExpectedFile != "" {
+			if check.Expected != "" {
+				return fmt.Errorf("%s: expected and expected_file are exclusive", where)
+			}
+			if _, ok := s.Files[cleanPath(check.ExpectedFile)]; !ok {
+				return fmt.Errorf("%s: expected_file %q not found", where, check.ExpectedFile)
+			}
+		}
+
+		switch check.Phase {
+		case "", model.PhaseSetup, model.PhaseCheck, model.PhaseTeardown:
+		default:
+			return fmt.Errorf("%s: unknown phase %q", where, check.Phase)
+		}
+		// 准备和清理脚本总是依次执行
+		if check.Parallel && check.Phase != "" && check.Phase != model.PhaseCheck {
+			return fmt.Errorf("%s: only checks can run in parallel", where)
+		}
+		switch check.Visibility {
+		case "", model.VisibilityVisible, model.VisibilityHidden:
+		default:
+			return fmt.Errorf("%s: unknown visibility %q", where, check.Visibility)
+		}
+		if len([]rune(check.Hint)) > maxHintLength {
+			return fmt.Errorf("%s: hint must be at most %d characters", where, maxHintLength)
+		}
+		switch check.Match {
+		case grader.MatchNone, grader.MatchContains, grader.MatchEquals:
+		case grader.MatchRegex:
+			if _, err := regexp.Compile(check.Expected); err != nil {
+				return fmt.Errorf("%s: invalid regex: %v", where, err)
+			}
+		default:
+			return fmt.Errorf("%s: unknown match type %q", where, check.Match)
+		}
+		if check.Report != nil {
+			switch check.Report.Format {
+			case model.ReportTAP, model.ReportJUnit:
+			default:
+				return fmt.Errorf("%s: unknown report format %q", where, check.Report.Format)
+			}
+		}
+		if check.Points != nil && *check.Points < 0 {
+			return fmt.Errorf("%s: points must not be negative", where)
+		}
+	}
+	return nil
+}
+
+// Checksum 套件内容的摘要，文件内容相同的套件摘要相同，用于判断导入时是否产生新版本
+func (s *Suite) Checksum() string {
+	paths := make([]string, 0, len(s.Files))
+	for p := range s.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s\x00%d\x00", p, len(s.Files[p]))
+		h.Write(s.Files[p])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Scripts 将套件转换为检测脚本，version 记录在每个脚本上
+func (s *Suite) Scripts(sectionID, templateID, version uint) []model.ContainerScript {
+	scripts := make([]model.ContainerScript, 0, len(s.Manifest.Checks))
+	for i, check := range s.Manifest.Checks {
+		script := model.ContainerScript{
+			SectionID:      sectionID,
+			TemplateID:     templateID,
+			Order:          uint(i + 1),
+			Name:           check.Name,
+			Phase:          check.Phase,
+			Visibility:     check.Visibility,
+			Content:        string(s.Files[cleanPath(check.Script)]),
+			ExpectedOutput: check.Expected,
+			MatchType:      check.Match,
+			Timeout:        check.Timeout,
+			Points:         defaultPoints,
+			Description:    check.Description,
+			Hint:           check.Hint,
+			Required:       check.Required,
+			Parallel:       check.Parallel,
+			SuiteVersion:   version,
+		}
+		if check.ExpectedFile != "" {
+			script.ExpectedOutput = string(s.Files[cleanPath(check.ExpectedFile)])
+		}
+		if script.Phase == "" {
+			script.Phase = model.PhaseCheck
+		}
+		if script.Visibility == "" {
+			script.Visibility = model.VisibilityVisible
+		}
+		if script.Timeout == 0 {
+			script.Timeout = defaultTimeout
+		}
+		if check.Points != nil {
+			script.Points = *check.Points
+		}
+		if check.Report != nil {
+			script.ReportFormat = check.Report.Format
+			script.ReportPath = check.Report.Path
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts
+}
+
+// FromScripts 将数据库中的检测脚本导出为套件，每个脚本保存为 scripts 目录下的一个文件
+func FromScripts(name string, scripts []model.ContainerScript) (*Suite, error) {
+	sorted := make([]model.ContainerScript, len(scripts))
+	copy(sorted, scripts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	s := &Suite{
+		Manifest: Manifest{Name: name},
+		Files:    make(map[string][]byte),
+	}
+	for _, script := range sorted {
+		points := script.Points
+		check := Check{
+			Name:        script.Name,
+			Description: script.Description,
+			Hint:        script.Hint,
+			Script:      fmt.Sprintf("scripts/%02d-%s.sh", script.Order, slug(script.Name)),
+			Match:       script.MatchType,
+			Expected:    script.ExpectedOutput,
+			Timeout:     script.Timeout,
+			Points:      &points,
+			Required:    script.Required,
+			Parallel:    script.Parallel,
+		}
+		if script.Phase != model.PhaseCheck {
+			check.Phase = script.Phase
+		}
+		if script.Visibility != model.VisibilityVisible {
+			check.Visibility = script.Visibility
+		}
+		if script.ReportFormat != "" {
+			check.Report = &Report{Format: script.ReportFormat, Path: script.ReportPath}
+		}
+		s.Manifest.Checks = append(s.Manifest.Checks, check)
+		s.Files[check.Script] = []byte(script.Content)
+	}
+
+	manifest, err := yaml.Marshal(&s.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	s.Files[ManifestFile] = manifest
+	return s, nil
+}
+
+// VersionOf 返回检测脚本所属的套件版本，脚本不是从套件导入时为 0
+func VersionOf(scripts []model.ContainerScript) uint {
+	var version uint
+	for _, script := range scripts {
+		version = max(version, script.SuiteVersion)
+	}
+	return version
+}
+
+var slugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug 将检测点名称转换为适合作为文件名的形式
+func slug(name string) string {
+	s := strings.Trim(slugRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if s == "" {
+		return "check"
+	}
+	return s
+}
+
+// cleanPath 规范化套件中的相对路径
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
@@ -0,0 +1,193 @@
+package suite
+
+import (
+	"awesomeProject/internal/model"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testManifest = `
+name: hello
+checks:
+  - name: build
+    phase: setup
+    script: setup.sh
+    timeout: 30
+  - name: prints hello
+    script: checks/hello.sh
+    match: equals
+    expected_file: expected/hello.txt
+    points: 2
+    required: true
+  - name: unit tests
+    script: checks/test.sh
+    visibility: hidden
+    hint: 检查边界条件
+    points: 0
+    parallel: true
+    report:
+      format: tap
+      path: /tmp/report.tap
+`
+
+func testFiles() map[string][]byte {
+	return map[string][]byte{
+		ManifestFile:         []byte(testManifest),
+		"setup.sh":           []byte("make"),
+		"checks/hello.sh":    []byte("./hello"),
+		"checks/test.sh":     []byte("make test"),
+		"expected/hello.txt": []byte("hello\n"),
+	}
+}
+
+func TestParseScripts(t *testing.T) {
+	s, err := Parse(testFiles())
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", s.Manifest.Name)
+
+	scripts := s.Scripts(7, 3, 2)
+	assert.Len(t, scripts, 3)
+
+	assert.Equal(t, model.PhaseSetup, scripts[0].Phase)
+	assert.Equal(t, uint(30), scripts[0].Timeout)
+	assert.Equal(t, "make", scripts[0].Content)
+
+	assert.Equal(t, uint(2), scripts[1].Order)
+	assert.Equal(t, model.PhaseCheck, scripts[1].Phase)
+	assert.Equal(t, model.VisibilityVisible, scripts[1].Visibility)
+	assert.Equal(t, "hello\n", scripts[1].ExpectedOutput)
+	assert.Equal(t, uint(defaultTimeout), scripts[1].Timeout)
+	assert.Equal(t, 2.0, scripts[1].Points)
+	assert.True(t, scripts[1].Required)
+
+	// 显式设置为 0 分的检测点不使用默认分值
+	assert.Equal(t, 0.0, scripts[2].Points)
+	assert.Equal(t, model.VisibilityHidden, scripts[2].Visibility)
+	assert.Equal(t, "检查边界条件", scripts[2].Hint)
+	assert.Equal(t, model.ReportTAP, scripts[2].ReportFormat)
+	assert.Equal(t, "/tmp/report.tap", scripts[2].ReportPath)
+	assert.True(t, scripts[2].Parallel)
+	assert.False(t, scripts[1].Parallel)
+
+	for _, script := range scripts {
+		assert.Equal(t, uint(7), script.SectionID)
+		assert.Equal(t, uint(3), script.TemplateID)
+		assert.Equal(t, uint(2), script.SuiteVersion)
+	}
+	assert.Equal(t, uint(2), VersionOf(scripts))
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(files map[string][]byte)
+	}{
+		{"missing manifest", func(files map[string][]byte) { delete(files, ManifestFile) }},
+		{"missing script", func(files map[string][]byte) { delete(files, "checks/test.sh") }},
+		{"missing expected file", func(files map[string][]byte) { delete(files, "expected/hello.txt") }},
+		{"invalid yaml", func(files map[string][]byte) { files[ManifestFile] = []byte("checks: [") }},
+		{"unknown match", func(files map[string][]byte) {
+			files[ManifestFile] = []byte("checks:\n  - script: setup.sh\n    match: fuzzy\n")
+		}},
+		{"invalid regex", func(files map[string][]byte) {
+			files[ManifestFile] = []byte("checks:\n  - script: setup.sh\n    match: regex\n    expected: \"(\"\n")
+		}},
+		{"duplicate name", func(files map[string][]byte) {
+			files[ManifestFile] = []byte("checks:\n  - name: a\n    script: setup.sh\n  - name: a\n    script: setup.sh\n")
+		}},
+		{"parallel setup", func(files map[string][]byte) {
+			files[ManifestFile] = []byte("checks:\n  - script: setup.sh\n    phase: setup\n    parallel: true\n")
+		}},
+		{"long hint", func(files map[string][]byte) {
+			files[ManifestFile] = []byte("checks:\n  - script: setup.sh\n    hint: " + strings.Repeat("a", maxHintLength+1) + "\n")
+		}},
+		{"no checks", func(files map[string][]byte) { files[ManifestFile] = []byte("name: empty\n") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := testFiles()
+			tt.modify(files)
+			_, err := Parse(files)
+			assert.True(t, errors.Is(err, ErrInvalidSuite), "got %v", err)
+		})
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	s, err := Parse(testFiles())
+	assert.NoError(t, err)
+
+	archive, err := s.Archive()
+	assert.NoError(t, err)
+
+	// 内容相同时归档相同
+	again, err := s.Archive()
+	assert.NoError(t, err)
+	assert.Equal(t, archive, again)
+
+	loaded, err := ReadArchive(bytes.NewReader(archive))
+	assert.NoError(t, err)
+	assert.Equal(t, s.Files, loaded.Files)
+	assert.Equal(t, s.Checksum(), loaded.Checksum())
+}
+
+func TestReadArchiveTopDir(t *testing.T) {
+	files := make(map[string][]byte)
+	for name, data := range testFiles() {
+		files["hello-suite/"+name] = data
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteArchive(&buf, &Suite{Files: files}))
+
+	loaded, err := ReadArchive(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, testFiles(), loaded.Files)
+}
+
+func TestDirRoundTrip(t *testing.T) {
+	s, err := Parse(testFiles())
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	assert.NoError(t, WriteDir(dir, s))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0644))
+
+	loaded, err := LoadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, s.Files, loaded.Files)
+}
+
+func TestFromScripts(t *testing.T) {
+	s, err := Parse(testFiles())
+	assert.NoError(t, err)
+	scripts := s.Scripts(7, 3, 1)
+
+	exported, err := FromScripts("hello", scripts)
+	assert.NoError(t, err)
+
+	// 导出的套件重新导入后得到相同的检测脚本
+	reparsed, err := Parse(exported.Files)
+	assert.NoError(t, err)
+	assert.Equal(t, scripts, reparsed.Scripts(7, 3, 1))
+}
+
+func TestChecksum(t *testing.T) {
+	a, err := Parse(testFiles())
+	assert.NoError(t, err)
+
+	files := testFiles()
+	files["checks/hello.sh"] = []byte("./hello --verbose")
+	b, err := Parse(files)
+	assert.NoError(t, err)
+
+	assert.Len(t, a.Checksum(), 64)
+	assert.NotEqual(t, a.Checksum(), b.Checksum())
+}
@@ -1,11 +1,11 @@
 package task
 
 import (
+	"awesomeProject/internal/quota"
 	"awesomeProject/pkg/configs"
 	"awesomeProject/pkg/message"
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/hibiken/asynq"
 	"github.com/sirupsen/logrus"
 	"sync"
@@ -14,7 +14,10 @@ import (
 
 var clientSyncOnce sync.Once
 var client *Client
-var channelCancel map[string]context.CancelFunc
+var (
+	channelCancel   map[string]context.CancelFunc
+	channelCancelMu sync.Mutex
+)
 
 type Client struct {
 	AsynqClient *asynq.Client
@@ -48,8 +51,19 @@ func (c *Client) EnqueueContainerCreateTask(p ContainerCreatePayload) error {
 		return err
 	}
 
+	// 排队的任务在主机资源不足时会按配置的间隔重试
+	opts := []asynq.Option{asynq.MaxRetry(0), asynq.Queue("default")}
+	statusMessage := pendingMessage
+	channelTimeout := time.Minute
+	if p.Queued {
+		limits := quota.NewChecker().Limits()
+		opts = []asynq.Option{asynq.MaxRetry(limits.QueueMaxRetry), asynq.Queue("default")}
+		statusMessage = queuedMessage
+		channelTimeout = limits.QueueRetryInterval*time.Duration(limits.QueueMaxRetry+1) + time.Minute
+	}
+
 	task := asynq.NewTask(TypeContainerCreate, payload)
-	_, err = c.AsynqClient.Enqueue(task, asynq.MaxRetry(0), asynq.Queue("default"))
+	_, err = c.AsynqClient.Enqueue(task, opts...)
 	if err != nil {
 		return err
 	}
@@ -61,13 +75,15 @@ func (c *Client) EnqueueContainerCreateTask(p ContainerCreatePayload) error {
 		return err
 	}
 
+	// 在发送状态消息前登记，任务执行时才能停止发送
+	ctx, cancel := context.WithCancel(context.Background())
+	setChannelCancel(channelID, cancel)
+
 	go func() {
 		ticker := time.NewTicker(time.Millisecond * 500)
 		defer ticker.Stop()
 
-		timeout := time.After(time.Minute)
-		ctx, cancel := context.WithCancel(context.Background())
-		channelCancel[channelID] = cancel
+		timeout := time.After(channelTimeout)
 
 		for {
 			select {
@@ -80,7 +96,7 @@ func (c *Client) EnqueueContainerCreateTask(p ContainerCreatePayload) error {
 				}
 				return
 			case <-ticker.C:
-				ch <- fmt.Sprintf(pendingMessage)
+				ch <- statusMessage
 			}
 		}
 	}()
@@ -88,6 +104,17 @@ func (c *Client) EnqueueContainerCreateTask(p ContainerCreatePayload) error {
 	return nil
 }
 
+func (c *Client) EnqueueContainerRemoveTask(p ContainerRemovePayload) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(TypeContainerRemove, payload)
+	_, err = c.AsynqClient.Enqueue(task, asynq.MaxRetry(3), asynq.Queue("default"))
+	return err
+}
+
 func (c *Client) EnqueueContainerExecTask(p ContainerExecPayload) error {
 	payload, err := json.Marshal(p)
 	if err != nil {
@@ -102,3 +129,46 @@ func (c *Client) EnqueueContainerExecTask(p ContainerExecPayload) error {
 
 	return nil
 }
+
+// EnqueueRegradeTask 提交重新评分任务，使用低优先级队列避免影响学生的检测
+func (c *Client) EnqueueRegradeTask(p RegradePayload) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(TypeRegrade, payload)
+	_, err = c.AsynqClient.Enqueue(task, asynq.MaxRetry(0), asynq.Queue("low"))
+	return err
+}
+
+// EnqueueSimilarityTask 提交查重任务，需要读取所有学生的容器，使用低优先级队列
+func (c *Client) EnqueueSimilarityTask(p SimilarityPayload) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(TypeSimilarity, payload)
+	_, err = c.AsynqClient.Enqueue(task, asynq.MaxRetry(0), asynq.Queue("low"), asynq.Timeout(30*time.Minute))
+	return err
+}
+
+// setChannelCancel 登记停止发送状态消息的函数
+func setChannelCancel(channelID string, cancel context.CancelFunc) {
+	channelCancelMu.Lock()
+	defer channelCancelMu.Unlock()
+	channelCancel[channelID] = cancel
+}
+
+// cancelChannel 停止向状态通道发送消息，remove 为 true 时同时移除登记
+func cancelChannel(channelID string, remove bool) {
+	channelCancelMu.Lock()
+	defer channelCancelMu.Unlock()
+	if cancel, ok := channelCancel[channelID]; ok {
+		cancel()
+		if remove {
+			delete(channelCancel, channelID)
+		}
+	}
+}
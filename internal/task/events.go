@@ -0,0 +1,169 @@
+package task
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/message"
+	"github.com/sirupsen/logrus"
+	"sync/atomic"
+	"time"
+)
+
+// 检测过程推送给前端的事件名，事件内容为 JSON
+const (
+	EventRunStarted    = "run-started"
+	EventCheckStarted  = "check-started"
+	EventCheckFinished = "check-finished"
+	EventRunFinished   = "run-finished"
+	EventError         = "error"
+)
+
+// RunStartedEvent 检测开始，checks 为检测点数量
+type RunStartedEvent struct {
+	SubmissionID uint `json:"submission_id"`
+	Checks       int  `json:"checks"`
+	Concurrency  uint `json:"concurrency"`
+}
+
+// CheckStartedEvent 检测点开始执行，隐藏的检测点不包含名称
+type CheckStartedEvent struct {
+	Index int    `json:"index"`
+	Order uint   `json:"order"`
+	Name  string `json:"name,omitempty"`
+}
+
+// CheckFinishedEvent 检测点完成，生成测试报告的检测点每个用例一个事件，index 相同而 case 不同
+type CheckFinishedEvent struct {
+	Index       int     `json:"index"`
+	Order       uint    `json:"order"`
+	Case        uint    `json:"case,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Hidden      bool    `json:"hidden"`
+	Hint        string  `json:"hint,omitempty"`
+	Status      string  `json:"status"`
+	Score       float64 `json:"score"`
+	MaxScore    float64 `json:"max_score"`
+	Output      string  `json:"output,omitempty"`
+	Diff        string  `json:"diff,omitempty"`
+	Duration    int64   `json:"duration"`
+}
+
+// RunFinishedEvent 检测结束，总是最后一个事件
+type RunFinishedEvent struct {
+	SubmissionID uint    `json:"submission_id"`
+	Status       string  `json:"status"`
+	Passed       uint    `json:"passed"`
+	Total        uint    `json:"total"`
+	Score        float64 `json:"score"`
+	MaxScore     float64 `json:"max_score"`
+	Percentage   float64 `json:"percentage"`
+	Late         bool    `json:"late"`
+	Penalty      float64 `json:"penalty"`
+	Duration     int64   `json:"duration"`
+}
+
+// ErrorEvent 检测被终止的原因
+type ErrorEvent struct {
+	Message string `json:"message"`
+}
+
+// eventSendTimeout 通道已满时等待客户端读取的最长时间
+var eventSendTimeout = time.Second
+
+// eventStream 将一次检测的事件写入消息通道，事件 ID 从 1 开始递增，
+// 客户端可以据此判断是否丢失了事件。为 nil 时不发送任何事件
+type eventStream struct {
+	ch      chan string
+	id      atomic.Uint64
+	dropped atomic.Bool // 已经有事件因为客户端没有读取被丢弃
+}
+
+func newEventStream(ch chan string) *eventStream {
+	return &eventStream{ch: ch}
+}
+
+// send 写入事件。客户端没有连接或已经断开时通道不会被读取，等待超时后丢弃事件，避免阻塞检测任务；
+// 丢弃过事件后只在通道有空间时写入，不再等待
+func (s *eventStream) send(name string, data any) {
+	if s == nil {
+		return
+	}
+	id := s.id.Add(1)
+	event, err := message.NewEvent(id, name, data)
+	if err != nil {
+		logrus.Warnf("message.NewEvent %s failed: %v", name, err)
+		return
+	}
+
+	select {
+	case s.ch <- event:
+		return
+	default:
+	}
+	if s.dropped.Load() {
+		return
+	}
+	select {
+	case s.ch <- event:
+	case <-time.After(eventSendTimeout):
+		s.dropped.Store(true)
+		logrus.Warnf("drop %s event %d: nobody is reading the channel", name, id)
+	}
+}
+
+func (s *eventStream) runStarted(submission *model.Submission, scripts []model.ContainerScript, concurrency uint) {
+	_, checks, _ := grader.SplitPhases(scripts)
+	s.send(EventRunStarted, RunStartedEvent{
+		SubmissionID: submission.ID,
+		Checks:       len(checks),
+		Concurrency:  max(concurrency, 1),
+	})
+}
+
+func (s *eventStream) checkStarted(index int, script *model.ContainerScript) {
+	event := CheckStartedEvent{Index: index, Order: script.Order}
+	if script.Visibility != model.VisibilityHidden {
+		event.Name = script.Name
+	}
+	s.send(EventCheckStarted, event)
+}
+
+// checkFinished 隐藏的检测点只包含状态和得分
+func (s *eventStream) checkFinished(index int, result *model.CheckResult) {
+	redacted := grader.Redact(*result)
+	s.send(EventCheckFinished, CheckFinishedEvent{
+		Index:       index,
+		Order:       redacted.Order,
+		Case:        redacted.Case,
+		Name:        redacted.Name,
+		Description: redacted.Description,
+		Hidden:      redacted.Hidden,
+		Hint:        redacted.Hint,
+		Status:      redacted.Status,
+		Score:       redacted.Points,
+		MaxScore:    redacted.MaxPoints,
+		Output:      redacted.Output,
+		Diff:        redacted.Diff,
+		Duration:    redacted.Duration,
+	})
+}
+
+func (s *eventStream) runFinished(submission *model.Submission) {
+	s.send(EventRunFinished, RunFinishedEvent{
+		SubmissionID: submission.ID,
+		Status:       submission.Status,
+		Passed:       submission.Passed,
+		Total:        submission.Total,
+		Score:        submission.Score,
+		MaxScore:     submission.MaxScore,
+		Percentage:   submission.Percentage,
+		Late:         submission.Late,
+		Penalty:      submission.Penalty,
+		Duration:     submission.Duration,
+	})
+}
+
+func (s *eventStream) abort(err error) {
+	s.send(EventError, ErrorEvent{Message: err.Error()})
+}
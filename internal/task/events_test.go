@@ -0,0 +1,114 @@
+package task
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/message"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	ch := make(chan string, 10)
+	events := newEventStream(ch)
+
+	submission := &model.Submission{Status: model.SubmissionRunning}
+	submission.ID = 7
+	scripts := []model.ContainerScript{
+		{Order: 1, Phase: model.PhaseSetup, Name: "build"},
+		{Order: 2, Phase: model.PhaseCheck, Name: "hello"},
+		{Order: 3, Phase: model.PhaseCheck, Name: "secret", Visibility: model.VisibilityHidden},
+	}
+	events.runStarted(submission, scripts, 0)
+	events.checkStarted(1, &scripts[2])
+	events.checkFinished(1, &model.CheckResult{
+		Order:     3,
+		Name:      "secret",
+		Hidden:    true,
+		Status:    model.CheckPass,
+		Points:    2,
+		MaxPoints: 2,
+		Output:    "flag",
+		Hint:      "check the flag",
+	})
+	events.abort(errors.New("setup failed"))
+	submission.Status = model.SubmissionError
+	events.runFinished(submission)
+	close(ch)
+
+	var parsed []*message.Event
+	for msg := range ch {
+		event, ok := message.ParseEvent(msg)
+		assert.True(t, ok, msg)
+		parsed = append(parsed, event)
+	}
+	assert.Len(t, parsed, 5)
+	for i, event := range parsed {
+		assert.Equal(t, uint64(i+1), event.ID)
+	}
+	assert.Equal(t, []string{EventRunStarted, EventCheckStarted, EventCheckFinished, EventError, EventRunFinished},
+		[]string{parsed[0].Name, parsed[1].Name, parsed[2].Name, parsed[3].Name, parsed[4].Name})
+
+	var started RunStartedEvent
+	assert.NoError(t, json.Unmarshal(parsed[0].Data, &started))
+	assert.Equal(t, RunStartedEvent{SubmissionID: 7, Checks: 2, Concurrency: 1}, started)
+
+	// 隐藏的检测点不暴露名称和输出
+	var check CheckStartedEvent
+	assert.NoError(t, json.Unmarshal(parsed[1].Data, &check))
+	assert.Equal(t, "", check.Name)
+	var finished CheckFinishedEvent
+	assert.NoError(t, json.Unmarshal(parsed[2].Data, &finished))
+	assert.Equal(t, 1, finished.Index)
+	assert.Equal(t, model.CheckPass, finished.Status)
+	assert.Equal(t, 2.0, finished.Score)
+	assert.Equal(t, "", finished.Name)
+	assert.Equal(t, "", finished.Output)
+	assert.Equal(t, "", finished.Hint)
+
+	var run RunFinishedEvent
+	assert.NoError(t, json.Unmarshal(parsed[4].Data, &run))
+	assert.Equal(t, model.SubmissionError, run.Status)
+}
+
+func TestParseEventIgnoresStatusMessages(t *testing.T) {
+	for _, msg := range []string{runningMessage, pendingMessage, "Running", ""} {
+		_, ok := message.ParseEvent(msg)
+		assert.False(t, ok, msg)
+	}
+
+	// 为 nil 时不发送事件
+	var events *eventStream
+	events.runFinished(&model.Submission{})
+}
+
+func TestEventStreamDropsWhenNobodyReads(t *testing.T) {
+	defer func(timeout time.Duration) { eventSendTimeout = timeout }(eventSendTimeout)
+	eventSendTimeout = 10 * time.Millisecond
+
+	ch := make(chan string, 1)
+	events := newEventStream(ch)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			events.abort(errors.New("boom"))
+		}
+		events.runFinished(&model.Submission{})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("send blocked on a full channel")
+	}
+
+	// 只保留通道中放得下的第一个事件
+	assert.Len(t, ch, 1)
+	event, ok := message.ParseEvent(<-ch)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), event.ID)
+}
@@ -3,13 +3,35 @@ package task
 import "awesomeProject/internal/model"
 
 type ContainerCreatePayload struct {
-	Template model.ContainerTemplate
-	UserID   uint
+	Template   model.ContainerTemplate
+	UserID     uint
+	SectionID  uint // 容器所属的小节，决定注入的实验参数，模板不属于任何小节时为0
+	InstanceID uint // 已占用配额的占位记录，排队时为0，由任务执行时占用
+	Queued     bool // 主机资源不足时排队等待
 }
 
 type ContainerExecPayload struct {
-	Instance   model.ContainerInstance
-	Scripts    []model.ContainerScript
-	UserID     uint
-	TemplateID uint
+	Instance     model.ContainerInstance
+	Template     model.ContainerTemplate
+	Scripts      []model.ContainerScript
+	UserID       uint
+	TemplateID   uint
+	SubmissionID uint
+}
+
+type ContainerRemovePayload struct {
+	Instance model.ContainerInstance
+}
+
+// RegradePayload 重新评分单个学生的最近一次提交
+type RegradePayload struct {
+	JobID    uint
+	Previous model.Submission
+	Template model.ContainerTemplate
+	Scripts  []model.ContainerScript
+}
+
+// SimilarityPayload 生成小节的查重报告
+type SimilarityPayload struct {
+	ReportID uint
 }
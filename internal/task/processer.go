@@ -1,15 +1,29 @@
 package task
 
 import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/params"
+	"awesomeProject/internal/quota"
 	"awesomeProject/internal/repository"
+	"awesomeProject/internal/similarity"
+	"awesomeProject/internal/suite"
+	"awesomeProject/internal/workspace"
 	"awesomeProject/pkg/container"
 	"awesomeProject/pkg/db"
 	"awesomeProject/pkg/message"
+	"awesomeProject/pkg/oss"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hibiken/asynq"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,17 +32,38 @@ var processOnce sync.Once
 var processor *ContainerProcessor
 
 type ContainerProcessor struct {
-	containerManager   container.Manager
-	messageManager     message.Manager
-	instanceRepository repository.InstanceRepository
+	containerManager     container.Manager
+	messageManager       message.Manager
+	instanceRepository   repository.InstanceRepository
+	submissionRepository repository.SubmissionRepository
+	sectionRepository    repository.SectionRepository
+	courseRepository     repository.CourseRepository
+	regradeRepository    repository.RegradeRepository
+	similarityRepository repository.SimilarityRepository
+	templateRepository   repository.TemplateRepository
+	snapshotRepository   repository.SnapshotRepository
+	ossManager           oss.Manager
+	quotaChecker         quota.Checker
+	runner               *grader.Runner
 }
 
 func newContainerProcessor() *ContainerProcessor {
 	processOnce.Do(func() {
+		containerManager := container.NewManager()
 		processor = &ContainerProcessor{
-			containerManager:   container.NewManager(),
-			messageManager:     message.NewChannelManager(),
-			instanceRepository: repository.NewInstanceRepository(db.DB),
+			containerManager:     containerManager,
+			messageManager:       message.NewChannelManager(),
+			instanceRepository:   repository.NewInstanceRepository(db.DB),
+			submissionRepository: repository.NewSubmissionRepository(db.DB),
+			sectionRepository:    repository.NewSectionRepository(db.DB),
+			courseRepository:     repository.NewCourseRepository(db.DB),
+			regradeRepository:    repository.NewRegradeRepository(db.DB),
+			similarityRepository: repository.NewSimilarityRepository(db.DB),
+			templateRepository:   repository.NewTemplateRepository(db.DB),
+			snapshotRepository:   repository.NewSnapshotRepository(db.DB),
+			ossManager:           oss.NewOssClient(),
+			quotaChecker:         quota.NewChecker(),
+			runner:               grader.NewRunner(containerManager),
 		}
 	})
 	return processor
@@ -37,6 +72,9 @@ func newContainerProcessor() *ContainerProcessor {
 func (p *ContainerProcessor) Register(mux *asynq.ServeMux) {
 	mux.HandleFunc(TypeContainerCreate, p.handleContainerCreateTask)
 	mux.HandleFunc(TypeContainerExec, p.handleContainerExecTask)
+	mux.HandleFunc(TypeContainerRemove, p.handleContainerRemoveTask)
+	mux.HandleFunc(TypeRegrade, p.handleRegradeTask)
+	mux.HandleFunc(TypeSimilarity, p.handleSimilarityTask)
 }
 
 func (p *ContainerProcessor) handleContainerCreateTask(ctx context.Context, t *asynq.Task) error {
@@ -47,34 +85,51 @@ func (p *ContainerProcessor) handleContainerCreateTask(ctx context.Context, t *a
 	}
 
 	channelID := ContainerCreateChannelName(payload.UserID, payload.Template.ID)
-	defer func() {
-		cancel := channelCancel[channelID]
-		cancel()
-		delete(channelCancel, channelID)
-		err := p.messageManager.RemoveChannel(channelID)
-		if err != nil {
-			logrus.Warnf("messageManager.RemoveChannel failed: %v", err)
+
+	// 排队的任务或重试的任务在执行时占用配额
+	reserved, err := p.reserveInstance(&payload)
+	if err != nil {
+		if payload.Queued && errors.Is(err, quota.ErrHostFull) {
+			// 主机已满，保留状态通道，等待下一次重试
+			logrus.Infof("host is full, container create task of user %d is waiting: %v", payload.UserID, err)
+			return err
 		}
-	}()
+		p.closeCreateChannel(channelID)
+		return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+	defer p.closeCreateChannel(channelID)
 
 	instance, err := p.containerManager.CreateContainer(&payload.Template)
 	if err != nil {
 		logrus.Warnf("containerManager.CreateContainer failed: %v", err)
+		p.releaseInstance(reserved)
 		return err
 	}
 
 	err = p.containerManager.StartContainer(instance)
 	if err != nil {
 		logrus.Warnf("containerManager.StartContainer failed: %v", err)
+		if removeErr := p.containerManager.RemoveContainer(instance); removeErr != nil {
+			logrus.Warnf("containerManager.RemoveContainer failed: %v", removeErr)
+		}
+		p.releaseInstance(reserved)
 		return err
 	}
 
+	// 容器信息保存到占位记录
+	instance.Model = reserved.Model
 	instance.UserID = payload.UserID
+	instance.SectionID = payload.SectionID
 	instance.TemplateID = payload.Template.ID
 
-	err = p.instanceRepository.CreateInstance(instance)
+	err = p.instanceRepository.UpdateInstance(instance)
 	if err != nil {
-		logrus.Warnf("instanceRepository.CreateInstance failed: %v", err)
+		// 没有记录的容器无法被移除，占位记录也会一直占用配额
+		logrus.Warnf("instanceRepository.UpdateInstance failed: %v", err)
+		if removeErr := p.containerManager.RemoveContainer(instance); removeErr != nil {
+			logrus.Warnf("containerManager.RemoveContainer failed: %v", removeErr)
+		}
+		p.releaseInstance(reserved)
 		return err
 	}
 
@@ -83,8 +138,7 @@ func (p *ContainerProcessor) handleContainerCreateTask(ctx context.Context, t *a
 		return err
 	}
 
-	cancel := channelCancel[channelID]
-	cancel()
+	cancelChannel(channelID, false)
 
 	ticker := time.NewTicker(time.Millisecond * 500)
 	defer ticker.Stop()
@@ -97,21 +151,158 @@ func (p *ContainerProcessor) handleContainerCreateTask(ctx context.Context, t *a
 		case <-timeout:
 			return nil
 		case <-ticker.C:
-			ch <- fmt.Sprintf(runningMessage)
+			ch <- runningMessage
 		}
 	}
 
 }
 
+// reserveInstance 返回任务的占位记录，没有占位记录或已被释放时重新占用配额
+func (p *ContainerProcessor) reserveInstance(payload *ContainerCreatePayload) (*model.ContainerInstance, error) {
+	if payload.InstanceID != 0 {
+		instance, err := p.instanceRepository.GetInstanceByID(payload.InstanceID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil && instance.Status == repository.InstanceReserved {
+			return instance, nil
+		}
+	}
+
+	instance := &model.ContainerInstance{UserID: payload.UserID, SectionID: payload.SectionID, TemplateID: payload.Template.ID}
+	if err := p.quotaChecker.Reserve(instance, &payload.Template); err != nil {
+		return nil, err
+	}
+	payload.InstanceID = instance.ID
+	return instance, nil
+}
+
+// releaseInstance 创建容器失败时释放占位记录，重试时重新占用配额
+func (p *ContainerProcessor) releaseInstance(instance *model.ContainerInstance) {
+	instance.Status = "Removed"
+	instance.EndAt = time.Now()
+	if err := p.instanceRepository.UpdateInstanceStatus(instance); err != nil {
+		logrus.Warnf("instanceRepository.UpdateInstanceStatus failed: %v", err)
+	}
+}
+
+// closeCreateChannel 停止发送Pending消息并移除创建容器的状态通道
+func (p *ContainerProcessor) closeCreateChannel(channelID string) {
+	cancelChannel(channelID, true)
+	err := p.messageManager.RemoveChannel(channelID)
+	if err != nil {
+		logrus.Warnf("messageManager.RemoveChannel failed: %v", err)
+	}
+}
+
+func (p *ContainerProcessor) handleContainerRemoveTask(ctx context.Context, t *asynq.Task) error {
+	var payload ContainerRemovePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	instance := payload.Instance
+
+	// 移除前保存工作目录，快照失败不影响移除容器
+	if err := p.saveSnapshot(&instance); err != nil {
+		logrus.Warnf("save workspace snapshot of container %s failed: %v", instance.Name, err)
+	}
+
+	err := p.containerManager.RemoveContainer(&instance)
+	if err != nil {
+		logrus.Warnf("containerManager.RemoveContainer failed: %v", err)
+		return err
+	}
+
+	err = p.instanceRepository.UpdateInstanceStatus(&instance)
+	if err != nil {
+		logrus.Warnf("instanceRepository.UpdateInstanceStatus failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// saveSnapshot 将容器的工作目录保存到对象存储，模板没有设置工作目录时不保存
+func (p *ContainerProcessor) saveSnapshot(instance *model.ContainerInstance) error {
+	template, err := p.templateRepository.GetTemplateByID(instance.TemplateID)
+	if err != nil {
+		return err
+	}
+	if template.WorkspacePath == "" {
+		return nil
+	}
+
+	archive, err := p.containerManager.ArchivePath(instance, template.WorkspacePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	file, err := os.CreateTemp("", "snapshot-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	size, err := io.Copy(file, archive)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	objectName := workspace.ObjectName(instance.UserID, instance.SectionID, instance.ID)
+	if err := p.ossManager.UploadObject(objectName, file.Name()); err != nil {
+		return err
+	}
+	return p.snapshotRepository.CreateSnapshot(&model.WorkspaceSnapshot{
+		UserID:     instance.UserID,
+		SectionID:  instance.SectionID,
+		TemplateID: instance.TemplateID,
+		InstanceID: instance.ID,
+		Path:       template.WorkspacePath,
+		ObjectName: objectName,
+		Size:       size,
+	})
+}
+
+// readSnapshot 从对象存储下载快照并读取其中的文件
+func (p *ContainerProcessor) readSnapshot(snapshot *model.WorkspaceSnapshot, paths []string) (map[string][]byte, error) {
+	file, err := os.CreateTemp("", "snapshot-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := p.ossManager.DownloadObject(snapshot.ObjectName, file.Name()); err != nil {
+		return nil, err
+	}
+	archive, err := os.Open(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return workspace.ReadFiles(archive, snapshot.Path, paths)
+}
+
 func (p *ContainerProcessor) handleContainerExecTask(ctx context.Context, t *asynq.Task) error {
 	var payload ContainerExecPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return err
 	}
 
+	submission, err := p.submissionRepository.GetSubmissionByID(payload.SubmissionID)
+	if err != nil {
+		logrus.Warnf("submissionRepository.GetSubmissionByID failed: %v", err)
+		return err
+	}
+
 	channelID := ContainerExecChannelName(payload.UserID, payload.TemplateID)
 	ch, err := p.messageManager.CreateChannel(channelID)
 	if err != nil {
+		p.finishSubmission(submission, model.SubmissionError, err.Error())
 		return err
 	}
 
@@ -122,17 +313,548 @@ func (p *ContainerProcessor) handleContainerExecTask(ctx context.Context, t *asy
 		}
 	}()
 
-	for order, script := range payload.Scripts {
-		ok, err := p.containerManager.ExecCommand(&payload.Instance, &script)
+	events := newEventStream(ch)
+	if err := p.gradeSubmission(submission, &payload, submission.CreatedAt, events); err != nil {
+		events.abort(err)
+	}
+	events.runFinished(submission)
+
+	return nil
+}
+
+// handleRegradeTask 用当前的检测脚本重新检测学生的容器，容器已被移除时使用最新的工作目录快照，并记录得分变化
+func (p *ContainerProcessor) handleRegradeTask(ctx context.Context, t *asynq.Task) error {
+	var payload RegradePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	previous := payload.Previous
+	result := &model.RegradeResult{
+		JobID:                payload.JobID,
+		UserID:               previous.UserID,
+		PreviousSubmissionID: previous.ID,
+		PreviousStatus:       previous.Status,
+		PreviousScore:        previous.Score,
+	}
+	defer p.recordRegradeResult(result)
+
+	instance, snapshot, cleanup, err := p.regradeTarget(&payload)
+	if err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+	defer cleanup()
+
+	instanceID := instance.ID
+	if snapshot != nil {
+		instanceID = snapshot.InstanceID
+		result.FromSnapshot = true
+	}
+	submission := &model.Submission{
+		UserID:       previous.UserID,
+		SectionID:    previous.SectionID,
+		TemplateID:   previous.TemplateID,
+		InstanceID:   instanceID,
+		Attempt:      previous.Attempt,
+		Status:       model.SubmissionPending,
+		Trigger:      model.TriggerRegrade,
+		RegradeOf:    previous.ID,
+		SuiteVersion: suite.VersionOf(payload.Scripts),
+	}
+	if err := p.submissionRepository.CreateSubmission(submission); err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+
+	exec := ContainerExecPayload{
+		Instance:     *instance,
+		Template:     payload.Template,
+		Scripts:      payload.Scripts,
+		UserID:       previous.UserID,
+		TemplateID:   previous.TemplateID,
+		SubmissionID: submission.ID,
+	}
+	// 迟交扣分按原提交的时间计算
+	if err := p.gradeSubmission(submission, &exec, previous.CreatedAt, nil); err != nil {
+		result.Error = err.Error()
+	}
+
+	result.SubmissionID = submission.ID
+	result.Status = submission.Status
+	if submission.Status != model.SubmissionError {
+		result.Score = submission.Score
+		result.Delta = submission.Score - previous.Score
+	}
+	return nil
+}
+
+// regradeTarget 获取重新检测的容器。学生的容器已被移除时，用模板创建临时容器并恢复最新的工作目录快照，
+// 此时返回使用的快照，检测结束后调用 cleanup 移除临时容器
+func (p *ContainerProcessor) regradeTarget(payload *RegradePayload) (*model.ContainerInstance, *model.WorkspaceSnapshot, func(), error) {
+	previous := &payload.Previous
+	instance, err := p.instanceRepository.GetInstanceByUserIDAndTemplateID(previous.UserID, previous.TemplateID)
+	if err == nil {
+		return instance, nil, func() {}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil, err
+	}
+
+	snapshot, err := p.snapshotRepository.GetLatestSnapshot(previous.UserID, previous.SectionID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("no container or workspace snapshot to regrade: %v", err)
+	}
+
+	// 临时容器与学生的容器一样注入实验参数
+	template := payload.Template
+	if section, err := p.sectionRepository.GetSectionByID(previous.SectionID); err == nil {
+		values, err := params.ForSection(section, previous.UserID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		template.Envs = params.AppendEnvs(template.Envs, values)
+	}
+
+	instance, err = p.containerManager.CreateContainer(&template)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	instance.UserID = previous.UserID
+	instance.SectionID = previous.SectionID
+	cleanup := func() {
+		if err := p.containerManager.RemoveContainer(instance); err != nil {
+			logrus.Warnf("containerManager.RemoveContainer failed: %v", err)
+		}
+	}
+
+	if err := p.containerManager.StartContainer(instance); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	if err := p.restoreSnapshot(instance, snapshot); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("restore workspace snapshot %d failed: %v", snapshot.ID, err)
+	}
+	return instance, snapshot, cleanup, nil
+}
+
+// restoreSnapshot 从对象存储下载快照并写回容器的工作目录
+func (p *ContainerProcessor) restoreSnapshot(instance *model.ContainerInstance, snapshot *model.WorkspaceSnapshot) error {
+	file, err := os.CreateTemp("", "snapshot-*.tar")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := p.ossManager.DownloadObject(snapshot.ObjectName, file.Name()); err != nil {
+		return err
+	}
+	archive, err := os.Open(file.Name())
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return p.containerManager.RestorePath(instance, snapshot.Path, archive)
+}
+
+// recordRegradeResult 保存单个学生的重新评分结果并更新任务进度
+func (p *ContainerProcessor) recordRegradeResult(result *model.RegradeResult) {
+	if err := p.regradeRepository.RecordResult(result); err != nil {
+		logrus.Warnf("regradeRepository.RecordResult failed: %v", err)
+	}
+}
+
+// handleSimilarityTask 收集小节每个学生容器或工作目录快照中的答案文件，两两计算相似度并保存查重报告
+func (p *ContainerProcessor) handleSimilarityTask(ctx context.Context, t *asynq.Task) error {
+	var payload SimilarityPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	report, err := p.similarityRepository.GetReportByID(payload.ReportID)
+	if err != nil {
+		logrus.Warnf("similarityRepository.GetReportByID failed: %v", err)
+		return err
+	}
+
+	report.Status = model.SimilarityRunning
+	if err := p.similarityRepository.UpdateReport(report); err != nil {
+		logrus.Warnf("similarityRepository.UpdateReport failed: %v", err)
+	}
+
+	report.Status = model.SimilarityFinished
+	if err := p.analyzeSimilarity(report); err != nil {
+		report.Status = model.SimilarityFailed
+		report.Error = err.Error()
+	}
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+	if err := p.similarityRepository.UpdateReport(report); err != nil {
+		logrus.Warnf("similarityRepository.UpdateReport failed: %v", err)
+	}
+	return nil
+}
+
+// analyzeSimilarity 比较学生的答案文件，保存相似度较高的学生对
+func (p *ContainerProcessor) analyzeSimilarity(report *model.SimilarityReport) error {
+	section, err := p.sectionRepository.GetSectionByID(report.SectionID)
+	if err != nil {
+		return err
+	}
+	paths := section.SolutionPaths()
+	if len(paths) == 0 {
+		return errors.New("section has no solution files")
+	}
+
+	instances, err := p.instanceRepository.GetLatestInstancesByTemplateID(section.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	users := make(map[uint]bool)
+	sources := make(map[uint]string)
+	for i := range instances {
+		users[instances[i].UserID] = true
+		source, err := p.collectSolution(&instances[i], paths)
+		if err != nil {
+			logrus.Infof("read solution of user %d from container failed, use the snapshot instead: %v", instances[i].UserID, err)
+			continue
+		}
+		sources[instances[i].UserID] = source
+	}
+
+	// 容器已经移除或无法读取时使用最新的工作目录快照
+	snapshots, err := p.snapshotRepository.GetLatestSnapshotsBySectionID(section.ID)
+	if err != nil {
+		return err
+	}
+	for i := range snapshots {
+		userID := snapshots[i].UserID
+		users[userID] = true
+		if _, ok := sources[userID]; ok {
+			continue
+		}
+		contents, err := p.readSnapshot(&snapshots[i], paths)
+		if err != nil || len(contents) == 0 {
+			logrus.Infof("no solution files in snapshot %d of user %d: %v", snapshots[i].ID, userID, err)
+			continue
+		}
+		sources[userID] = joinSolution(paths, contents)
+	}
+
+	documents := make([]similarity.Document, 0, len(sources))
+	for userID, source := range sources {
+		documents = append(documents, similarity.Document{ID: userID, Source: source})
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID < documents[j].ID
+	})
+	report.Students = uint(len(documents))
+	report.Skipped = uint(len(users) - len(documents))
+
+	// 镜像中的初始代码不计入相似度
+	options := similarity.DefaultOptions()
+	starter, err := p.collectStarter(section.TemplateID, paths)
+	if err != nil {
+		logrus.Infof("no starter code for similarity report %d: %v", report.ID, err)
+	} else {
+		options.Boilerplate = []string{starter}
+	}
+
+	pairs := similarity.Analyze(documents, options)
+	records := make([]model.SimilarityPair, 0, len(pairs))
+	for _, pair := range pairs {
+		record := model.SimilarityPair{
+			ReportID: report.ID,
+			UserA:    pair.A,
+			UserB:    pair.B,
+			Score:    pair.Score,
+			Shared:   uint(pair.Shared),
+			SourceA:  sources[pair.A],
+			SourceB:  sources[pair.B],
+			Diff:     grader.Diff(sources[pair.A], sources[pair.B]),
+		}
+		for _, match := range pair.Matches {
+			record.Matches = append(record.Matches, model.SimilarityMatch{
+				StartA: uint(match.StartA),
+				EndA:   uint(match.EndA),
+				StartB: uint(match.StartB),
+				EndB:   uint(match.EndB),
+			})
+		}
+		records = append(records, record)
+	}
+	return p.similarityRepository.CreatePairs(records)
+}
+
+// collectSolution 读取容器中的答案文件并按路径拼接
+func (p *ContainerProcessor) collectSolution(instance *model.ContainerInstance, paths []string) (string, error) {
+	contents := make(map[string][]byte)
+	for _, path := range paths {
+		data, err := p.containerManager.ReadFile(instance, path)
 		if err != nil {
-			logrus.Warnf("containerManager.ExecCommand failed: %d,%v", order, err)
+			logrus.Debugf("read %s from container %s failed: %v", path, instance.Name, err)
+			continue
 		}
+		contents[path] = data
+	}
+	if len(contents) == 0 {
+		return "", fmt.Errorf("no solution files found in container %s", instance.Name)
+	}
+	return joinSolution(paths, contents), nil
+}
+
+// joinSolution 按路径顺序拼接答案文件，每个文件前加一行注释标明路径
+func joinSolution(paths []string, contents map[string][]byte) string {
+	var source strings.Builder
+	for _, path := range paths {
+		data, ok := contents[path]
 		if !ok {
-			ch <- fmt.Sprintf(failMessage)
-		} else {
-			ch <- fmt.Sprintf(passMessage)
+			continue
+		}
+		fmt.Fprintf(&source, "// === %s ===\n", path)
+		source.Write(data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			source.WriteByte('\n')
+		}
+	}
+	return source.String()
+}
+
+// collectStarter 用模板创建一个不启动的容器，读取镜像中的答案文件作为初始代码
+func (p *ContainerProcessor) collectStarter(templateID uint, paths []string) (string, error) {
+	template, err := p.templateRepository.GetTemplateByID(templateID)
+	if err != nil {
+		return "", err
+	}
+	instance, err := p.containerManager.CreateContainer(template)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := p.containerManager.RemoveContainer(instance); err != nil {
+			logrus.Warnf("containerManager.RemoveContainer failed: %v", err)
 		}
+	}()
+	return p.collectSolution(instance, paths)
+}
+
+// gradeSubmission 执行检测脚本并记录提交的最终结果，检测无法完成时返回原因。
+// submittedAt 用于计算迟交扣分，检测过程的事件写入 events，每个检测点的结果在保存后推送
+func (p *ContainerProcessor) gradeSubmission(submission *model.Submission, payload *ContainerExecPayload, submittedAt time.Time, events *eventStream) error {
+	startedAt := time.Now()
+	submission.Status = model.SubmissionRunning
+	submission.StartedAt = &startedAt
+	if err := p.submissionRepository.UpdateSubmission(submission); err != nil {
+		logrus.Warnf("submissionRepository.UpdateSubmission failed: %v", err)
+	}
+
+	scripts := p.sectionScripts(submission, payload.Scripts)
+	events.runStarted(submission, scripts, payload.Template.CheckConcurrency)
+
+	target, cleanup, err := p.gradingTarget(payload)
+	if err != nil {
+		logrus.Warnf("create grader container failed: %v", err)
+		p.finishSubmission(submission, model.SubmissionError, err.Error())
+		return err
+	}
+	defer cleanup()
+
+	results, err := p.runner.RunConcurrent(target, scripts, payload.Template.CheckConcurrency, grader.Hooks{
+		OnStart: events.checkStarted,
+		OnResult: func(index int, result *model.CheckResult) {
+			result.SubmissionID = submission.ID
+			if err := p.submissionRepository.CreateCheckResult(result); err != nil {
+				logrus.Warnf("submissionRepository.CreateCheckResult failed: %d,%v", result.Order, err)
+			}
+
+			events.checkFinished(index, result)
+		},
+	})
+	if err != nil {
+		p.abortSubmission(submission, scripts, err)
+		return err
+	}
+
+	summary := grader.Summarize(results)
+	submission.Passed = summary.Passed
+	submission.Total = summary.Total
+	submission.RawScore = summary.Score
+	submission.MaxScore = summary.MaxScore
+	grader.ApplyLatePenalty(submission, p.sectionDeadline(submission.SectionID), submittedAt)
+	reason := ""
+	if summary.Total == 0 {
+		reason = grader.ErrNoChecks.Error()
+	}
+	p.finishSubmission(submission, summary.Status, reason)
+
+	if summary.Status != model.SubmissionError {
+		p.recordSectionResult(submission)
 	}
 
 	return nil
 }
+
+// gradingTarget 获取执行检测脚本的容器，isolated 模式下创建临时的检测容器，检测结束后调用 cleanup 移除
+func (p *ContainerProcessor) gradingTarget(payload *ContainerExecPayload) (*model.ContainerInstance, func(), error) {
+	if payload.Template.GradeMode != model.GradeIsolated {
+		return &payload.Instance, func() {}, nil
+	}
+
+	grader, err := p.containerManager.CreateGraderContainer(&payload.Instance, &payload.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if err := p.containerManager.RemoveContainer(grader); err != nil {
+			logrus.Warnf("containerManager.RemoveContainer grader failed: %v", err)
+		}
+	}
+	return grader, cleanup, nil
+}
+
+// sectionScripts 为检测脚本注入用户的实验参数，获取失败时使用原来的脚本
+func (p *ContainerProcessor) sectionScripts(submission *model.Submission, scripts []model.ContainerScript) []model.ContainerScript {
+	section, err := p.sectionRepository.GetSectionByID(submission.SectionID)
+	if err != nil {
+		logrus.Warnf("sectionRepository.GetSectionByID failed: %v", err)
+		return scripts
+	}
+	values, err := params.ForSection(section, submission.UserID)
+	if err != nil {
+		logrus.Warnf("params.ForSection failed: %v", err)
+		return scripts
+	}
+	return params.Scripts(scripts, values)
+}
+
+// sectionDeadline 获取小节实际生效的截止时间，获取失败时不扣分
+func (p *ContainerProcessor) sectionDeadline(sectionID uint) *model.Deadline {
+	section, err := p.sectionRepository.GetSectionByID(sectionID)
+	if err != nil {
+		logrus.Warnf("sectionRepository.GetSectionByID failed: %v", err)
+		return nil
+	}
+	chapter, err := p.courseRepository.GetChapterByID(section.ChapterID)
+	if err != nil {
+		logrus.Warnf("courseRepository.GetChapterByID failed: %v", err)
+		return nil
+	}
+	return model.EffectiveDeadline(section, chapter)
+}
+
+// abortSubmission 准备脚本失败时终止提交，学生代码导致的失败按 0 分计入成绩
+func (p *ContainerProcessor) abortSubmission(submission *model.Submission, scripts []model.ContainerScript, err error) {
+	_, checks, _ := grader.SplitPhases(scripts)
+	for _, check := range checks {
+		submission.MaxScore += check.Points
+	}
+
+	status := model.SubmissionFailed
+	var setupErr *grader.SetupError
+	if !errors.As(err, &setupErr) || setupErr.Infrastructure() {
+		status = model.SubmissionError
+	}
+	p.finishSubmission(submission, status, err.Error())
+
+	if status == model.SubmissionFailed {
+		p.recordSectionResult(submission)
+	}
+}
+
+// recordSectionResult 根据检测结果更新用户的小节完成状态
+func (p *ContainerProcessor) recordSectionResult(submission *model.Submission) {
+	if submission.Trigger == model.TriggerRegrade {
+		p.recordRegradedResult(submission)
+		return
+	}
+	completed := submission.Status == model.SubmissionPassed
+	_, err := p.sectionRepository.RecordSectionResult(submission.UserID, submission.SectionID, submission.Score, submission.MaxScore, completed)
+	if err != nil {
+		logrus.Warnf("sectionRepository.RecordSectionResult failed: %v", err)
+		return
+	}
+}
+
+// recordRegradedResult 重新评分后按所有提交重新计算小节成绩，重新评分可以降低成绩或把小节变回未完成
+func (p *ContainerProcessor) recordRegradedResult(submission *model.Submission) {
+	submissions, err := p.submissionRepository.GetSubmissionsByUserID(submission.UserID, submission.SectionID)
+	if err != nil {
+		logrus.Warnf("submissionRepository.GetSubmissionsByUserID failed: %v", err)
+		return
+	}
+	score, maxScore, completed := regradedResult(submissions, submission)
+	if _, err := p.sectionRepository.SetSectionResult(submission.UserID, submission.SectionID, score, maxScore, completed); err != nil {
+		logrus.Warnf("sectionRepository.SetSectionResult failed: %v", err)
+	}
+}
+
+// regradedResult 计算小节成绩：被重新评分的提交以最新一次重新评分的结果为准，取得分百分比最高的一次，
+// 任意一次通过即视为完成。regraded 是刚完成的重新评分，列表中没有时也会计入
+func regradedResult(submissions []model.Submission, regraded *model.Submission) (score, maxScore float64, completed bool) {
+	latest := map[uint]model.Submission{regraded.RegradeOf: *regraded}
+	for _, s := range submissions {
+		if s.Trigger != model.TriggerRegrade || s.Status == model.SubmissionError || s.RegradeOf == regraded.RegradeOf {
+			continue
+		}
+		if current, ok := latest[s.RegradeOf]; !ok || s.ID > current.ID {
+			latest[s.RegradeOf] = s
+		}
+	}
+
+	best := -1.0
+	consider := func(s model.Submission) {
+		if s.Status != model.SubmissionPassed && s.Status != model.SubmissionFailed {
+			return
+		}
+		if s.Percentage > best {
+			best = s.Percentage
+			score = s.Score
+			maxScore = s.MaxScore
+		}
+		if s.Status == model.SubmissionPassed {
+			completed = true
+		}
+	}
+	for _, s := range submissions {
+		if s.Trigger == model.TriggerRegrade {
+			continue
+		}
+		if replaced, ok := latest[s.ID]; ok {
+			s = replaced
+		}
+		consider(s)
+	}
+	// 原提交不在列表中时单独计入
+	if !containsSubmission(submissions, regraded.RegradeOf) {
+		consider(*regraded)
+	}
+	return score, maxScore, completed
+}
+
+func containsSubmission(submissions []model.Submission, id uint) bool {
+	for _, s := range submissions {
+		if s.ID == id && s.Trigger != model.TriggerRegrade {
+			return true
+		}
+	}
+	return false
+}
+
+// finishSubmission 记录提交的最终状态和耗时
+func (p *ContainerProcessor) finishSubmission(submission *model.Submission, status, reason string) {
+	finishedAt := time.Now()
+	submission.Status = status
+	submission.Error = reason
+	submission.FinishedAt = &finishedAt
+	if submission.StartedAt != nil {
+		submission.Duration = finishedAt.Sub(*submission.StartedAt).Milliseconds()
+	}
+
+	if err := p.submissionRepository.UpdateSubmission(submission); err != nil {
+		logrus.Warnf("submissionRepository.UpdateSubmission failed: %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package task
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/container"
+	"awesomeProject/pkg/oss"
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"testing"
+)
+
+// 重新评分测试使用的内存仓库和容器管理器，只实现重新评分用到的方法，并记录写入的数据
+
+type fakeInstanceRepo struct {
+	repository.InstanceRepository
+	instances map[uint]*model.ContainerInstance
+}
+
+func (r *fakeInstanceRepo) GetInstanceByUserIDAndTemplateID(userID, templateID uint) (*model.ContainerInstance, error) {
+	instance, ok := r.instances[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return instance, nil
+}
+
+type fakeSnapshotRepo struct {
+	repository.SnapshotRepository
+	snapshots map[uint]*model.WorkspaceSnapshot
+}
+
+func (r *fakeSnapshotRepo) GetLatestSnapshot(userID, sectionID uint) (*model.WorkspaceSnapshot, error) {
+	snapshot, ok := r.snapshots[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return snapshot, nil
+}
+
+// fakeSectionRepo 按用户记录写入的小节成绩
+type fakeSectionRepo struct {
+	repository.SectionRepository
+	statuses map[uint]*model.UserSectionStatus
+}
+
+func (r *fakeSectionRepo) GetSectionByID(id uint) (*model.Section, error) {
+	return &model.Section{Model: gorm.Model{ID: id}, ChapterID: 1}, nil
+}
+
+func (r *fakeSectionRepo) RecordSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error) {
+	status, ok := r.statuses[userID]
+	if ok && (status.Completed || status.BestScore >= score) {
+		return status, nil
+	}
+	return r.SetSectionResult(userID, sectionID, score, maxScore, completed)
+}
+
+func (r *fakeSectionRepo) SetSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error) {
+	status := &model.UserSectionStatus{UserID: userID, SectionID: sectionID, BestScore: score, MaxScore: maxScore, Completed: completed}
+	r.statuses[userID] = status
+	return status, nil
+}
+
+type fakeCourseRepo struct {
+	repository.CourseRepository
+}
+
+func (r *fakeCourseRepo) GetChapterByID(id uint) (*model.Chapter, error) {
+	return &model.Chapter{Model: gorm.Model{ID: id}}, nil
+}
+
+type fakeSubmissionRepo struct {
+	repository.SubmissionRepository
+	submissions []*model.Submission
+	results     []*model.CheckResult
+}
+
+func (r *fakeSubmissionRepo) CreateSubmission(submission *model.Submission) error {
+	var id uint
+	for _, s := range r.submissions {
+		id = max(id, s.ID)
+	}
+	submission.ID = id + 1
+	saved := *submission
+	r.submissions = append(r.submissions, &saved)
+	return nil
+}
+
+func (r *fakeSubmissionRepo) UpdateSubmission(submission *model.Submission) error {
+	for i, s := range r.submissions {
+		if s.ID == submission.ID {
+			saved := *submission
+			r.submissions[i] = &saved
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeSubmissionRepo) GetSubmissionsByUserID(userID, sectionID uint) ([]model.Submission, error) {
+	var submissions []model.Submission
+	for _, s := range r.submissions {
+		if s.UserID == userID && s.SectionID == sectionID {
+			submissions = append(submissions, *s)
+		}
+	}
+	return submissions, nil
+}
+
+// regraded 返回重新评分创建的提交
+func (r *fakeSubmissionRepo) regraded() []*model.Submission {
+	var submissions []*model.Submission
+	for _, s := range r.submissions {
+		if s.Trigger == model.TriggerRegrade {
+			submissions = append(submissions, s)
+		}
+	}
+	return submissions
+}
+
+func (r *fakeSubmissionRepo) CreateCheckResult(result *model.CheckResult) error {
+	r.results = append(r.results, result)
+	return nil
+}
+
+type fakeRegradeRepo struct {
+	repository.RegradeRepository
+	results []*model.RegradeResult
+}
+
+func (r *fakeRegradeRepo) RecordResult(result *model.RegradeResult) error {
+	r.results = append(r.results, result)
+	return nil
+}
+
+type fakeOSS struct {
+	oss.Manager
+	objects map[string]string
+}
+
+func (m *fakeOSS) DownloadObject(objectName, filePath string) error {
+	return os.WriteFile(filePath, []byte(m.objects[objectName]), 0o644)
+}
+
+// fakeManager 记录创建、恢复和移除的容器，检测脚本在名为 passing 的容器中通过
+type fakeManager struct {
+	container.Manager
+	created  []string
+	removed  []string
+	restored map[string]string
+	executed []string
+}
+
+func (m *fakeManager) CreateContainer(template *model.ContainerTemplate) (*model.ContainerInstance, error) {
+	m.created = append(m.created, template.Name)
+	return &model.ContainerInstance{ContainerID: "temporary", Name: template.Name}, nil
+}
+
+func (m *fakeManager) StartContainer(instance *model.ContainerInstance) error {
+	instance.Status = "Running"
+	return nil
+}
+
+func (m *fakeManager) RemoveContainer(instance *model.ContainerInstance) error {
+	m.removed = append(m.removed, instance.ContainerID)
+	return nil
+}
+
+func (m *fakeManager) RestorePath(instance *model.ContainerInstance, path string, archive io.Reader) error {
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		return err
+	}
+	m.restored[instance.ContainerID+":"+path] = string(data)
+	instance.ContainerID = "passing"
+	return nil
+}
+
+func (m *fakeManager) ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (*container.ExecResult, error) {
+	m.executed = append(m.executed, instance.ContainerID)
+	if instance.ContainerID != "passing" {
+		return &container.ExecResult{ExitCode: 1}, nil
+	}
+	return &container.ExecResult{}, nil
+}
+
+// regradeFixture 重新评分测试的处理器和记录数据的依赖
+type regradeFixture struct {
+	processor      *ContainerProcessor
+	manager        *fakeManager
+	submissionRepo *fakeSubmissionRepo
+	sectionRepo    *fakeSectionRepo
+	regradeRepo    *fakeRegradeRepo
+}
+
+// newRegradeFixture 用户 1 的容器仍在运行但检测不通过，用户 2 的容器已被移除只有快照，用户 3 两者都没有
+func newRegradeFixture(submissions ...*model.Submission) *regradeFixture {
+	f := &regradeFixture{
+		manager:        &fakeManager{restored: make(map[string]string)},
+		submissionRepo: &fakeSubmissionRepo{submissions: submissions},
+		sectionRepo:    &fakeSectionRepo{statuses: make(map[uint]*model.UserSectionStatus)},
+		regradeRepo:    &fakeRegradeRepo{},
+	}
+	f.processor = &ContainerProcessor{
+		containerManager: f.manager,
+		instanceRepository: &fakeInstanceRepo{instances: map[uint]*model.ContainerInstance{
+			1: {Model: gorm.Model{ID: 11}, UserID: 1, ContainerID: "failing"},
+		}},
+		snapshotRepository: &fakeSnapshotRepo{snapshots: map[uint]*model.WorkspaceSnapshot{
+			2: {Model: gorm.Model{ID: 5}, UserID: 2, InstanceID: 22, Path: "/home/student/work", ObjectName: "snapshots/2/1/22.tar"},
+		}},
+		ossManager:           &fakeOSS{objects: map[string]string{"snapshots/2/1/22.tar": "archive"}},
+		sectionRepository:    f.sectionRepo,
+		courseRepository:     &fakeCourseRepo{},
+		submissionRepository: f.submissionRepo,
+		regradeRepository:    f.regradeRepo,
+		runner:               grader.NewRunner(f.manager),
+	}
+	return f
+}
+
+// studentSubmission 学生在小节 1 的一次提交
+func studentSubmission(id, userID uint, status string, score float64) *model.Submission {
+	return &model.Submission{
+		Model:      gorm.Model{ID: id},
+		UserID:     userID,
+		SectionID:  1,
+		TemplateID: 1,
+		Status:     status,
+		Trigger:    model.TriggerStudent,
+		Score:      score,
+		MaxScore:   10,
+		Percentage: score * 10,
+	}
+}
+
+func regradeTask(t *testing.T, previous *model.Submission) *asynq.Task {
+	data, err := json.Marshal(RegradePayload{
+		JobID:    1,
+		Previous: *previous,
+		Template: model.ContainerTemplate{Model: gorm.Model{ID: 1}, Name: "lab"},
+		Scripts:  []model.ContainerScript{{Order: 1, Name: "check", Content: "check", Points: 10}},
+	})
+	assert.NoError(t, err)
+	return asynq.NewTask(TypeRegrade, data)
+}
+
+func TestRegradeLiveContainer(t *testing.T) {
+	// 原来误判通过的提交重新评分后不通过，小节成绩回落到其他提交中最好的一次
+	previous := studentSubmission(101, 1, model.SubmissionPassed, 10)
+	f := newRegradeFixture(studentSubmission(100, 1, model.SubmissionFailed, 4), previous)
+
+	assert.NoError(t, f.processor.handleRegradeTask(context.Background(), regradeTask(t, previous)))
+	assert.Empty(t, f.manager.created)
+	assert.Equal(t, []string{"failing"}, f.manager.executed)
+
+	if assert.Len(t, f.regradeRepo.results, 1) {
+		result := f.regradeRepo.results[0]
+		assert.Empty(t, result.Error)
+		assert.False(t, result.FromSnapshot)
+		assert.Equal(t, model.SubmissionFailed, result.Status)
+		assert.Equal(t, -10.0, result.Delta)
+	}
+	regraded := f.submissionRepo.regraded()
+	if assert.Len(t, regraded, 1) {
+		assert.Equal(t, uint(11), regraded[0].InstanceID)
+		assert.Equal(t, uint(101), regraded[0].RegradeOf)
+		assert.Equal(t, model.SubmissionFailed, regraded[0].Status)
+	}
+	assert.Len(t, f.submissionRepo.results, 1)
+
+	status := f.sectionRepo.statuses[1]
+	if assert.NotNil(t, status) {
+		assert.Equal(t, 4.0, status.BestScore)
+		assert.Equal(t, 10.0, status.MaxScore)
+		assert.False(t, status.Completed)
+	}
+}
+
+func TestRegradeSnapshot(t *testing.T) {
+	previous := studentSubmission(102, 2, model.SubmissionFailed, 0)
+	f := newRegradeFixture(previous)
+
+	// 容器已被移除，在恢复了快照的临时容器中检测，结束后移除临时容器
+	assert.NoError(t, f.processor.handleRegradeTask(context.Background(), regradeTask(t, previous)))
+	assert.Equal(t, []string{"lab"}, f.manager.created)
+	assert.Equal(t, map[string]string{"temporary:/home/student/work": "archive"}, f.manager.restored)
+	assert.Equal(t, []string{"passing"}, f.manager.executed)
+	assert.Equal(t, []string{"passing"}, f.manager.removed)
+
+	if assert.Len(t, f.regradeRepo.results, 1) {
+		result := f.regradeRepo.results[0]
+		assert.Empty(t, result.Error)
+		assert.True(t, result.FromSnapshot)
+		assert.Equal(t, model.SubmissionPassed, result.Status)
+		assert.Equal(t, 10.0, result.Score)
+		assert.Equal(t, 10.0, result.Delta)
+	}
+	regraded := f.submissionRepo.regraded()
+	if assert.Len(t, regraded, 1) {
+		assert.Equal(t, uint(22), regraded[0].InstanceID)
+		assert.Equal(t, model.TriggerRegrade, regraded[0].Trigger)
+	}
+
+	status := f.sectionRepo.statuses[2]
+	if assert.NotNil(t, status) {
+		assert.Equal(t, 10.0, status.BestScore)
+		assert.True(t, status.Completed)
+	}
+}
+
+func TestRegradeNothingToGrade(t *testing.T) {
+	previous := studentSubmission(103, 3, model.SubmissionPassed, 10)
+	f := newRegradeFixture(previous)
+
+	assert.NoError(t, f.processor.handleRegradeTask(context.Background(), regradeTask(t, previous)))
+	assert.Empty(t, f.manager.created)
+	assert.Empty(t, f.submissionRepo.regraded())
+	assert.Empty(t, f.sectionRepo.statuses)
+	if assert.Len(t, f.regradeRepo.results, 1) {
+		assert.Contains(t, f.regradeRepo.results[0].Error, "no container or workspace snapshot")
+		assert.Zero(t, f.regradeRepo.results[0].SubmissionID)
+	}
+}
+
+func TestRegradedResult(t *testing.T) {
+	first := studentSubmission(1, 1, model.SubmissionPassed, 10)
+	second := studentSubmission(2, 1, model.SubmissionFailed, 6)
+
+	// 之前的重新评分已经把第一次提交降为 2 分，这次重新评分第二次提交降为 3 分
+	earlier := studentSubmission(3, 1, model.SubmissionFailed, 2)
+	earlier.Trigger, earlier.RegradeOf = model.TriggerRegrade, 1
+	regraded := studentSubmission(4, 1, model.SubmissionFailed, 3)
+	regraded.Trigger, regraded.RegradeOf = model.TriggerRegrade, 2
+
+	submissions := []model.Submission{*first, *second, *earlier, *regraded}
+	score, maxScore, completed := regradedResult(submissions, regraded)
+	assert.Equal(t, 3.0, score)
+	assert.Equal(t, 10.0, maxScore)
+	assert.False(t, completed)
+
+	// 重新评分后通过
+	regraded.Status, regraded.Score, regraded.Percentage = model.SubmissionPassed, 10, 100
+	score, _, completed = regradedResult(submissions[:3], regraded)
+	assert.Equal(t, 10.0, score)
+	assert.True(t, completed)
+}
@@ -1,9 +1,12 @@
 package task
 
 import (
+	"awesomeProject/internal/quota"
 	"awesomeProject/pkg/configs"
+	"errors"
 	"github.com/hibiken/asynq"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 func InitTaskServer() {
@@ -24,6 +27,13 @@ func InitTaskServer() {
 				"default":  3,
 				"low":      1,
 			},
+			// 主机资源不足的排队任务按配置的间隔重试
+			RetryDelayFunc: func(n int, e error, t *asynq.Task) time.Duration {
+				if errors.Is(e, quota.ErrHostFull) {
+					return quota.NewChecker().Limits().QueueRetryInterval
+				}
+				return asynq.DefaultRetryDelayFunc(n, e, t)
+			},
 			// See the godoc for other configuration options
 		},
 	)
@@ -1,10 +1,15 @@
 package task
 
-import "fmt"
+import (
+	"fmt"
+)
 
 const (
 	TypeContainerCreate = "container:create"
 	TypeContainerExec   = "container:exec"
+	TypeContainerRemove = "container:remove"
+	TypeRegrade         = "submission:regrade"
+	TypeSimilarity      = "section:similarity"
 )
 
 var (
@@ -19,8 +24,7 @@ var (
 var (
 	runningMessage string
 	pendingMessage string
-	passMessage    string
-	failMessage    string
+	queuedMessage  string
 )
 
 func init() {
@@ -30,7 +34,6 @@ func init() {
 	//}
 	runningMessage = `{"status": "Running"}`
 	pendingMessage = `{"status": "Pending"}`
-	passMessage = `{"status": "Pass"}`
-	failMessage = `{"status": "Fail"}`
+	queuedMessage = `{"status": "Queued"}`
 
 }
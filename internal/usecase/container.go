@@ -1,13 +1,24 @@
 package usecase
 
 import (
+	"awesomeProject/internal/grader"
 	"awesomeProject/internal/model"
+	"awesomeProject/internal/params"
+	"awesomeProject/internal/quota"
 	"awesomeProject/internal/repository"
+	"awesomeProject/internal/suite"
 	"awesomeProject/internal/task"
+	"awesomeProject/pkg/container"
 	"awesomeProject/pkg/db"
 	"awesomeProject/pkg/message"
+	"awesomeProject/pkg/secret"
+	"context"
 	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"sync"
+	"time"
 )
 
 const (
@@ -21,19 +32,48 @@ var (
 	_                        ContainerService = (*ContainerServiceImpl)(nil)
 )
 
+var (
+	// ErrNoSudoPassword 容器没有SUDO密码
+	ErrNoSudoPassword = errors.New("container has no sudo password")
+	// ErrAmbiguousSection 模板被多个小节使用，需要指定小节
+	ErrAmbiguousSection = errors.New("template is used by several sections, section_id is required")
+	// ErrSectionTemplate 指定的小节不使用该模板
+	ErrSectionTemplate = errors.New("section does not use the template")
+)
+
 type ContainerService interface {
-	CreateContainer(userID, templateID uint) error
+	CreateContainer(userID, templateID, sectionID uint) error
 	GetContainer(userID, templateID uint) (*model.ContainerInstance, error)
 	GetChannel(userID, templateID uint, typ int) (chan string, error)
-	CheckContainer(userID, templateID uint) error
+	CheckContainer(userID, templateID uint) (*quota.AttemptStatus, error)
+	GetCheckAttempts(userID, templateID uint) (*quota.AttemptStatus, error)
+	GetContainerSudoPassword(userID, templateID uint) (string, error)
+	RemoveContainer(userID, templateID uint) error
+	GetContainerUsage() (*ContainerUsage, error)
+	GetContainerLogs(ctx context.Context, userID, templateID uint, options container.LogOptions) (<-chan container.LogLine, error)
+	GetInstanceLogs(ctx context.Context, instanceID uint, options container.LogOptions) (<-chan container.LogLine, error)
+}
+
+// ContainerUsage 容器资源占用情况，供管理员查看
+type ContainerUsage struct {
+	Limits quota.Limits               `json:"limits"`
+	Total  *repository.InstanceUsage  `json:"total"`
+	Users  []repository.InstanceUsage `json:"users"`
 }
 
 type ContainerServiceImpl struct {
 	instanceRepo   repository.InstanceRepository
 	templateRepo   repository.TemplateRepository
 	scriptRepo     repository.ContainerScript
+	profileRepo    repository.SecurityProfileRepository
+	sectionRepo    repository.SectionRepository
+	courseRepo     repository.CourseRepository
+	submissionRepo repository.SubmissionRepository
 	taskClient     *task.Client
 	messageManager message.Manager
+	secretManager  secret.Manager
+	quotaChecker   quota.Checker
+	containerMgr   container.Manager
 }
 
 func NewContainerService() ContainerService {
@@ -42,15 +82,23 @@ func NewContainerService() ContainerService {
 			instanceRepo:   repository.NewInstanceRepository(db.DB),
 			templateRepo:   repository.NewTemplateRepository(db.DB),
 			scriptRepo:     repository.NewContainerScript(db.DB),
+			profileRepo:    repository.NewSecurityProfileRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			courseRepo:     repository.NewCourseRepository(db.DB),
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
 			taskClient:     task.GetTaskClient(),
 			messageManager: message.NewChannelManager(),
+			secretManager:  secret.NewManager(),
+			quotaChecker:   quota.NewChecker(),
+			containerMgr:   container.NewManager(),
 		}
 	})
 
 	return containerServiceInstance
 }
 
-func (s *ContainerServiceImpl) CreateContainer(userID, templateID uint) error {
+// CreateContainer 为用户创建模板的容器，sectionID 为 0 时使用模板所属的唯一小节，模板被多个小节使用时必须指定
+func (s *ContainerServiceImpl) CreateContainer(userID, templateID, sectionID uint) error {
 
 	// 获取模板信息
 	template, err := s.templateRepo.GetTemplateByID(templateID)
@@ -58,17 +106,127 @@ func (s *ContainerServiceImpl) CreateContainer(userID, templateID uint) error {
 		return err
 	}
 
+	// 解析模板的安全配置
+	template.Profile, err = resolveSecurityProfile(s.profileRepo, template)
+	if err != nil {
+		return err
+	}
+
 	// 创建异步任务
 	payload := task.ContainerCreatePayload{
 		UserID:   userID,
 		Template: *template,
 	}
 
-	return s.taskClient.EnqueueContainerCreateTask(payload)
+	// 小节的实验参数作为环境变量注入容器
+	section, err := s.resolveSection(templateID, sectionID)
+	if err != nil && (sectionID != 0 || !errors.Is(err, gorm.ErrRecordNotFound)) {
+		return err
+	}
+	if err == nil {
+		values, err := params.ForSection(section, userID)
+		if err != nil {
+			return err
+		}
+		payload.Template.Envs = params.AppendEnvs(template.Envs, values)
+		payload.SectionID = section.ID
+	}
+
+	// 占用配额，全局资源不足且开启了排队时进入排队
+	reserved := &model.ContainerInstance{UserID: userID, SectionID: payload.SectionID, TemplateID: templateID}
+	if err := s.quotaChecker.Reserve(reserved, template); err != nil {
+		if !errors.Is(err, quota.ErrHostFull) || !s.quotaChecker.Limits().QueueWhenFull {
+			return err
+		}
+		payload.Queued = true
+	} else {
+		payload.InstanceID = reserved.ID
+	}
+
+	if err := s.taskClient.EnqueueContainerCreateTask(payload); err != nil {
+		if payload.InstanceID != 0 {
+			s.releaseInstance(reserved)
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseInstance 释放没有创建容器的占位记录
+func (s *ContainerServiceImpl) releaseInstance(instance *model.ContainerInstance) {
+	instance.Status = "Removed"
+	instance.EndAt = time.Now()
+	if err := s.instanceRepo.UpdateInstanceStatus(instance); err != nil {
+		logrus.Warnf("instanceRepo.UpdateInstanceStatus failed: %v", err)
+	}
+}
+
+// RemoveContainer 停止并移除用户的容器，释放配额
+func (s *ContainerServiceImpl) RemoveContainer(userID, templateID uint) error {
+	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
+	if err != nil {
+		return err
+	}
+
+	return s.taskClient.EnqueueContainerRemoveTask(task.ContainerRemovePayload{
+		Instance: *instance,
+	})
+}
+
+// GetContainerUsage 获取当前的容器资源占用和配额
+func (s *ContainerServiceImpl) GetContainerUsage() (*ContainerUsage, error) {
+	total, err := s.instanceRepo.GetRunningUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.instanceRepo.GetRunningUsageGroupByUser()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerUsage{
+		Limits: s.quotaChecker.Limits(),
+		Total:  total,
+		Users:  users,
+	}, nil
+}
+
+// resolveSecurityProfile 获取模板引用的安全配置
+// 模板未指定时使用名为 default 的安全配置，若管理员没有配置则使用Docker默认配置
+func resolveSecurityProfile(profileRepo repository.SecurityProfileRepository, template *model.ContainerTemplate) (*model.SecurityProfile, error) {
+	if template.SecurityProfile != "" {
+		profile, err := profileRepo.GetProfileByName(template.SecurityProfile)
+		if err != nil {
+			return nil, fmt.Errorf("security profile %s of template %d not found: %v", template.SecurityProfile, template.ID, err)
+		}
+		return profile, nil
+	}
+
+	profile, err := profileRepo.GetProfileByName(model.DefaultSecurityProfile)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
 }
 
 func (s *ContainerServiceImpl) GetContainer(userID, templateID uint) (*model.ContainerInstance, error) {
-	return s.instanceRepo.GetInstanceByUserIDAndTemplateID(templateID, userID)
+	return s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
+}
+
+// GetContainerSudoPassword 获取用户容器的SUDO密码，只能获取自己的容器
+func (s *ContainerServiceImpl) GetContainerSudoPassword(userID, templateID uint) (string, error) {
+	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
+	if err != nil {
+		return "", err
+	}
+	if instance.SUDOPass == "" {
+		return "", ErrNoSudoPassword
+	}
+	return s.secretManager.Decrypt(instance.SUDOPass)
 }
 
 func (s *ContainerServiceImpl) GetChannel(userID, templateID uint, typ int) (chan string, error) {
@@ -80,15 +238,37 @@ func (s *ContainerServiceImpl) GetChannel(userID, templateID uint, typ int) (cha
 	return nil, errors.New("invalid type")
 }
 
-func (s *ContainerServiceImpl) CheckContainer(userID, templateID uint) error {
-	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(templateID, userID)
+// CheckContainer 检查小节的开放时间和检测次数限制后提交检测任务，返回提交后的检测次数状态
+func (s *ContainerServiceImpl) CheckContainer(userID, templateID uint) (*quota.AttemptStatus, error) {
+	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	scripts, err := s.scriptRepo.GetScriptsByTemplateID(templateID)
+	section, err := s.resolveSection(templateID, instance.SectionID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	now := time.Now()
+	chapter, err := s.courseRepo.GetChapterByID(section.ChapterID)
+	if err != nil {
+		return nil, err
+	}
+	if deadline := model.EffectiveDeadline(section, chapter); deadline != nil {
+		if err := deadline.Check(now); err != nil {
+			return nil, err
+		}
+	}
+
+	template, err := s.templateRepo.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := s.scriptRepo.GetScriptsBySectionID(section.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	scriptSlice := make([]model.ContainerScript, 0)
@@ -96,12 +276,120 @@ func (s *ContainerServiceImpl) CheckContainer(userID, templateID uint) error {
 		scriptSlice = append(scriptSlice, *script)
 	}
 
+	_, checks, _ := grader.SplitPhases(scriptSlice)
+	submission := &model.Submission{
+		UserID:       userID,
+		SectionID:    section.ID,
+		TemplateID:   templateID,
+		InstanceID:   instance.ID,
+		Status:       model.SubmissionPending,
+		Total:        uint(len(checks)),
+		SuiteVersion: suite.VersionOf(scriptSlice),
+	}
+	// 检查次数和创建提交在同一事务中完成，并发的检测请求不会超过次数限制
+	stats, err := s.submissionRepo.ReserveAttempt(submission, now.Add(-quota.AttemptWindow), quota.CheckAttempts(section, now))
+	if err != nil {
+		var limitErr *quota.AttemptLimitError
+		if errors.As(err, &limitErr) {
+			return &limitErr.Status, err
+		}
+		return nil, err
+	}
+
 	payload := task.ContainerExecPayload{
-		Instance:   *instance,
-		Scripts:    scriptSlice,
-		UserID:     userID,
-		TemplateID: templateID,
+		Instance:     *instance,
+		Template:     *template,
+		Scripts:      scriptSlice,
+		UserID:       userID,
+		TemplateID:   templateID,
+		SubmissionID: submission.ID,
+	}
+	if err := s.taskClient.EnqueueContainerExecTask(payload); err != nil {
+		// 任务没有提交成功，不计入检测次数
+		submission.Status = model.SubmissionError
+		submission.Error = err.Error()
+		if updateErr := s.submissionRepo.UpdateSubmission(submission); updateErr != nil {
+			logrus.Warnf("submissionRepo.UpdateSubmission failed: %v", updateErr)
+		}
+		return nil, err
 	}
 
-	return s.taskClient.EnqueueContainerExecTask(payload)
+	// 本次提交计入统计后的状态
+	stats.Total++
+	stats.Recent++
+	if stats.OldestRecent == nil {
+		stats.OldestRecent = &now
+	}
+	status := quota.EvaluateAttempts(section, stats, now)
+	return &status, nil
+}
+
+// GetCheckAttempts 获取用户在模板对应小节的检测次数状态，已有容器时使用容器所属的小节
+func (s *ContainerServiceImpl) GetCheckAttempts(userID, templateID uint) (*quota.AttemptStatus, error) {
+	var sectionID uint
+	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
+	if err == nil {
+		sectionID = instance.SectionID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	section, err := s.resolveSection(templateID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stats, err := s.submissionRepo.GetAttemptStats(userID, section.ID, now.Add(-quota.AttemptWindow))
+	if err != nil {
+		return nil, err
+	}
+	status := quota.EvaluateAttempts(section, stats, now)
+	return &status, nil
+}
+
+// resolveSection 确定容器对应的小节：指定了小节时校验小节使用该模板，
+// 否则使用模板所属的唯一小节，模板被多个小节使用时返回 ErrAmbiguousSection
+func (s *ContainerServiceImpl) resolveSection(templateID, sectionID uint) (*model.Section, error) {
+	if sectionID != 0 {
+		section, err := s.sectionRepo.GetSectionByID(sectionID)
+		if err != nil {
+			return nil, err
+		}
+		if section.TemplateID != templateID {
+			return nil, ErrSectionTemplate
+		}
+		return section, nil
+	}
+
+	sections, err := s.sectionRepo.GetSectionsByTemplateID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	switch len(sections) {
+	case 0:
+		return nil, gorm.ErrRecordNotFound
+	case 1:
+		return &sections[0], nil
+	default:
+		return nil, ErrAmbiguousSection
+	}
+}
+
+// GetContainerLogs 获取用户自己容器的日志
+func (s *ContainerServiceImpl) GetContainerLogs(ctx context.Context, userID, templateID uint, options container.LogOptions) (<-chan container.LogLine, error) {
+	instance, err := s.instanceRepo.GetInstanceByUserIDAndTemplateID(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.containerMgr.FollowLogs(ctx, instance, options)
+}
+
+// GetInstanceLogs 根据实例ID获取容器日志，供管理员排查问题
+func (s *ContainerServiceImpl) GetInstanceLogs(ctx context.Context, instanceID uint, options container.LogOptions) (<-chan container.LogLine, error) {
+	instance, err := s.instanceRepo.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.containerMgr.FollowLogs(ctx, instance, options)
 }
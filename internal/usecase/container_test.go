@@ -38,7 +38,7 @@ func TestContainerService(t *testing.T) {
 	templateID := uint(1)
 
 	// 1. 创建容器
-	err := service.CreateContainer(userID, templateID)
+	err := service.CreateContainer(userID, templateID, 0)
 	if err != nil {
 		t.Fatalf("创建容器失败: %v", err)
 	}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"awesomeProject/internal/model"
+	"awesomeProject/internal/params"
 	"awesomeProject/internal/repository"
 	"awesomeProject/pkg/db"
 	"awesomeProject/pkg/oss"
@@ -19,18 +20,32 @@ var (
 	_ CourseService = (*CourseServiceImpl)(nil)
 )
 
+var (
+	// ErrNotCourseTeacher 用户不是课程的授课教师
+	ErrNotCourseTeacher = errors.New("not a teacher of the course")
+	// ErrNotTeacher 用户不是教师，不能成为授课教师
+	ErrNotTeacher = errors.New("user is not a teacher")
+)
+
 // CourseService 课程服务接口
 type CourseService interface {
 	GetAllCourses() ([]model.Course, error)
-	GetCourseByID(id uint) (*model.Course, error)
+	GetCourseByID(userID, id uint) (*model.Course, error)
 	GetCourseReferences(courseID uint) ([]model.CourseReference, error)
 	GetCourseReferencesDownloadURL(referenceID uint) (string, error)
 	GetCourseStatus(userID, courseID uint) ([]model.UserSectionStatus, error)
+	CheckCourseTeacher(userID, courseID uint) error
+	CheckSectionTeacher(userID, sectionID uint) error
+	CheckSubmissionTeacher(userID, submissionID uint) error
+	AddCourseTeacher(courseID, userID uint) error
+	RemoveCourseTeacher(courseID, userID uint) error
 }
 
 // CourseServiceImpl 课程服务实现
 type CourseServiceImpl struct {
 	CourseRepository repository.CourseRepository
+	userRepo         repository.UserRepository
+	submissionRepo   repository.SubmissionRepository
 	cache            db.Cache
 	ossManager       oss.Manager
 }
@@ -39,6 +54,8 @@ func NewCourseService() CourseService {
 	courseSyncOnce.Do(func() {
 		courseServiceInstance = &CourseServiceImpl{
 			CourseRepository: repository.NewCourseRepository(db.DB),
+			userRepo:         repository.NewUserRepository(db.DB),
+			submissionRepo:   repository.NewSubmissionRepository(db.DB),
 			cache:            db.NewCache(),
 			ossManager:       oss.NewOssClient(),
 		}
@@ -80,12 +97,32 @@ func (s *CourseServiceImpl) GetAllCourses() ([]model.Course, error) {
 	return courses, nil
 }
 
-// GetCourseByID 根据ID获取课程
-func (s *CourseServiceImpl) GetCourseByID(id uint) (*model.Course, error) {
+// GetCourseByID 根据ID获取课程，小节内容中的实验参数按用户渲染；
+// 某个小节的参数声明有误时只在该小节上标记原因，不影响整个课程
+func (s *CourseServiceImpl) GetCourseByID(userID, id uint) (*model.Course, error) {
 	course, err := s.CourseRepository.GetCourseByID(id)
 	if err != nil {
 		return nil, errors.New("课程不存在")
 	}
+
+	// 填充各小节实际生效的截止时间和用户的实验参数
+	for i := range course.Chapters {
+		chapter := &course.Chapters[i]
+		for j := range chapter.Sections {
+			section := &chapter.Sections[j]
+			section.EffectiveDeadline = model.EffectiveDeadline(section, chapter)
+
+			values, err := params.ForSection(section, userID)
+			if err != nil {
+				section.ParamError = err.Error()
+				continue
+			}
+			if len(values) > 0 {
+				section.ParamValues = values
+				section.Content = params.Render(section.Content, values)
+			}
+		}
+	}
 	return course, nil
 }
 
@@ -114,3 +151,53 @@ func (s *CourseServiceImpl) GetCourseReferencesDownloadURL(referenceID uint) (st
 
 	return downloadURL, nil
 }
+
+// CheckCourseTeacher 检查用户是否为课程的授课教师，不是时返回 ErrNotCourseTeacher
+func (s *CourseServiceImpl) CheckCourseTeacher(userID, courseID uint) error {
+	ok, err := s.CourseRepository.IsCourseTeacher(courseID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotCourseTeacher
+	}
+	return nil
+}
+
+// CheckSectionTeacher 检查用户是否为小节所属课程的授课教师
+func (s *CourseServiceImpl) CheckSectionTeacher(userID, sectionID uint) error {
+	courseID, err := s.CourseRepository.GetCourseIDBySectionID(sectionID)
+	if err != nil {
+		return err
+	}
+	return s.CheckCourseTeacher(userID, courseID)
+}
+
+// CheckSubmissionTeacher 检查用户是否为提交所属课程的授课教师
+func (s *CourseServiceImpl) CheckSubmissionTeacher(userID, submissionID uint) error {
+	submission, err := s.submissionRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return err
+	}
+	return s.CheckSectionTeacher(userID, submission.SectionID)
+}
+
+// AddCourseTeacher 添加课程的授课教师，只有教师可以成为授课教师
+func (s *CourseServiceImpl) AddCourseTeacher(courseID, userID uint) error {
+	if _, err := s.CourseRepository.GetCourseByID(courseID); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Role != model.RoleTeacher {
+		return ErrNotTeacher
+	}
+	return s.CourseRepository.AddCourseTeacher(courseID, userID)
+}
+
+// RemoveCourseTeacher 移除课程的授课教师
+func (s *CourseServiceImpl) RemoveCourseTeacher(courseID, userID uint) error {
+	return s.CourseRepository.RemoveCourseTeacher(courseID, userID)
+}
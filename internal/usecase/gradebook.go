@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"awesomeProject/internal/gradebook"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var (
+	gradebookServiceInstance GradebookService
+	gradebookSyncOnce        sync.Once
+
+	_ GradebookService = (*GradebookServiceImpl)(nil)
+)
+
+// ErrInvalidCohort 班级名称不正确
+var ErrInvalidCohort = errors.New("cohort must be at most 50 characters")
+
+// GradebookService 成绩册服务接口
+type GradebookService interface {
+	GetGradebook(courseID uint, filter gradebook.Filter) (*gradebook.Gradebook, error)
+	SetCohort(userID uint, cohort string) error
+}
+
+// GradebookServiceImpl 成绩册服务实现
+type GradebookServiceImpl struct {
+	gradebookRepo repository.GradebookRepository
+	courseRepo    repository.CourseRepository
+}
+
+func NewGradebookService() GradebookService {
+	gradebookSyncOnce.Do(func() {
+		gradebookServiceInstance = &GradebookServiceImpl{
+			gradebookRepo: repository.NewGradebookRepository(db.DB),
+			courseRepo:    repository.NewCourseRepository(db.DB),
+		}
+	})
+	return gradebookServiceInstance
+}
+
+// GetGradebook 生成课程的成绩册，包括参与了课程且符合班级条件的学生，只统计时间范围内的提交
+func (s *GradebookServiceImpl) GetGradebook(courseID uint, filter gradebook.Filter) (*gradebook.Gradebook, error) {
+	course, err := s.courseRepo.GetCourseByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := s.columns(course)
+	if err != nil {
+		return nil, err
+	}
+	sectionIDs := make([]uint, 0, len(columns))
+	for _, column := range columns {
+		sectionIDs = append(sectionIDs, column.SectionID)
+	}
+
+	users, err := s.gradebookRepo.GetStudents(sectionIDs, filter.Cohort)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.gradebookRepo.GetSubmissions(sectionIDs, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return gradebook.Build(course, columns, users, submissions), nil
+}
+
+// columns 返回课程的所有小节及其满分
+func (s *GradebookServiceImpl) columns(course *model.Course) ([]gradebook.Column, error) {
+	columns := gradebook.Columns(course)
+	sectionIDs := make([]uint, 0, len(columns))
+	for _, column := range columns {
+		sectionIDs = append(sectionIDs, column.SectionID)
+	}
+
+	checkPoints, err := s.gradebookRepo.GetCheckPoints(sectionIDs)
+	if err != nil {
+		return nil, err
+	}
+	questionPoints, err := s.gradebookRepo.GetQuestionPoints(sectionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[uint]*model.Section)
+	for i := range course.Chapters {
+		for j := range course.Chapters[i].Sections {
+			section := &course.Chapters[i].Sections[j]
+			sections[section.ID] = section
+		}
+	}
+	for i := range columns {
+		id := columns[i].SectionID
+		columns[i].MaxScore = gradebook.SectionMaxScore(sections[id], checkPoints[id], questionPoints[id])
+	}
+	return columns, nil
+}
+
+// SetCohort 设置用户所属班级，为空时清除
+func (s *GradebookServiceImpl) SetCohort(userID uint, cohort string) error {
+	cohort = strings.TrimSpace(cohort)
+	if len([]rune(cohort)) > 50 {
+		return ErrInvalidCohort
+	}
+	return s.gradebookRepo.UpdateCohort(userID, cohort)
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quota"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"awesomeProject/pkg/oss"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	gradingServiceInstance GradingService
+	gradingSyncOnce        sync.Once
+
+	_ GradingService = (*GradingServiceImpl)(nil)
+)
+
+// 人工评分提交的限制
+const (
+	MaxSubmissionFiles    = 10
+	MaxSubmissionFileSize = 20 << 20
+	submissionURLExpire   = 10 * time.Minute
+)
+
+var (
+	// ErrNotManualSection 小节不是人工评分的小节
+	ErrNotManualSection = errors.New("section is not manually graded")
+	// ErrEmptySubmission 提交既没有文字也没有文件
+	ErrEmptySubmission = errors.New("submission has no content or files")
+	// ErrInvalidScore 分数超出小节的满分范围
+	ErrInvalidScore = errors.New("score is out of range")
+)
+
+// UploadedFile 学生上传的文件，Path 为保存在本地的临时文件
+type UploadedFile struct {
+	Name string
+	Path string
+	Size int64
+}
+
+// Grade 教师给出的评分
+type Grade struct {
+	Score    float64
+	Passed   bool
+	Feedback string
+}
+
+// GradingService 人工评分服务接口
+type GradingService interface {
+	Submit(userID, sectionID uint, content string, files []UploadedFile) (*model.Submission, error)
+	GetGradingQueue(courseID uint, pending bool) ([]model.Submission, error)
+	GetGradingSubmission(submissionID uint) (*model.Submission, error)
+	GradeSubmission(teacherID, submissionID uint, grade Grade) (*model.Submission, error)
+}
+
+// GradingServiceImpl 人工评分服务实现
+type GradingServiceImpl struct {
+	submissionRepo repository.SubmissionRepository
+	sectionRepo    repository.SectionRepository
+	courseRepo     repository.CourseRepository
+	ossManager     oss.Manager
+}
+
+func NewGradingService() GradingService {
+	gradingSyncOnce.Do(func() {
+		gradingServiceInstance = &GradingServiceImpl{
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			courseRepo:     repository.NewCourseRepository(db.DB),
+			ossManager:     oss.NewOssClient(),
+		}
+	})
+	return gradingServiceInstance
+}
+
+// Submit 学生提交人工评分小节的作答，与自动检测一样受截止时间和检测次数的限制
+func (s *GradingServiceImpl) Submit(userID, sectionID uint, content string, files []UploadedFile) (*model.Submission, error) {
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.GradingMode != model.GradingManual {
+		return nil, ErrNotManualSection
+	}
+
+	if strings.TrimSpace(content) == "" && len(files) == 0 {
+		return nil, ErrEmptySubmission
+	}
+	if err := CheckSubmissionFiles(files); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	chapter, err := s.courseRepo.GetChapterByID(section.ChapterID)
+	if err != nil {
+		return nil, err
+	}
+	if deadline := model.EffectiveDeadline(section, chapter); deadline != nil {
+		if err := deadline.Check(now); err != nil {
+			return nil, err
+		}
+	}
+
+	submission := &model.Submission{
+		UserID:     userID,
+		SectionID:  section.ID,
+		TemplateID: section.TemplateID,
+		Status:     model.SubmissionSubmitted,
+		MaxScore:   section.ManualMaxScore,
+		Content:    content,
+	}
+	// 检查次数和创建提交在同一事务中完成，并发提交不会超过次数限制
+	if _, err := s.submissionRepo.ReserveAttempt(submission, now.Add(-quota.AttemptWindow), quota.CheckAttempts(section, now)); err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file.Name)
+		objectName := fmt.Sprintf("submissions/%d/%d/%s", userID, submission.ID, name)
+		if err := s.ossManager.UploadObject(objectName, file.Path); err != nil {
+			// 文件没有上传成功，不计入检测次数
+			submission.Status = model.SubmissionError
+			submission.Error = err.Error()
+			if updateErr := s.submissionRepo.UpdateSubmission(submission); updateErr != nil {
+				logrus.Warnf("submissionRepo.UpdateSubmission failed: %v", updateErr)
+			}
+			return nil, err
+		}
+
+		record := model.SubmissionFile{
+			SubmissionID: submission.ID,
+			Name:         name,
+			ObjectName:   objectName,
+			Size:         file.Size,
+		}
+		if err := s.submissionRepo.CreateSubmissionFile(&record); err != nil {
+			return nil, err
+		}
+		submission.Files = append(submission.Files, record)
+	}
+
+	return submission, nil
+}
+
+// CheckSubmissionFiles 检查上传文件的数量和大小，只使用文件名和大小，可以在保存文件之前调用
+func CheckSubmissionFiles(files []UploadedFile) error {
+	if len(files) > MaxSubmissionFiles {
+		return fmt.Errorf("at most %d files can be submitted", MaxSubmissionFiles)
+	}
+	for _, file := range files {
+		if file.Size > MaxSubmissionFileSize {
+			return fmt.Errorf("file %s exceeds %d bytes", file.Name, MaxSubmissionFileSize)
+		}
+	}
+	return nil
+}
+
+// GetGradingQueue 获取课程的评分队列，pending 为 false 时返回已评分的提交
+func (s *GradingServiceImpl) GetGradingQueue(courseID uint, pending bool) ([]model.Submission, error) {
+	return s.submissionRepo.GetGradingQueue(courseID, pending)
+}
+
+// GetGradingSubmission 获取待评分提交的详情，附带文件的下载链接
+func (s *GradingServiceImpl) GetGradingSubmission(submissionID uint) (*model.Submission, error) {
+	submission, err := s.submissionRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range submission.Files {
+		url, err := s.ossManager.GetObjectUrl(submission.Files[i].ObjectName, int64(submissionURLExpire/time.Second))
+		if err != nil {
+			return nil, err
+		}
+		submission.Files[i].URL = url
+	}
+	return submission, nil
+}
+
+// GradeSubmission 教师评分，按提交时间计算迟交罚分并更新学生的小节完成状态；
+// 已评分的提交可以重新评分，小节成绩按所有已评分的提交重新计算，重新评分可以降低成绩
+func (s *GradingServiceImpl) GradeSubmission(teacherID, submissionID uint, grade Grade) (*model.Submission, error) {
+	submission, err := s.submissionRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	section, err := s.sectionRepo.GetSectionByID(submission.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.GradingMode != model.GradingManual || (submission.Status != model.SubmissionSubmitted && submission.GradedAt == nil) {
+		return nil, ErrNotManualSection
+	}
+	if grade.Score < 0 || grade.Score > submission.MaxScore {
+		return nil, fmt.Errorf("%w: must be between 0 and %g", ErrInvalidScore, submission.MaxScore)
+	}
+
+	chapter, err := s.courseRepo.GetChapterByID(section.ChapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	submission.RawScore = grade.Score
+	grader.ApplyLatePenalty(submission, model.EffectiveDeadline(section, chapter), submission.CreatedAt)
+	submission.Status = model.SubmissionFailed
+	if grade.Passed {
+		submission.Status = model.SubmissionPassed
+	}
+	submission.Feedback = grade.Feedback
+	submission.GradedBy = teacherID
+	submission.GradedAt = &now
+	submission.FinishedAt = &now
+	if err := s.submissionRepo.UpdateSubmission(submission); err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.submissionRepo.GetSubmissionsByUserID(submission.UserID, submission.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	score, maxScore, completed := gradedResult(submissions)
+	if _, err := s.sectionRepo.SetSectionResult(submission.UserID, submission.SectionID, score, maxScore, completed); err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// gradedResult 按已评分的提交计算小节成绩：取得分百分比最高的一次，任意一次通过即视为完成
+func gradedResult(submissions []model.Submission) (score, maxScore float64, completed bool) {
+	best := -1.0
+	for _, submission := range submissions {
+		if submission.GradedAt == nil {
+			continue
+		}
+		if submission.Percentage > best {
+			best = submission.Percentage
+			score = submission.Score
+			maxScore = submission.MaxScore
+		}
+		if submission.Status == model.SubmissionPassed {
+			completed = true
+		}
+	}
+	return score, maxScore, completed
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quota"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/oss"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 人工评分测试使用的内存仓库，只实现评分服务用到的方法
+
+type fakeSubmissionRepo struct {
+	repository.SubmissionRepository
+	submissions []*model.Submission
+}
+
+func (r *fakeSubmissionRepo) ReserveAttempt(submission *model.Submission, since time.Time, check func(*repository.AttemptStats) error) (*repository.AttemptStats, error) {
+	stats := &repository.AttemptStats{}
+	for _, s := range r.submissions {
+		if s.UserID != submission.UserID || s.SectionID != submission.SectionID || s.Status == model.SubmissionError {
+			continue
+		}
+		stats.Total++
+		if !s.CreatedAt.Before(since) {
+			stats.Recent++
+		}
+	}
+	if err := check(stats); err != nil {
+		return stats, err
+	}
+
+	submission.ID = uint(len(r.submissions) + 1)
+	submission.Attempt = uint(stats.Total) + 1
+	submission.CreatedAt = time.Now()
+	saved := *submission
+	r.submissions = append(r.submissions, &saved)
+	return stats, nil
+}
+
+func (r *fakeSubmissionRepo) UpdateSubmission(submission *model.Submission) error {
+	saved := *submission
+	r.submissions[submission.ID-1] = &saved
+	return nil
+}
+
+func (r *fakeSubmissionRepo) GetSubmissionByID(id uint) (*model.Submission, error) {
+	if id == 0 || int(id) > len(r.submissions) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	submission := *r.submissions[id-1]
+	return &submission, nil
+}
+
+func (r *fakeSubmissionRepo) GetSubmissionsByUserID(userID, sectionID uint) ([]model.Submission, error) {
+	var submissions []model.Submission
+	for _, s := range r.submissions {
+		if s.UserID == userID && s.SectionID == sectionID {
+			submissions = append(submissions, *s)
+		}
+	}
+	return submissions, nil
+}
+
+func (r *fakeSubmissionRepo) CreateSubmissionFile(file *model.SubmissionFile) error {
+	return nil
+}
+
+type fakeSectionRepo struct {
+	repository.SectionRepository
+	sections map[uint]*model.Section
+	statuses map[uint]*model.UserSectionStatus
+}
+
+func (r *fakeSectionRepo) GetSectionByID(id uint) (*model.Section, error) {
+	section, ok := r.sections[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return section, nil
+}
+
+func (r *fakeSectionRepo) SetSectionResult(userID, sectionID uint, score, maxScore float64, completed bool) (*model.UserSectionStatus, error) {
+	status := &model.UserSectionStatus{UserID: userID, SectionID: sectionID, BestScore: score, MaxScore: maxScore, Completed: completed}
+	r.statuses[userID] = status
+	return status, nil
+}
+
+func (r *fakeSectionRepo) UpdateSolutionFiles(sectionID uint, files string) error {
+	section, ok := r.sections[sectionID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	section.SolutionFiles = files
+	return nil
+}
+
+type fakeCourseRepo struct {
+	repository.CourseRepository
+}
+
+func (r *fakeCourseRepo) GetChapterByID(id uint) (*model.Chapter, error) {
+	return &model.Chapter{Model: gorm.Model{ID: id}, CourseID: 1}, nil
+}
+
+type fakeOSS struct {
+	oss.Manager
+	objects []string
+}
+
+func (m *fakeOSS) UploadObject(objectName, filePath string) error {
+	m.objects = append(m.objects, objectName)
+	return nil
+}
+
+func newFakeGradingService(sections ...*model.Section) (*GradingServiceImpl, *fakeSectionRepo, *fakeOSS) {
+	sectionRepo := &fakeSectionRepo{
+		sections: make(map[uint]*model.Section),
+		statuses: make(map[uint]*model.UserSectionStatus),
+	}
+	for _, section := range sections {
+		sectionRepo.sections[section.ID] = section
+	}
+	ossManager := &fakeOSS{}
+	return &GradingServiceImpl{
+		submissionRepo: &fakeSubmissionRepo{},
+		sectionRepo:    sectionRepo,
+		courseRepo:     &fakeCourseRepo{},
+		ossManager:     ossManager,
+	}, sectionRepo, ossManager
+}
+
+func manualSection(id uint) *model.Section {
+	return &model.Section{Model: gorm.Model{ID: id}, ChapterID: 1, GradingMode: model.GradingManual, ManualMaxScore: 100}
+}
+
+func TestGradingSubmit(t *testing.T) {
+	section := manualSection(1)
+	section.MaxAttempts = 1
+	service, _, ossManager := newFakeGradingService(section, &model.Section{Model: gorm.Model{ID: 2}, GradingMode: model.GradingAuto})
+
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	assert.NoError(t, os.WriteFile(path, []byte("report"), 0o644))
+	files := []UploadedFile{{Name: "../report.pdf", Path: path, Size: 6}}
+
+	submission, err := service.Submit(7, 1, "answer", files)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(1), submission.Attempt)
+		assert.Equal(t, model.SubmissionSubmitted, submission.Status)
+		assert.Equal(t, 100.0, submission.MaxScore)
+		assert.Equal(t, []string{"submissions/7/1/report.pdf"}, ossManager.objects)
+	}
+
+	// 次数用尽
+	_, err = service.Submit(7, 1, "again", nil)
+	var limitErr *quota.AttemptLimitError
+	assert.ErrorAs(t, err, &limitErr)
+
+	_, err = service.Submit(7, 2, "answer", nil)
+	assert.ErrorIs(t, err, ErrNotManualSection)
+	_, err = service.Submit(8, 1, "  ", nil)
+	assert.ErrorIs(t, err, ErrEmptySubmission)
+	_, err = service.Submit(8, 1, "", make([]UploadedFile, MaxSubmissionFiles+1))
+	assert.Error(t, err)
+}
+
+func TestGradeSubmission(t *testing.T) {
+	service, sectionRepo, _ := newFakeGradingService(manualSection(1))
+
+	submission, err := service.Submit(7, 1, "answer", nil)
+	assert.NoError(t, err)
+
+	_, err = service.GradeSubmission(3, submission.ID, Grade{Score: 120, Passed: true})
+	assert.ErrorIs(t, err, ErrInvalidScore)
+
+	graded, err := service.GradeSubmission(3, submission.ID, Grade{Score: 80, Passed: true, Feedback: "good"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.SubmissionPassed, graded.Status)
+		assert.Equal(t, 80.0, graded.Score)
+		assert.Equal(t, uint(3), graded.GradedBy)
+		assert.Equal(t, "good", graded.Feedback)
+	}
+	assert.Equal(t, 80.0, sectionRepo.statuses[7].BestScore)
+	assert.True(t, sectionRepo.statuses[7].Completed)
+}
+
+func TestRegradeSubmission(t *testing.T) {
+	service, sectionRepo, _ := newFakeGradingService(manualSection(1))
+
+	first, err := service.Submit(7, 1, "first", nil)
+	assert.NoError(t, err)
+	second, err := service.Submit(7, 1, "second", nil)
+	assert.NoError(t, err)
+
+	_, err = service.GradeSubmission(3, first.ID, Grade{Score: 90, Passed: true})
+	assert.NoError(t, err)
+	_, err = service.GradeSubmission(3, second.ID, Grade{Score: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, sectionRepo.statuses[7].BestScore)
+	assert.True(t, sectionRepo.statuses[7].Completed)
+
+	// 重新评分可以降低成绩并取消完成状态
+	_, err = service.GradeSubmission(3, first.ID, Grade{Score: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, sectionRepo.statuses[7].BestScore)
+	assert.False(t, sectionRepo.statuses[7].Completed)
+}
@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"awesomeProject/internal/hint"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	hintServiceInstance HintService
+	hintSyncOnce        sync.Once
+
+	_ HintService = (*HintServiceImpl)(nil)
+)
+
+// ErrHintLocked 提示尚未解锁
+var ErrHintLocked = errors.New("hint is locked")
+
+// HintService 提示服务接口
+type HintService interface {
+	GetHints(userID, sectionID uint) ([]HintView, error)
+	ViewHint(userID, sectionID, hintID uint) (*HintView, error)
+	ListHints(sectionID uint) ([]model.Hint, error)
+	GetHint(id uint) (*model.Hint, error)
+	CreateHint(sectionID uint, h *model.Hint) error
+	UpdateHint(id uint, h *model.Hint) (*model.Hint, error)
+	DeleteHint(id uint) error
+	GetHintUsage(sectionID uint) ([]HintUsage, error)
+}
+
+// HintView 学生看到的提示，只有查看过的提示才包含内容
+type HintView struct {
+	ID                uint       `json:"id"`
+	CheckName         string     `json:"check_name,omitempty"`
+	Order             uint       `json:"order"`
+	Title             string     `json:"title"`
+	Content           string     `json:"content,omitempty"`
+	Unlocked          bool       `json:"unlocked"`
+	Viewed            bool       `json:"viewed"`
+	FailuresRemaining int64      `json:"failures_remaining,omitempty"`
+	UnlockAt          *time.Time `json:"unlock_at,omitempty"`
+}
+
+// HintUsage 提示的查看情况，供教师了解学生卡在哪里
+type HintUsage struct {
+	Hint   model.Hint        `json:"hint"`
+	Users  int               `json:"users"`
+	Usages []model.HintUsage `json:"usages"`
+}
+
+// HintServiceImpl 提示服务实现
+type HintServiceImpl struct {
+	hintRepo       repository.HintRepository
+	sectionRepo    repository.SectionRepository
+	scriptRepo     repository.ContainerScript
+	submissionRepo repository.SubmissionRepository
+}
+
+func NewHintService() HintService {
+	hintSyncOnce.Do(func() {
+		hintServiceInstance = &HintServiceImpl{
+			hintRepo:       repository.NewHintRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			scriptRepo:     repository.NewContainerScript(db.DB),
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
+		}
+	})
+	return hintServiceInstance
+}
+
+// GetHints 获取小节的提示及其对用户的解锁状态
+func (s *HintServiceImpl) GetHints(userID, sectionID uint) ([]HintView, error) {
+	hints, err := s.hintRepo.GetHintsBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.submissionRepo.GetFailureStats(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := s.hintRepo.GetUsagesByUserID(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+	viewed := make(map[uint]bool)
+	for _, usage := range usages {
+		viewed[usage.HintID] = true
+	}
+
+	progress := hint.Progress{
+		Failures:       stats.Failed,
+		CheckFailures:  stats.CheckFailures,
+		FirstAttemptAt: stats.FirstAt,
+	}
+	statuses := hint.Evaluate(hints, progress, time.Now())
+
+	views := make([]HintView, 0, len(statuses))
+	for _, status := range statuses {
+		view := HintView{
+			ID:                status.Hint.ID,
+			CheckName:         status.Hint.CheckName,
+			Order:             status.Hint.Order,
+			Title:             status.Hint.Title,
+			Unlocked:          status.Unlocked,
+			Viewed:            viewed[status.Hint.ID],
+			FailuresRemaining: status.FailuresRemaining,
+			UnlockAt:          status.UnlockAt,
+		}
+		if view.Viewed {
+			view.Content = status.Hint.Content
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// ViewHint 查看已解锁的提示并记录，未解锁时返回 ErrHintLocked
+func (s *HintServiceImpl) ViewHint(userID, sectionID, hintID uint) (*HintView, error) {
+	views, err := s.GetHints(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range views {
+		if views[i].ID != hintID {
+			continue
+		}
+		if !views[i].Unlocked {
+			return nil, ErrHintLocked
+		}
+
+		h, err := s.hintRepo.GetHintByID(hintID)
+		if err != nil {
+			return nil, err
+		}
+		err = s.hintRepo.RecordUsage(&model.HintUsage{
+			UserID:    userID,
+			HintID:    hintID,
+			SectionID: sectionID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		views[i].Viewed = true
+		views[i].Content = h.Content
+		return &views[i], nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// ListHints 获取小节的所有提示，包括内容
+func (s *HintServiceImpl) ListHints(sectionID uint) ([]model.Hint, error) {
+	return s.hintRepo.GetHintsBySectionID(sectionID)
+}
+
+// GetHint 根据ID获取提示，包括内容
+func (s *HintServiceImpl) GetHint(id uint) (*model.Hint, error) {
+	return s.hintRepo.GetHintByID(id)
+}
+
+// CreateHint 为小节或小节中的检测点创建提示
+func (s *HintServiceImpl) CreateHint(sectionID uint, h *model.Hint) error {
+	h.SectionID = sectionID
+	if err := s.validateHint(h); err != nil {
+		return err
+	}
+	return s.hintRepo.CreateHint(h)
+}
+
+// UpdateHint 更新提示，不允许移动到其他小节
+func (s *HintServiceImpl) UpdateHint(id uint, h *model.Hint) (*model.Hint, error) {
+	existing, err := s.hintRepo.GetHintByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	h.Model = existing.Model
+	h.SectionID = existing.SectionID
+	if err := s.validateHint(h); err != nil {
+		return nil, err
+	}
+
+	if err := s.hintRepo.UpdateHint(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// DeleteHint 删除提示
+func (s *HintServiceImpl) DeleteHint(id uint) error {
+	return s.hintRepo.DeleteHint(id)
+}
+
+// GetHintUsage 获取小节每个提示被哪些学生查看过
+func (s *HintServiceImpl) GetHintUsage(sectionID uint) ([]HintUsage, error) {
+	hints, err := s.hintRepo.GetHintsBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := s.hintRepo.GetUsagesBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	byHint := make(map[uint][]model.HintUsage)
+	for _, usage := range usages {
+		byHint[usage.HintID] = append(byHint[usage.HintID], usage)
+	}
+
+	result := make([]HintUsage, 0, len(hints))
+	for _, h := range hints {
+		result = append(result, HintUsage{
+			Hint:   h,
+			Users:  len(byHint[h.ID]),
+			Usages: byHint[h.ID],
+		})
+	}
+	return result, nil
+}
+
+// validateHint 校验提示内容，检测点的提示只能关联小节模板中已有的检测点
+func (s *HintServiceImpl) validateHint(h *model.Hint) error {
+	if strings.TrimSpace(h.Content) == "" {
+		return errors.New("hint content is required")
+	}
+
+	section, err := s.sectionRepo.GetSectionByID(h.SectionID)
+	if err != nil {
+		return err
+	}
+	if h.CheckName == "" {
+		return nil
+	}
+
+	scripts, err := s.scriptRepo.GetScriptsBySectionID(section.ID)
+	if err != nil {
+		return err
+	}
+	for _, script := range scripts {
+		if script.Name == h.CheckName {
+			return nil
+		}
+	}
+	return fmt.Errorf("check %q does not belong to section %d", h.CheckName, h.SectionID)
+}
@@ -0,0 +1,394 @@
+package usecase
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/quiz"
+	"awesomeProject/internal/quota"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+var (
+	quizServiceInstance QuizService
+	quizSyncOnce        sync.Once
+
+	_ QuizService = (*QuizServiceImpl)(nil)
+)
+
+var (
+	// ErrNotQuizSection 小节不是测验小节
+	ErrNotQuizSection = errors.New("section is not a quiz")
+	// ErrEmptyQuiz 测验小节还没有题目
+	ErrEmptyQuiz = errors.New("quiz has no questions")
+)
+
+// QuizService 测验服务接口
+type QuizService interface {
+	StartQuiz(userID, sectionID uint) (*QuizAttemptView, error)
+	GetAttempt(userID, attemptID uint) (*QuizAttemptView, error)
+	ListAttempts(userID, sectionID uint) ([]model.QuizAttempt, error)
+	SubmitQuiz(userID, attemptID uint, responses map[uint]quiz.Response) (*QuizAttemptView, error)
+	ListQuestions(sectionID uint) ([]model.QuizQuestion, error)
+	CreateQuestion(sectionID uint, q *model.QuizQuestion) error
+	UpdateQuestion(id uint, q *model.QuizQuestion) (*model.QuizQuestion, error)
+	DeleteQuestion(id uint) error
+}
+
+// QuizAttemptView 学生看到的测验作答，提交前不包含任何答案信息
+type QuizAttemptView struct {
+	ID           uint               `json:"id"`
+	SectionID    uint               `json:"section_id"`
+	Status       string             `json:"status"`
+	StartedAt    time.Time          `json:"started_at"`
+	SubmittedAt  *time.Time         `json:"submitted_at,omitempty"`
+	SubmissionID uint               `json:"submission_id,omitempty"`
+	Score        float64            `json:"score"`
+	MaxScore     float64            `json:"max_score"`
+	Percentage   float64            `json:"percentage"`
+	Passed       bool               `json:"passed"`
+	Questions    []QuizQuestionView `json:"questions"`
+}
+
+// QuizQuestionView 学生看到的题目，提交后附带作答、是否正确和解析
+type QuizQuestionView struct {
+	ID          uint             `json:"id"`
+	Type        string           `json:"type"`
+	Prompt      string           `json:"prompt"`
+	Points      float64          `json:"points"`
+	Options     []QuizOptionView `json:"options,omitempty"`
+	Response    string           `json:"response,omitempty"`
+	OptionIDs   []uint           `json:"option_ids,omitempty"`
+	Correct     *bool            `json:"correct,omitempty"`
+	Score       *float64         `json:"score,omitempty"`
+	Explanation string           `json:"explanation,omitempty"`
+}
+
+// QuizOptionView 学生看到的选项，不包含是否正确
+type QuizOptionView struct {
+	ID      uint   `json:"id"`
+	Content string `json:"content"`
+}
+
+// QuizServiceImpl 测验服务实现
+type QuizServiceImpl struct {
+	quizRepo       repository.QuizRepository
+	sectionRepo    repository.SectionRepository
+	courseRepo     repository.CourseRepository
+	submissionRepo repository.SubmissionRepository
+}
+
+func NewQuizService() QuizService {
+	quizSyncOnce.Do(func() {
+		quizServiceInstance = &QuizServiceImpl{
+			quizRepo:       repository.NewQuizRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			courseRepo:     repository.NewCourseRepository(db.DB),
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
+		}
+	})
+	return quizServiceInstance
+}
+
+// StartQuiz 开始测验，已有未提交的作答时继续该作答；每次作答按随机种子抽取题目，
+// 与自动检测一样受截止时间和检测次数的限制
+func (s *QuizServiceImpl) StartQuiz(userID, sectionID uint) (*QuizAttemptView, error) {
+	section, chapter, err := s.quizSection(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.quizRepo.GetQuestionsBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := s.quizRepo.GetOpenAttempt(userID, sectionID)
+	if err == nil {
+		return s.attemptView(section, attempt, questions, nil), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if len(questions) == 0 {
+		return nil, ErrEmptyQuiz
+	}
+	now := time.Now()
+	if deadline := model.EffectiveDeadline(section, chapter); deadline != nil {
+		if err := deadline.Check(now); err != nil {
+			return nil, err
+		}
+	}
+
+	seed := now.UnixNano()
+	attempt = &model.QuizAttempt{
+		UserID:      userID,
+		SectionID:   sectionID,
+		Status:      model.QuizInProgress,
+		Seed:        seed,
+		QuestionIDs: quiz.FormatIDs(quiz.Select(questions, int(section.QuizQuestionCount), section.QuizShuffle, seed)),
+	}
+	// 并发开始测验时只有一个请求创建作答，其余请求返回同一个作答
+	attempt, err = s.quizRepo.StartAttempt(attempt, now.Add(-quota.AttemptWindow), quota.CheckAttempts(section, now))
+	if err != nil {
+		return nil, err
+	}
+	return s.attemptView(section, attempt, questions, nil), nil
+}
+
+// GetAttempt 获取作答详情，只能查看自己的作答
+func (s *QuizServiceImpl) GetAttempt(userID, attemptID uint) (*QuizAttemptView, error) {
+	attempt, err := s.quizRepo.GetAttemptByID(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	section, err := s.sectionRepo.GetSectionByID(attempt.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.quizRepo.GetQuestionsBySectionID(attempt.SectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var submission *model.Submission
+	if attempt.SubmissionID != 0 {
+		if submission, err = s.submissionRepo.GetSubmissionByID(attempt.SubmissionID); err != nil {
+			return nil, err
+		}
+	}
+	return s.attemptView(section, attempt, questions, submission), nil
+}
+
+// ListAttempts 获取用户在小节中的所有作答
+func (s *QuizServiceImpl) ListAttempts(userID, sectionID uint) ([]model.QuizAttempt, error) {
+	return s.quizRepo.GetAttemptsByUserID(userID, sectionID)
+}
+
+// SubmitQuiz 提交作答并自动评分。评分结果保存为一次提交，按提交时间计算迟交罚分，
+// 得分百分比达到小节的通过线时视为完成
+func (s *QuizServiceImpl) SubmitQuiz(userID, attemptID uint, responses map[uint]quiz.Response) (*QuizAttemptView, error) {
+	attempt, err := s.quizRepo.GetAttemptByID(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if attempt.Status != model.QuizInProgress {
+		return nil, repository.ErrAttemptSubmitted
+	}
+
+	section, chapter, err := s.quizSection(attempt.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	deadline := model.EffectiveDeadline(section, chapter)
+	if deadline != nil {
+		if err := deadline.Check(now); err != nil {
+			return nil, err
+		}
+	}
+
+	questions, err := s.quizRepo.GetQuestionsBySectionID(attempt.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.QuizQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	submission := &model.Submission{
+		UserID:     userID,
+		SectionID:  section.ID,
+		TemplateID: section.TemplateID,
+		StartedAt:  &attempt.CreatedAt,
+		FinishedAt: &now,
+		Duration:   now.Sub(attempt.CreatedAt).Milliseconds(),
+	}
+
+	attempt.Answers = nil
+	for _, id := range quiz.ParseIDs(attempt.QuestionIDs) {
+		// 作答期间被删除的题目不计分
+		q, ok := byID[id]
+		if !ok {
+			continue
+		}
+		response := responses[id]
+		correct, score := quiz.Grade(q, response)
+		attempt.Answers = append(attempt.Answers, model.QuizAnswer{
+			QuestionID: id,
+			Response:   response.Text,
+			OptionIDs:  quiz.FormatIDs(response.OptionIDs),
+			Correct:    correct,
+			Score:      score,
+		})
+
+		submission.Total++
+		submission.MaxScore += q.Points
+		submission.RawScore += score
+		if correct {
+			submission.Passed++
+		}
+	}
+
+	grader.ApplyLatePenalty(submission, deadline, now)
+	submission.Status = model.SubmissionFailed
+	if submission.Percentage >= section.QuizPassPercentage {
+		submission.Status = model.SubmissionPassed
+	}
+
+	attempt.SubmittedAt = &now
+	if err := s.quizRepo.SubmitAttempt(attempt, submission); err != nil {
+		return nil, err
+	}
+
+	completed := submission.Status == model.SubmissionPassed
+	if _, err := s.sectionRepo.RecordSectionResult(userID, section.ID, submission.Score, submission.MaxScore, completed); err != nil {
+		return nil, err
+	}
+
+	return s.attemptView(section, attempt, questions, submission), nil
+}
+
+// ListQuestions 教师获取小节的所有题目，包括答案
+func (s *QuizServiceImpl) ListQuestions(sectionID uint) ([]model.QuizQuestion, error) {
+	return s.quizRepo.GetQuestionsBySectionID(sectionID)
+}
+
+// CreateQuestion 为测验小节添加题目
+func (s *QuizServiceImpl) CreateQuestion(sectionID uint, q *model.QuizQuestion) error {
+	if _, _, err := s.quizSection(sectionID); err != nil {
+		return err
+	}
+	if err := quiz.Validate(*q); err != nil {
+		return err
+	}
+	q.SectionID = sectionID
+	return s.quizRepo.CreateQuestion(q)
+}
+
+// UpdateQuestion 更新题目，已提交的作答保留原来的评分
+func (s *QuizServiceImpl) UpdateQuestion(id uint, q *model.QuizQuestion) (*model.QuizQuestion, error) {
+	existing, err := s.quizRepo.GetQuestionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := quiz.Validate(*q); err != nil {
+		return nil, err
+	}
+	// 选项按ID原地更新，只能引用这道题目已有的选项
+	options := make(map[uint]bool, len(existing.Options))
+	for _, option := range existing.Options {
+		options[option.ID] = true
+	}
+	for _, option := range q.Options {
+		if option.ID != 0 && !options[option.ID] {
+			return nil, fmt.Errorf("%w: option %d does not belong to the question", quiz.ErrInvalidQuestion, option.ID)
+		}
+	}
+
+	existing.Order = q.Order
+	existing.Type = q.Type
+	existing.Prompt = q.Prompt
+	existing.Answer = q.Answer
+	existing.Tolerance = q.Tolerance
+	existing.CaseSensitive = q.CaseSensitive
+	existing.Points = q.Points
+	existing.Explanation = q.Explanation
+	existing.Options = q.Options
+	if err := s.quizRepo.UpdateQuestion(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteQuestion 删除题目
+func (s *QuizServiceImpl) DeleteQuestion(id uint) error {
+	return s.quizRepo.DeleteQuestion(id)
+}
+
+// quizSection 获取测验小节及其章节
+func (s *QuizServiceImpl) quizSection(sectionID uint) (*model.Section, *model.Chapter, error) {
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if section.GradingMode != model.GradingQuiz {
+		return nil, nil, ErrNotQuizSection
+	}
+	chapter, err := s.courseRepo.GetChapterByID(section.ChapterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return section, chapter, nil
+}
+
+// attemptView 按作答抽取的题目顺序生成学生看到的内容，提交后才附带评分和解析
+func (s *QuizServiceImpl) attemptView(section *model.Section, attempt *model.QuizAttempt, questions []model.QuizQuestion, submission *model.Submission) *QuizAttemptView {
+	byID := make(map[uint]model.QuizQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+	answers := make(map[uint]model.QuizAnswer, len(attempt.Answers))
+	for _, answer := range attempt.Answers {
+		answers[answer.QuestionID] = answer
+	}
+
+	view := &QuizAttemptView{
+		ID:           attempt.ID,
+		SectionID:    attempt.SectionID,
+		Status:       attempt.Status,
+		StartedAt:    attempt.CreatedAt,
+		SubmittedAt:  attempt.SubmittedAt,
+		SubmissionID: attempt.SubmissionID,
+		Questions:    make([]QuizQuestionView, 0),
+	}
+	if submission != nil {
+		view.Score = submission.Score
+		view.MaxScore = submission.MaxScore
+		view.Percentage = submission.Percentage
+		view.Passed = submission.Status == model.SubmissionPassed
+	}
+
+	for _, id := range quiz.ParseIDs(attempt.QuestionIDs) {
+		q, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if submission == nil {
+			view.MaxScore += q.Points
+		}
+
+		question := QuizQuestionView{
+			ID:     q.ID,
+			Type:   q.Type,
+			Prompt: q.Prompt,
+			Points: q.Points,
+		}
+		for _, option := range quiz.Options(q, section.QuizShuffle, attempt.Seed) {
+			question.Options = append(question.Options, QuizOptionView{ID: option.ID, Content: option.Content})
+		}
+		if answer, ok := answers[id]; ok && attempt.Status == model.QuizSubmitted {
+			question.Response = answer.Response
+			question.OptionIDs = quiz.ParseIDs(answer.OptionIDs)
+			question.Correct = &answer.Correct
+			question.Score = &answer.Score
+			question.Explanation = q.Explanation
+		}
+		view.Questions = append(view.Questions, question)
+	}
+	return view
+}
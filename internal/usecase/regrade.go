@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/internal/task"
+	"awesomeProject/pkg/db"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	regradeServiceInstance RegradeService
+	regradeSyncOnce        sync.Once
+
+	_ RegradeService = (*RegradeServiceImpl)(nil)
+)
+
+// ErrSectionNotGradable 小节没有关联容器模板，无法检测
+var ErrSectionNotGradable = errors.New("section has no container template")
+
+// RegradeService 重新评分服务接口
+type RegradeService interface {
+	StartRegrade(adminID, sectionID uint) (*model.RegradeJob, error)
+	ListRegradeJobs() ([]model.RegradeJob, error)
+	GetRegradeJob(jobID uint) (*model.RegradeJob, error)
+}
+
+// RegradeServiceImpl 重新评分服务实现
+type RegradeServiceImpl struct {
+	regradeRepo    repository.RegradeRepository
+	submissionRepo repository.SubmissionRepository
+	sectionRepo    repository.SectionRepository
+	templateRepo   repository.TemplateRepository
+	profileRepo    repository.SecurityProfileRepository
+	scriptRepo     repository.ContainerScript
+	taskClient     *task.Client
+}
+
+func NewRegradeService() RegradeService {
+	regradeSyncOnce.Do(func() {
+		regradeServiceInstance = &RegradeServiceImpl{
+			regradeRepo:    repository.NewRegradeRepository(db.DB),
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			templateRepo:   repository.NewTemplateRepository(db.DB),
+			profileRepo:    repository.NewSecurityProfileRepository(db.DB),
+			scriptRepo:     repository.NewContainerScript(db.DB),
+			taskClient:     task.GetTaskClient(),
+		}
+	})
+	return regradeServiceInstance
+}
+
+// StartRegrade 用小节当前的检测脚本重新检测每个学生最近一次完成的提交，每个学生一个异步任务
+func (s *RegradeServiceImpl) StartRegrade(adminID, sectionID uint) (*model.RegradeJob, error) {
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.TemplateID == 0 {
+		return nil, ErrSectionNotGradable
+	}
+
+	template, err := s.templateRepo.GetTemplateByID(section.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	// 学生的容器已被移除时，任务用模板创建临时容器重新检测
+	template.Profile, err = resolveSecurityProfile(s.profileRepo, template)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := s.scriptRepo.GetScriptsBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	scriptSlice := make([]model.ContainerScript, 0, len(scripts))
+	for _, script := range scripts {
+		scriptSlice = append(scriptSlice, *script)
+	}
+
+	submissions, err := s.submissionRepo.GetLatestSubmissions(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.RegradeJob{
+		SectionID:   sectionID,
+		TemplateID:  section.TemplateID,
+		RequestedBy: adminID,
+		Status:      model.RegradePending,
+		Total:       uint(len(submissions)),
+	}
+	if len(submissions) == 0 {
+		finishedAt := time.Now()
+		job.Status = model.RegradeFinished
+		job.FinishedAt = &finishedAt
+	}
+	if err := s.regradeRepo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	for _, submission := range submissions {
+		err := s.taskClient.EnqueueRegradeTask(task.RegradePayload{
+			JobID:    job.ID,
+			Previous: submission,
+			Template: *template,
+			Scripts:  scriptSlice,
+		})
+		if err == nil {
+			continue
+		}
+
+		// 任务没有提交成功，记为跳过，保证任务能够结束
+		err = s.regradeRepo.RecordResult(&model.RegradeResult{
+			JobID:                job.ID,
+			UserID:               submission.UserID,
+			PreviousSubmissionID: submission.ID,
+			PreviousStatus:       submission.Status,
+			PreviousScore:        submission.Score,
+			Error:                "enqueue regrade task failed: " + err.Error(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+// ListRegradeJobs 获取所有重新评分任务及其进度
+func (s *RegradeServiceImpl) ListRegradeJobs() ([]model.RegradeJob, error) {
+	return s.regradeRepo.GetJobs()
+}
+
+// GetRegradeJob 获取重新评分任务的进度和每个学生的得分变化
+func (s *RegradeServiceImpl) GetRegradeJob(jobID uint) (*model.RegradeJob, error) {
+	return s.regradeRepo.GetJobByID(jobID)
+}
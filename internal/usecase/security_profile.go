@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	securityProfileServiceInstance SecurityProfileService
+	securityProfileSyncOnce        sync.Once
+
+	_ SecurityProfileService = (*SecurityProfileServiceImpl)(nil)
+)
+
+// SecurityProfileService 安全配置服务接口，供管理员统一管理容器的安全配置
+type SecurityProfileService interface {
+	GetAllProfiles() ([]model.SecurityProfile, error)
+	GetProfile(id uint) (*model.SecurityProfile, error)
+	CreateProfile(profile *model.SecurityProfile) error
+	UpdateProfile(id uint, profile *model.SecurityProfile) (*model.SecurityProfile, error)
+	DeleteProfile(id uint) error
+}
+
+// SecurityProfileServiceImpl 安全配置服务实现
+type SecurityProfileServiceImpl struct {
+	profileRepo repository.SecurityProfileRepository
+}
+
+func NewSecurityProfileService() SecurityProfileService {
+	securityProfileSyncOnce.Do(func() {
+		securityProfileServiceInstance = &SecurityProfileServiceImpl{
+			profileRepo: repository.NewSecurityProfileRepository(db.DB),
+		}
+	})
+	return securityProfileServiceInstance
+}
+
+// GetAllProfiles 获取所有安全配置
+func (s *SecurityProfileServiceImpl) GetAllProfiles() ([]model.SecurityProfile, error) {
+	return s.profileRepo.GetAllProfiles()
+}
+
+// GetProfile 获取安全配置
+func (s *SecurityProfileServiceImpl) GetProfile(id uint) (*model.SecurityProfile, error) {
+	return s.profileRepo.GetProfileByID(id)
+}
+
+// CreateProfile 创建安全配置
+func (s *SecurityProfileServiceImpl) CreateProfile(profile *model.SecurityProfile) error {
+	if err := validateSecurityProfile(profile); err != nil {
+		return err
+	}
+	return s.profileRepo.CreateProfile(profile)
+}
+
+// UpdateProfile 更新安全配置，配置名称被模板引用，不允许修改
+func (s *SecurityProfileServiceImpl) UpdateProfile(id uint, profile *model.SecurityProfile) (*model.SecurityProfile, error) {
+	existing, err := s.profileRepo.GetProfileByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Name != "" && profile.Name != existing.Name {
+		return nil, errors.New("security profile name cannot be changed")
+	}
+
+	profile.Model = existing.Model
+	profile.Name = existing.Name
+	if err := validateSecurityProfile(profile); err != nil {
+		return nil, err
+	}
+
+	if err := s.profileRepo.UpdateProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// DeleteProfile 删除安全配置，仍被模板引用时不允许删除
+func (s *SecurityProfileServiceImpl) DeleteProfile(id uint) error {
+	profile, err := s.profileRepo.GetProfileByID(id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.profileRepo.CountTemplatesByProfile(profile.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("security profile %s is used by %d template(s)", profile.Name, count)
+	}
+
+	return s.profileRepo.DeleteProfile(id)
+}
+
+// validateSecurityProfile 校验安全配置，避免创建容器时才发现配置错误
+func validateSecurityProfile(profile *model.SecurityProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return errors.New("security profile name is required")
+	}
+
+	seccomp := strings.TrimSpace(profile.SeccompProfile)
+	if seccomp != "" && seccomp != "unconfined" && !json.Valid([]byte(seccomp)) {
+		return errors.New("seccomp profile must be empty, unconfined or a valid JSON profile")
+	}
+
+	if profile.UsernsMode != "" && profile.UsernsMode != "host" {
+		return errors.New("userns mode must be empty or host")
+	}
+
+	// capabilities 统一使用大写且不带 CAP_ 前缀
+	profile.CapAdd = normalizeCapabilities(profile.CapAdd)
+	profile.CapDrop = normalizeCapabilities(profile.CapDrop)
+
+	return nil
+}
+
+func normalizeCapabilities(value string) string {
+	caps := make([]string, 0)
+	for _, c := range strings.Split(value, ";") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		c = strings.TrimPrefix(c, "CAP_")
+		if c != "" {
+			caps = append(caps, c)
+		}
+	}
+	if len(caps) == 0 {
+		return ""
+	}
+	return strings.Join(caps, ";") + ";"
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/internal/task"
+	"awesomeProject/pkg/db"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+var (
+	similarityServiceInstance SimilarityService
+	similaritySyncOnce        sync.Once
+
+	_ SimilarityService = (*SimilarityServiceImpl)(nil)
+)
+
+// MaxSolutionFiles 查重时最多收集的答案文件数量
+const MaxSolutionFiles = 20
+
+var (
+	// ErrNoSolutionFiles 小节没有设置查重时需要收集的答案文件
+	ErrNoSolutionFiles = errors.New("section has no solution files")
+	// ErrInvalidSolutionFile 答案文件路径不正确
+	ErrInvalidSolutionFile = errors.New("invalid solution file")
+)
+
+// SimilarityService 查重服务接口
+type SimilarityService interface {
+	StartAnalysis(adminID, sectionID uint) (*model.SimilarityReport, error)
+	SetSolutionFiles(sectionID uint, files []string) ([]string, error)
+	ListReports(sectionID uint) ([]model.SimilarityReport, error)
+	GetReport(reportID uint) (*model.SimilarityReport, error)
+	GetPair(reportID, pairID uint) (*model.SimilarityPair, error)
+}
+
+// SimilarityServiceImpl 查重服务实现
+type SimilarityServiceImpl struct {
+	similarityRepo repository.SimilarityRepository
+	sectionRepo    repository.SectionRepository
+	taskClient     *task.Client
+}
+
+func NewSimilarityService() SimilarityService {
+	similaritySyncOnce.Do(func() {
+		similarityServiceInstance = &SimilarityServiceImpl{
+			similarityRepo: repository.NewSimilarityRepository(db.DB),
+			sectionRepo:    repository.NewSectionRepository(db.DB),
+			taskClient:     task.GetTaskClient(),
+		}
+	})
+	return similarityServiceInstance
+}
+
+// StartAnalysis 创建查重报告并提交异步任务，报告在任务完成后生成
+func (s *SimilarityServiceImpl) StartAnalysis(adminID, sectionID uint) (*model.SimilarityReport, error) {
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.TemplateID == 0 {
+		return nil, ErrSectionNotGradable
+	}
+	if len(section.SolutionPaths()) == 0 {
+		return nil, ErrNoSolutionFiles
+	}
+
+	report := &model.SimilarityReport{
+		SectionID:   sectionID,
+		RequestedBy: adminID,
+		Status:      model.SimilarityPending,
+	}
+	if err := s.similarityRepo.CreateReport(report); err != nil {
+		return nil, err
+	}
+
+	if err := s.taskClient.EnqueueSimilarityTask(task.SimilarityPayload{ReportID: report.ID}); err != nil {
+		report.Status = model.SimilarityFailed
+		report.Error = err.Error()
+		if updateErr := s.similarityRepo.UpdateReport(report); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// SetSolutionFiles 设置小节查重时从学生容器中收集的答案文件，路径必须是容器中的绝对路径，为空时清除。
+// 返回整理后的路径
+func (s *SimilarityServiceImpl) SetSolutionFiles(sectionID uint, files []string) ([]string, error) {
+	if len(files) > MaxSolutionFiles {
+		return nil, fmt.Errorf("%w: at most %d files", ErrInvalidSolutionFile, MaxSolutionFiles)
+	}
+
+	seen := make(map[string]bool, len(files))
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if !path.IsAbs(file) || strings.ContainsAny(file, "\r\n") || len(file) > 255 {
+			return nil, fmt.Errorf("%w: %q must be an absolute path", ErrInvalidSolutionFile, file)
+		}
+		file = path.Clean(file)
+		if !seen[file] {
+			seen[file] = true
+			paths = append(paths, file)
+		}
+	}
+
+	if err := s.sectionRepo.UpdateSolutionFiles(sectionID, strings.Join(paths, "\n")); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// ListReports 获取小节的所有查重报告
+func (s *SimilarityServiceImpl) ListReports(sectionID uint) ([]model.SimilarityReport, error) {
+	return s.similarityRepo.GetReportsBySectionID(sectionID)
+}
+
+// GetReport 获取查重报告及按相似度排列的学生对
+func (s *SimilarityServiceImpl) GetReport(reportID uint) (*model.SimilarityReport, error) {
+	return s.similarityRepo.GetReportByID(reportID)
+}
+
+// GetPair 获取两个学生的答案和相同的代码片段，用于并排对比
+func (s *SimilarityServiceImpl) GetPair(reportID, pairID uint) (*model.SimilarityPair, error) {
+	return s.similarityRepo.GetPairByID(reportID, pairID)
+}
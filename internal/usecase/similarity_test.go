@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestSetSolutionFiles(t *testing.T) {
+	_, sectionRepo, _ := newFakeGradingService(manualSection(1))
+	service := &SimilarityServiceImpl{sectionRepo: sectionRepo}
+
+	files, err := service.SetSolutionFiles(1, []string{" /work/main.c ", "/work/src/../util.c", "/work/main.c"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"/work/main.c", "/work/util.c"}, files)
+		assert.Equal(t, "/work/main.c\n/work/util.c", sectionRepo.sections[1].SolutionFiles)
+	}
+
+	_, err = service.SetSolutionFiles(1, []string{"main.c"})
+	assert.ErrorIs(t, err, ErrInvalidSolutionFile)
+	_, err = service.SetSolutionFiles(1, make([]string, MaxSolutionFiles+1))
+	assert.ErrorIs(t, err, ErrInvalidSolutionFile)
+	_, err = service.SetSolutionFiles(2, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 为空时清除
+	files, err = service.SetSolutionFiles(1, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Empty(t, sectionRepo.sections[1].SolutionFiles)
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"awesomeProject/internal/grader"
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/pkg/db"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	submissionServiceInstance SubmissionService
+	submissionSyncOnce        sync.Once
+
+	_ SubmissionService = (*SubmissionServiceImpl)(nil)
+)
+
+// SubmissionService 检测提交记录服务接口
+type SubmissionService interface {
+	ListSubmissions(userID, sectionID uint) ([]model.Submission, error)
+	GetSubmission(userID, submissionID uint) (*model.Submission, error)
+}
+
+// SubmissionServiceImpl 检测提交记录服务实现
+type SubmissionServiceImpl struct {
+	submissionRepo repository.SubmissionRepository
+}
+
+func NewSubmissionService() SubmissionService {
+	submissionSyncOnce.Do(func() {
+		submissionServiceInstance = &SubmissionServiceImpl{
+			submissionRepo: repository.NewSubmissionRepository(db.DB),
+		}
+	})
+	return submissionServiceInstance
+}
+
+// ListSubmissions 获取用户的提交历史，sectionID 为 0 时返回全部小节
+func (s *SubmissionServiceImpl) ListSubmissions(userID, sectionID uint) ([]model.Submission, error) {
+	return s.submissionRepo.GetSubmissionsByUserID(userID, sectionID)
+}
+
+// GetSubmission 获取提交详情，只能查看自己的提交，隐藏检测点的细节会被脱敏
+func (s *SubmissionServiceImpl) GetSubmission(userID, submissionID uint) (*model.Submission, error) {
+	submission, err := s.submissionRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不暴露其他用户的提交是否存在
+	if submission.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	submission.Results = grader.RedactAll(submission.Results)
+	return submission, nil
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/internal/repository"
+	"awesomeProject/internal/suite"
+	"awesomeProject/pkg/db"
+	"bytes"
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+)
+
+var (
+	suiteServiceInstance SuiteService
+	suiteSyncOnce        sync.Once
+
+	_ SuiteService = (*SuiteServiceImpl)(nil)
+)
+
+// SuiteService 检测套件服务接口
+type SuiteService interface {
+	ImportSuite(userID, sectionID uint, s *suite.Suite) (*model.GradingSuite, error)
+	ImportArchive(userID, sectionID uint, archive []byte) (*model.GradingSuite, error)
+	ExportSuite(sectionID, version uint) (*suite.Suite, error)
+	ListSuites(sectionID uint) ([]model.GradingSuite, error)
+}
+
+// SuiteServiceImpl 检测套件服务实现
+type SuiteServiceImpl struct {
+	suiteRepo   repository.SuiteRepository
+	sectionRepo repository.SectionRepository
+	scriptRepo  repository.ContainerScript
+}
+
+func NewSuiteService() SuiteService {
+	suiteSyncOnce.Do(func() {
+		suiteServiceInstance = &SuiteServiceImpl{
+			suiteRepo:   repository.NewSuiteRepository(db.DB),
+			sectionRepo: repository.NewSectionRepository(db.DB),
+			scriptRepo:  repository.NewContainerScript(db.DB),
+		}
+	})
+	return suiteServiceInstance
+}
+
+// ImportArchive 解析 tar.gz 格式的检测套件并导入到小节
+func (s *SuiteServiceImpl) ImportArchive(userID, sectionID uint, archive []byte) (*model.GradingSuite, error) {
+	parsed, err := suite.ReadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	return s.ImportSuite(userID, sectionID, parsed)
+}
+
+// ImportSuite 将检测套件导入到小节，替换小节的检测脚本。
+// 内容与最新版本相同时不产生新版本，用最新版本的脚本覆盖手动修改过的检测脚本后返回最新版本
+func (s *SuiteServiceImpl) ImportSuite(userID, sectionID uint, parsed *suite.Suite) (*model.GradingSuite, error) {
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.TemplateID == 0 {
+		return nil, ErrSectionNotGradable
+	}
+
+	checksum := parsed.Checksum()
+	latest, err := s.suiteRepo.GetLatestSuite(sectionID)
+	if err == nil && latest.Checksum == checksum {
+		if err := s.suiteRepo.RestoreSuite(latest, parsed.Scripts(sectionID, section.TemplateID, latest.Version)); err != nil {
+			return nil, err
+		}
+		return latest, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	archive, err := parsed.Archive()
+	if err != nil {
+		return nil, err
+	}
+
+	imported := &model.GradingSuite{
+		SectionID:  sectionID,
+		Name:       parsed.Manifest.Name,
+		Checksum:   checksum,
+		Checks:     uint(len(parsed.Manifest.Checks)),
+		ImportedBy: userID,
+		Archive:    archive,
+	}
+	scripts := parsed.Scripts(sectionID, section.TemplateID, 0)
+	if err := s.suiteRepo.ImportSuite(imported, scripts); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// ExportSuite 导出小节的检测套件，version 为 0 时导出当前的检测脚本
+func (s *SuiteServiceImpl) ExportSuite(sectionID, version uint) (*suite.Suite, error) {
+	if version != 0 {
+		stored, err := s.suiteRepo.GetSuite(sectionID, version)
+		if err != nil {
+			return nil, err
+		}
+		return suite.ReadArchive(bytes.NewReader(stored.Archive))
+	}
+
+	section, err := s.sectionRepo.GetSectionByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.TemplateID == 0 {
+		return nil, ErrSectionNotGradable
+	}
+
+	scripts, err := s.scriptRepo.GetScriptsBySectionID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	scriptSlice := make([]model.ContainerScript, 0, len(scripts))
+	for _, script := range scripts {
+		scriptSlice = append(scriptSlice, *script)
+	}
+	return suite.FromScripts(section.Title, scriptSlice)
+}
+
+// ListSuites 获取小节所有版本的检测套件
+func (s *SuiteServiceImpl) ListSuites(sectionID uint) ([]model.GradingSuite, error) {
+	return s.suiteRepo.GetSuites(sectionID)
+}
@@ -53,6 +53,9 @@ func (s *UserServiceImpl) Register(user *model.User) (string, string, error) {
 		return "", "", errors.New("用户名或邮箱已存在")
 	}
 
+	// 注册的用户只能是学生，角色由管理员分配
+	user.Role = model.RoleStudent
+
 	// 对密码进行哈希处理
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -0,0 +1,67 @@
+package workspace
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// MaxFileSize 从快照中读取的单个文件的最大字节数
+const MaxFileSize = 1024 * 1024
+
+// ObjectName 返回快照在对象存储中的名称
+func ObjectName(userID, sectionID, instanceID uint) string {
+	return fmt.Sprintf("snapshots/%d/%d/%d.tar", userID, sectionID, instanceID)
+}
+
+// EntryName 返回容器中的文件在工作目录快照中的名称。快照由 Docker 打包工作目录生成，
+// 名称以工作目录的最后一级开头，不在工作目录中的文件返回 false
+func EntryName(dir, file string) (string, bool) {
+	dir, file = path.Clean(dir), path.Clean(file)
+	if !path.IsAbs(dir) || !path.IsAbs(file) || dir == "/" {
+		return "", false
+	}
+	rel, ok := strings.CutPrefix(file, dir+"/")
+	if !ok {
+		return "", false
+	}
+	return path.Join(path.Base(dir), rel), true
+}
+
+// ReadFiles 从快照中读取容器中的文件，返回以容器中的路径为键的内容。
+// 不在快照中的文件不包含在结果中，超过 MaxFileSize 的文件返回错误
+func ReadFiles(archive io.Reader, dir string, files []string) (map[string][]byte, error) {
+	wanted := make(map[string]string, len(files))
+	for _, file := range files {
+		if name, ok := EntryName(dir, file); ok {
+			wanted[name] = file
+		}
+	}
+
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(archive)
+	for len(contents) < len(wanted) {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, ok := wanted[path.Clean(header.Name)]
+		if !ok || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > MaxFileSize {
+			return nil, fmt.Errorf("%s is too large: %d bytes", file, header.Size)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		contents[file] = data
+	}
+	return contents, nil
+}
@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEntryName(t *testing.T) {
+	tests := []struct {
+		dir, file string
+		name      string
+		ok        bool
+	}{
+		{"/home/student/work", "/home/student/work/main.c", "work/main.c", true},
+		{"/home/student/work/", "/home/student/work/src/../lib/a.py", "work/lib/a.py", true},
+		{"/home/student/work", "/home/student/workspace/main.c", "", false},
+		{"/home/student/work", "/etc/passwd", "", false},
+		{"/home/student/work", "main.c", "", false},
+		{"/", "/main.c", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := EntryName(tt.dir, tt.file)
+		assert.Equal(t, tt.ok, ok, tt.file)
+		assert.Equal(t, tt.name, name, tt.file)
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	write := func(name string, typeflag byte, data string) {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: typeflag, Size: int64(len(data)), Mode: 0o644}))
+		_, err := tw.Write([]byte(data))
+		assert.NoError(t, err)
+	}
+	write("work/", tar.TypeDir, "")
+	write("work/main.c", tar.TypeReg, "int main() {}\n")
+	write("work/notes.txt", tar.TypeReg, "notes")
+	write("work/src", tar.TypeDir, "")
+	assert.NoError(t, tw.Close())
+
+	contents, err := ReadFiles(bytes.NewReader(buf.Bytes()), "/root/work", []string{"/root/work/main.c", "/root/work/src", "/root/work/missing.c", "/etc/hosts"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"/root/work/main.c": []byte("int main() {}\n")}, contents)
+}
+
+func TestReadFilesTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "work/big.c", Typeflag: tar.TypeReg, Size: MaxFileSize + 1, Mode: 0o644}))
+	_, err := tw.Write(make([]byte, MaxFileSize+1))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+
+	_, err = ReadFiles(bytes.NewReader(buf.Bytes()), "/root/work", []string{"/root/work/big.c"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package configs
+
+// docker:
+//
+//	nodes:
+//	  - name: local
+//	    host:
+//	    max_containers: 0
+//	    max_cpus: 0
+//	    max_memory: 0
+//	    labels:
+func setDockerConfig(appConfig *AppConfig) {
+	appConfig.Docker.Nodes = []DockerNodeConfig{
+		{
+			Name: "local",
+		},
+	}
+}
@@ -14,6 +14,9 @@ func init() {
 	defaultConfigFuncList = append(defaultConfigFuncList, setRedisConfig)
 	defaultConfigFuncList = append(defaultConfigFuncList, setJWTConfig)
 	defaultConfigFuncList = append(defaultConfigFuncList, setLogConfig)
+	defaultConfigFuncList = append(defaultConfigFuncList, setSecretConfig)
+	defaultConfigFuncList = append(defaultConfigFuncList, setQuotaConfig)
+	defaultConfigFuncList = append(defaultConfigFuncList, setDockerConfig)
 }
 
 // AppConfig
@@ -61,6 +64,36 @@ type AppConfig struct {
 		Path     string `mapstructure:"path"`
 		Filename string `mapstructure:"filename"`
 	} `mapstructure:"log"`
+
+	Secret struct {
+		Key string `mapstructure:"key"` // 用于加密敏感字段（如SUDO密码）的密钥
+	} `mapstructure:"secret"`
+
+	// 容器配额，数值为 0 表示不限制
+	Quota struct {
+		MaxContainersPerUser   int     `mapstructure:"max_containers_per_user"`   // 每个用户同时运行的容器数
+		MaxContainersPerCourse int     `mapstructure:"max_containers_per_course"` // 每门课程同时运行的容器数
+		MaxContainers          int     `mapstructure:"max_containers"`            // 全局同时运行的容器数
+		MaxCPUs                float64 `mapstructure:"max_cpus"`                  // 全局CPU核数上限
+		MaxMemory              int64   `mapstructure:"max_memory"`                // 全局内存上限（MB）
+		QueueWhenFull          bool    `mapstructure:"queue_when_full"`           // 全局资源不足时排队等待而不是直接失败
+		QueueRetryInterval     string  `mapstructure:"queue_retry_interval"`      // 排队时重试的间隔
+		QueueMaxRetry          int     `mapstructure:"queue_max_retry"`           // 排队时最多重试次数
+	} `mapstructure:"quota"`
+
+	Docker struct {
+		Nodes []DockerNodeConfig `mapstructure:"nodes"` // 可调度的Docker节点
+	} `mapstructure:"docker"`
+}
+
+// DockerNodeConfig Docker节点配置
+type DockerNodeConfig struct {
+	Name          string  `mapstructure:"name"`           // 节点名称，唯一
+	Host          string  `mapstructure:"host"`           // Docker daemon地址，例如 tcp://10.0.0.2:2375，为空时使用环境变量
+	MaxContainers int     `mapstructure:"max_containers"` // 节点最多运行的容器数，0表示不限制
+	MaxCPUs       float64 `mapstructure:"max_cpus"`       // 节点上容器CPU限制之和的上限，0表示不限制
+	MaxMemory     int64   `mapstructure:"max_memory"`     // 节点上容器内存限制之和的上限（MB），0表示不限制
+	Labels        string  `mapstructure:"labels"`         // 节点标签，格式: key=value;key=value;
 }
 
 var (
@@ -0,0 +1,22 @@
+package configs
+
+// quota:
+//
+//	max_containers_per_user: 2
+//	max_containers_per_course: 0
+//	max_containers: 0
+//	max_cpus: 0
+//	max_memory: 0
+//	queue_when_full: false
+//	queue_retry_interval: 30s
+//	queue_max_retry: 20
+func setQuotaConfig(appConfig *AppConfig) {
+	appConfig.Quota.MaxContainersPerUser = 2
+	appConfig.Quota.MaxContainersPerCourse = 0
+	appConfig.Quota.MaxContainers = 0
+	appConfig.Quota.MaxCPUs = 0
+	appConfig.Quota.MaxMemory = 0
+	appConfig.Quota.QueueWhenFull = false
+	appConfig.Quota.QueueRetryInterval = "30s"
+	appConfig.Quota.QueueMaxRetry = 20
+}
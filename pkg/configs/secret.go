@@ -0,0 +1,11 @@
+package configs
+
+// DefaultSecretKey 默认的加密密钥，只能在开发环境中使用
+const DefaultSecretKey = "ttds-secret"
+
+// secret:
+//
+//	key: ttds-secret
+func setSecretConfig(appConfig *AppConfig) {
+	appConfig.Secret.Key = DefaultSecretKey
+}
@@ -1,6 +1,35 @@
 package container
 
-import "awesomeProject/internal/model"
+import (
+	"awesomeProject/internal/model"
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrExecTimeout 脚本执行超时
+var ErrExecTimeout = errors.New("execution timeout")
+
+// ExecResult 脚本执行结果
+type ExecResult struct {
+	ExitCode int           // 退出码，超时时为 -1
+	Output   string        // 标准输出和标准错误的合并内容
+	Duration time.Duration // 执行耗时
+}
+
+// LogOptions 获取容器日志的参数
+type LogOptions struct {
+	Tail   string // 从最后多少行开始输出，all 表示全部
+	Since  string // 只输出该时间之后的日志，支持时间戳或相对时间（例如 10m）
+	Follow bool   // 是否持续跟踪新的日志
+}
+
+// LogLine 容器日志中的一行
+type LogLine struct {
+	Stream string `json:"stream"` // stdout / stderr
+	Line   string `json:"line"`
+}
 
 type Manager interface {
 	CreateContainer(template *model.ContainerTemplate) (*model.ContainerInstance, error)
@@ -8,9 +37,15 @@ type Manager interface {
 	StopContainer(instance *model.ContainerInstance) error
 	RemoveContainer(instance *model.ContainerInstance) error
 	Exists(containerName string) (bool, error)
-	ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (bool, error)
+	ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (*ExecResult, error)
+	FollowLogs(ctx context.Context, instance *model.ContainerInstance, options LogOptions) (<-chan LogLine, error)
+	CreateGraderContainer(instance *model.ContainerInstance, template *model.ContainerTemplate) (*model.ContainerInstance, error)
+	ReadFile(instance *model.ContainerInstance, path string) ([]byte, error)
+	ArchivePath(instance *model.ContainerInstance, path string) (io.ReadCloser, error)
+	RestorePath(instance *model.ContainerInstance, path string, archive io.Reader) error
 }
 
+// NewManager 返回多节点调度器，只配置一个节点时等同于直接使用该节点
 func NewManager() Manager {
-	return newDockerEngine()
+	return newScheduler()
 }
@@ -1,23 +1,56 @@
 package container
 
 import (
+	"archive/tar"
 	"awesomeProject/internal/model"
+	"awesomeProject/pkg/secret"
+	"bufio"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/sirupsen/logrus"
+	"io"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// 平台创建的容器都带有该标签，用于统计节点上运行的容器
+const managedLabel = "ttds.managed"
+
+// 容器的CPU和内存限制记录在标签中，用于统计节点上已分配的资源
+const (
+	cpusLabel   = "ttds.cpus"
+	memoryLabel = "ttds.memory"
+)
+
+// NodeUsage 节点上由平台创建、还没有启动或正在运行的容器及其资源限制之和
+type NodeUsage struct {
+	Containers int
+	CPUs       float64
+	Memory     int64 // MB
+}
+
+// containerLabels 返回平台创建的容器的标签，包括模板的资源限制
+func containerLabels(template *model.ContainerTemplate) map[string]string {
+	return map[string]string{
+		managedLabel: "true",
+		cpusLabel:    strconv.FormatFloat(template.CPULimit, 'f', -1, 64),
+		memoryLabel:  strconv.FormatInt(template.MemoryLimit, 10),
+	}
+}
+
 // 设置Go Docker Client变量
 var cli *client.Client
 var _ Manager = (*DockerEngine)(nil)
@@ -36,6 +69,17 @@ func newDockerEngine() *DockerEngine {
 	}
 }
 
+// newDockerEngineWithHost 连接指定地址的Docker daemon
+func newDockerEngineWithHost(host string) (*DockerEngine, error) {
+	hostCli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client for %s: %v", host, err)
+	}
+	return &DockerEngine{
+		cli: hostCli,
+	}, nil
+}
+
 type DockerEngine struct {
 	cli *client.Client
 }
@@ -43,8 +87,9 @@ type DockerEngine struct {
 func (d *DockerEngine) CreateContainer(template *model.ContainerTemplate) (*model.ContainerInstance, error) {
 	// 创建容器配置
 	config := &container.Config{
-		Image: template.Image,
-		Env:   []string{},
+		Image:  template.Image,
+		Env:    []string{},
+		Labels: containerLabels(template),
 	}
 
 	// 创建主机配置
@@ -64,8 +109,18 @@ func (d *DockerEngine) CreateContainer(template *model.ContainerTemplate) (*mode
 		}
 	}
 
-	// 设置固定的sudo密码
-	config.Env = append(config.Env, "SUDO_PASSWORD=123456")
+	// 设置sudo密码：模板配置了密码则使用模板密码，否则为每个实例随机生成
+	sudoPass, err := resolveSudoPassword(template)
+	if err != nil {
+		return nil, err
+	}
+	config.Env = append(config.Env, "SUDO_PASSWORD="+sudoPass)
+
+	// 实例中只保存加密后的密码
+	encryptedSudoPass, err := secret.NewManager().Encrypt(sudoPass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt sudo password: %v", err)
+	}
 
 	// 生成随机token CONNECTION_TOKEN
 	token := generateRandomToken()
@@ -120,6 +175,33 @@ func (d *DockerEngine) CreateContainer(template *model.ContainerTemplate) (*mode
 		}
 	}
 
+	// isolated 模式下学生工作目录保存在独立的数据卷中，检测时只读挂载到检测容器
+	var workspace string
+	if template.GradeMode == model.GradeIsolated && template.WorkspacePath != "" {
+		workspace = fmt.Sprintf("%s-%s-workspace", template.Name, generateRandomString(8))
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: workspace,
+			Target: template.WorkspacePath,
+			VolumeOptions: &mount.VolumeOptions{
+				Labels: map[string]string{managedLabel: "true"},
+			},
+		})
+	}
+
+	// 设置资源限制
+	if template.CPULimit > 0 {
+		hostConfig.Resources.NanoCPUs = int64(template.CPULimit * 1e9)
+	}
+	if template.MemoryLimit > 0 {
+		hostConfig.Resources.Memory = template.MemoryLimit * 1024 * 1024
+	}
+
+	// 应用模板的安全配置
+	if err := applySecurityProfile(hostConfig, template.Profile); err != nil {
+		return nil, err
+	}
+
 	// 生成随机容器名称
 	containerName := fmt.Sprintf("%s-%s", template.Name, generateRandomString(8))
 
@@ -162,11 +244,136 @@ func (d *DockerEngine) CreateContainer(template *model.ContainerTemplate) (*mode
 		StartAt:     time.Now(),
 		Token:       token,
 		IPAddress:   ipAddress,
+		SUDOPass:    encryptedSudoPass,
+		Workspace:   workspace,
 	}
 
 	return instance, nil
 }
 
+// CreateGraderContainer 为检测创建并启动一个临时的检测容器。
+// 检测容器使用模板中的可信镜像，只读挂载学生的工作目录，并共享学生容器的网络以便检测学生启动的服务
+func (d *DockerEngine) CreateGraderContainer(instance *model.ContainerInstance, template *model.ContainerTemplate) (*model.ContainerInstance, error) {
+	if template.GraderImage == "" || template.WorkspacePath == "" {
+		return nil, fmt.Errorf("template %s has no grader image or workspace path", template.Name)
+	}
+	if instance.Workspace == "" {
+		return nil, fmt.Errorf("container %s has no workspace volume, recreate it to use isolated grading", instance.Name)
+	}
+
+	config := &container.Config{
+		Image: template.GraderImage,
+		// 保持运行，检测脚本通过 exec 执行
+		Entrypoint: []string{"/bin/sh", "-c"},
+		Cmd:        []string{"trap 'exit 0' TERM; while true; do sleep 3600 & wait; done"},
+		Labels:     containerLabels(template),
+	}
+
+	hostConfig := &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + instance.ContainerID),
+		Mounts: []mount.Mount{
+			{
+				Type:     mount.TypeVolume,
+				Source:   instance.Workspace,
+				Target:   template.WorkspacePath,
+				ReadOnly: true,
+			},
+		},
+		SecurityOpt: []string{"no-new-privileges:true"},
+	}
+	if template.CPULimit > 0 {
+		hostConfig.Resources.NanoCPUs = int64(template.CPULimit * 1e9)
+	}
+	if template.MemoryLimit > 0 {
+		hostConfig.Resources.Memory = template.MemoryLimit * 1024 * 1024
+	}
+
+	containerName := fmt.Sprintf("%s-grader-%s", instance.Name, generateRandomString(8))
+	resp, err := d.cli.ContainerCreate(
+		context.Background(),
+		config,
+		hostConfig,
+		&network.NetworkingConfig{},
+		nil,
+		containerName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grader container: %v", err)
+	}
+
+	grader := &model.ContainerInstance{
+		UserID:      instance.UserID,
+		TemplateID:  instance.TemplateID,
+		ContainerID: resp.ID,
+		Name:        containerName,
+		Status:      "Pending",
+		Node:        instance.Node,
+	}
+
+	if err := d.StartContainer(grader); err != nil {
+		if removeErr := d.RemoveContainer(grader); removeErr != nil {
+			logrus.Warnf("failed to remove grader container %s: %v", containerName, removeErr)
+		}
+		return nil, err
+	}
+
+	return grader, nil
+}
+
+// 获取容器的sudo密码，模板中的密码是加密存储的
+func resolveSudoPassword(template *model.ContainerTemplate) (string, error) {
+	if template.SUDOPass == "" {
+		return secret.GeneratePassword()
+	}
+	sudoPass, err := secret.NewManager().Decrypt(template.SUDOPass)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt template sudo password: %v", err)
+	}
+	return sudoPass, nil
+}
+
+// 将安全配置转换为Docker的主机配置，profile为空时使用Docker默认配置
+func applySecurityProfile(hostConfig *container.HostConfig, profile *model.SecurityProfile) error {
+	if profile == nil {
+		return nil
+	}
+
+	hostConfig.CapAdd = splitList(profile.CapAdd)
+	hostConfig.CapDrop = splitList(profile.CapDrop)
+	hostConfig.ReadonlyRootfs = profile.ReadOnlyRootfs
+
+	if profile.SeccompProfile != "" {
+		seccomp := strings.TrimSpace(profile.SeccompProfile)
+		if seccomp != "unconfined" && !json.Valid([]byte(seccomp)) {
+			return fmt.Errorf("invalid seccomp profile in security profile %s", profile.Name)
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+seccomp)
+	}
+	if profile.AppArmorProfile != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "apparmor="+profile.AppArmorProfile)
+	}
+	if profile.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+	if profile.UsernsMode != "" {
+		hostConfig.UsernsMode = container.UsernsMode(profile.UsernsMode)
+	}
+
+	return nil
+}
+
+// 解析 a;b;c; 格式的列表
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // 生成随机字符串
 func generateRandomString(length int) string {
 	bytes := make([]byte, length/2)
@@ -261,6 +468,13 @@ func (d *DockerEngine) RemoveContainer(instance *model.ContainerInstance) error
 		return fmt.Errorf("failed to remove container: %v", err)
 	}
 
+	// 具名数据卷不会随容器删除，需要单独移除
+	if instance.Workspace != "" {
+		if err := d.cli.VolumeRemove(context.Background(), instance.Workspace, true); err != nil {
+			logrus.Warnf("failed to remove workspace volume %s: %v", instance.Workspace, err)
+		}
+	}
+
 	// 更新容器状态
 	instance.Status = "Removed"
 	instance.EndAt = time.Now()
@@ -268,6 +482,33 @@ func (d *DockerEngine) RemoveContainer(instance *model.ContainerInstance) error
 	return nil
 }
 
+// Usage 统计节点上由平台创建、还没有启动或正在运行的容器数量和资源限制之和，没有资源标签的容器不计入资源。
+// 还没有启动的容器即将启动，同样占用节点的资源
+func (d *DockerEngine) Usage() (*NodeUsage, error) {
+	filter := filters.NewArgs(
+		filters.KeyValuePair{Key: "label", Value: managedLabel + "=true"},
+		filters.KeyValuePair{Key: "status", Value: "created"},
+		filters.KeyValuePair{Key: "status", Value: "running"},
+	)
+
+	containers, err := d.cli.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %v", err)
+	}
+
+	usage := &NodeUsage{Containers: len(containers)}
+	for _, c := range containers {
+		cpus, _ := strconv.ParseFloat(c.Labels[cpusLabel], 64)
+		memory, _ := strconv.ParseInt(c.Labels[memoryLabel], 10, 64)
+		usage.CPUs += cpus
+		usage.Memory += memory
+	}
+	return usage, nil
+}
+
 func (d *DockerEngine) Exists(containerName string) (bool, error) {
 	// 设置过滤器
 	filter := filters.NewArgs(filters.KeyValuePair{Key: "name", Value: containerName})
@@ -286,51 +527,185 @@ func (d *DockerEngine) Exists(containerName string) (bool, error) {
 	return len(containers) > 0, nil
 }
 
-func (d *DockerEngine) ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (bool, error) {
-	// 创建执行配置
+func (d *DockerEngine) ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (*ExecResult, error) {
+	// 创建执行配置，收集标准输出和标准错误
 	execConfig := container.ExecOptions{
-		Cmd: []string{"/bin/sh", "-c", script.Content},
+		Cmd:          []string{"/bin/sh", "-c", script.Content},
+		AttachStdout: true,
+		AttachStderr: true,
 	}
 
 	// 创建执行实例
 	execID, err := d.cli.ContainerExecCreate(context.Background(), instance.ContainerID, execConfig)
 	if err != nil {
-		return false, fmt.Errorf("failed to create exec instance: %v", err)
+		return nil, fmt.Errorf("failed to create exec instance: %v", err)
 	}
 
-	// 启动执行实例
-	err = d.cli.ContainerExecStart(context.Background(), execID.ID, container.ExecStartOptions{
-		Detach: true,
-	})
+	// 附加到执行实例，同时会启动执行
+	start := time.Now()
+	resp, err := d.cli.ContainerExecAttach(context.Background(), execID.ID, container.ExecAttachOptions{})
 	if err != nil {
-		return false, fmt.Errorf("failed to start exec instance: %v", err)
+		return nil, fmt.Errorf("failed to start exec instance: %v", err)
 	}
+	defer resp.Close()
+
+	output := newLimitedBuffer(maxExecOutput)
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(output, output, resp.Reader)
+		done <- err
+	}()
 
 	// 设置整体超时
 	timeout := time.Duration(script.Timeout) * time.Second
 	deadline := time.After(timeout)
 
-	tick := time.NewTicker(500 * time.Millisecond)
+	select {
+	case <-deadline:
+		return &ExecResult{ExitCode: -1, Output: output.String(), Duration: time.Since(start)},
+			fmt.Errorf("%w after %s", ErrExecTimeout, timeout)
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exec output: %v", err)
+		}
+	}
+
+	// 输出结束后进程可能还没有完全退出，等待获取退出码
+	tick := time.NewTicker(50 * time.Millisecond)
 	defer tick.Stop()
 
 	for {
+		inspect, err := d.cli.ContainerExecInspect(context.Background(), execID.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect exec instance: %v", err)
+		}
+
+		if !inspect.Running {
+			// 脚本执行完成
+			return &ExecResult{
+				ExitCode: inspect.ExitCode,
+				Output:   output.String(),
+				Duration: time.Since(start),
+			}, nil
+		}
+
 		select {
 		case <-deadline:
-			return false, fmt.Errorf("execution timeout after %s", timeout)
+			return &ExecResult{ExitCode: -1, Output: output.String(), Duration: time.Since(start)},
+				fmt.Errorf("%w after %s", ErrExecTimeout, timeout)
 		case <-tick.C:
-			inspect, err := d.cli.ContainerExecInspect(context.Background(), execID.ID)
-			if err != nil {
-				return false, fmt.Errorf("failed to inspect exec instance: %v", err)
-			}
+		}
+	}
+}
 
-			if !inspect.Running {
-				// 脚本执行完成
-				if inspect.ExitCode != 0 {
-					return false, fmt.Errorf("script failed with exit code %d", inspect.ExitCode)
-				}
-				return true, nil
+// 从容器中读取文件的最大长度
+const maxReadFileSize = 1024 * 1024
+
+// ReadFile 读取容器中的普通文件，超过 maxReadFileSize 时返回错误
+func (d *DockerEngine) ReadFile(instance *model.ContainerInstance, path string) ([]byte, error) {
+	reader, _, err := d.cli.CopyFromContainer(context.Background(), instance.ContainerID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s from container: %v", path, err)
+	}
+	defer reader.Close()
+
+	// 返回的内容是 tar 格式，只读取第一个文件
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from container: %v", path, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	if header.Size > maxReadFileSize {
+		return nil, fmt.Errorf("%s is too large: %d bytes", path, header.Size)
+	}
+
+	return io.ReadAll(tr)
+}
+
+// ArchivePath 以 tar 格式读取容器中的目录，条目名称以目录的最后一级开头，调用方负责关闭
+func (d *DockerEngine) ArchivePath(instance *model.ContainerInstance, path string) (io.ReadCloser, error) {
+	reader, _, err := d.cli.CopyFromContainer(context.Background(), instance.ContainerID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s from container: %v", path, err)
+	}
+	return reader, nil
+}
+
+// RestorePath 将 ArchivePath 读取的目录写回容器中的同一位置，已存在的文件被覆盖
+func (d *DockerEngine) RestorePath(instance *model.ContainerInstance, dir string, archive io.Reader) error {
+	err := d.cli.CopyToContainer(context.Background(), instance.ContainerID, path.Dir(path.Clean(dir)), archive, container.CopyToContainerOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to container: %v", dir, err)
+	}
+	return nil
+}
+
+// FollowLogs 读取容器的标准输出和标准错误，ctx 取消后停止读取并关闭返回的通道
+func (d *DockerEngine) FollowLogs(ctx context.Context, instance *model.ContainerInstance, options LogOptions) (<-chan LogLine, error) {
+	containerInfo, err := d.cli.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %v", err)
+	}
+
+	reader, err := d.cli.ContainerLogs(ctx, instance.ContainerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     options.Follow,
+		Tail:       options.Tail,
+		Since:      options.Since,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %v", err)
+	}
+
+	lines := make(chan LogLine, 100)
+	var wg sync.WaitGroup
+
+	// 按行读取日志并发送到通道
+	scan := func(stream string, r io.ReadCloser) {
+		defer wg.Done()
+		// 提前退出时关闭读取端，避免写入端阻塞
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- LogLine{Stream: stream, Line: scanner.Text()}:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}
 
+	if containerInfo.Config != nil && containerInfo.Config.Tty {
+		// TTY 模式下输出没有多路复用，全部视为标准输出
+		wg.Add(1)
+		go scan("stdout", reader)
+	} else {
+		stdoutReader, stdoutWriter := io.Pipe()
+		stderrReader, stderrWriter := io.Pipe()
+		wg.Add(2)
+		go scan("stdout", stdoutReader)
+		go scan("stderr", stderrReader)
+
+		go func() {
+			_, err := stdcopy.StdCopy(stdoutWriter, stderrWriter, reader)
+			if err != nil && ctx.Err() == nil {
+				logrus.Warnf("failed to read container logs: %v", err)
+			}
+			stdoutWriter.Close()
+			stderrWriter.Close()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		reader.Close()
+		close(lines)
+	}()
+
+	return lines, nil
 }
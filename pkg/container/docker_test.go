@@ -2,8 +2,10 @@ package container
 
 import (
 	"awesomeProject/internal/model"
+	"context"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 // 创建测试用的容器模板
@@ -93,7 +95,7 @@ func TestDockerEngine_ExecCommand(t *testing.T) {
 	}
 
 	// 执行命令
-	err = docker.ExecCommand(instance, script)
+	_, err = docker.ExecCommand(instance, script)
 
 	// 断言
 	assert.NoError(t, err, "执行命令应该成功")
@@ -105,7 +107,7 @@ func TestDockerEngine_ExecCommand(t *testing.T) {
 	}
 
 	// 执行超时命令
-	err = docker.ExecCommand(instance, timeoutScript)
+	_, err = docker.ExecCommand(instance, timeoutScript)
 
 	// 断言应该超时
 	assert.Error(t, err, "执行超时命令应该失败")
@@ -114,3 +116,114 @@ func TestDockerEngine_ExecCommand(t *testing.T) {
 	// 清理：移除测试容器
 	defer docker.RemoveContainer(instance)
 }
+
+// 测试读取和跟踪容器日志
+func TestDockerEngine_FollowLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过集成测试")
+	}
+
+	docker := newDockerEngine()
+
+	template := createTestTemplate()
+	instance, err := docker.CreateContainer(template)
+	assert.NoError(t, err, "创建容器应该成功")
+	defer docker.RemoveContainer(instance)
+	assert.NoError(t, docker.StartContainer(instance), "启动容器应该成功")
+
+	// 写入主进程的标准输出和标准错误，内容会出现在容器日志中
+	_, err = docker.ExecCommand(instance, &model.ContainerScript{
+		Content: "echo hello-logs > /proc/1/fd/1; echo oops-logs > /proc/1/fd/2",
+		Timeout: 10,
+	})
+	assert.NoError(t, err, "执行命令应该成功")
+
+	// 不跟踪时读完已有日志后关闭通道
+	lines, err := docker.FollowLogs(context.Background(), instance, LogOptions{Tail: "all"})
+	assert.NoError(t, err, "读取日志应该成功")
+	var texts []string
+	for line := range lines {
+		texts = append(texts, line.Line)
+	}
+	assert.Contains(t, texts, "hello-logs")
+	assert.Contains(t, texts, "oops-logs")
+
+	// 跟踪时收到新的日志，取消后关闭通道
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, err = docker.FollowLogs(ctx, instance, LogOptions{Tail: "0", Follow: true})
+	assert.NoError(t, err, "跟踪日志应该成功")
+	_, err = docker.ExecCommand(instance, &model.ContainerScript{Content: "echo followed-logs > /proc/1/fd/1", Timeout: 10})
+	assert.NoError(t, err, "执行命令应该成功")
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, "followed-logs", line.Line)
+	case <-time.After(10 * time.Second):
+		t.Fatal("没有收到新的日志")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-lines:
+		for ok {
+			_, ok = <-lines
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("取消后通道应该关闭")
+	}
+}
+
+// 测试检测容器只读挂载学生容器的工作目录
+func TestDockerEngine_CreateGraderContainer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过集成测试")
+	}
+
+	docker := newDockerEngine()
+
+	template := createTestTemplate()
+	template.GradeMode = model.GradeIsolated
+	template.WorkspacePath = "/workspace"
+	template.GraderImage = template.Image
+	instance, err := docker.CreateContainer(template)
+	assert.NoError(t, err, "创建容器应该成功")
+	defer docker.RemoveContainer(instance)
+	assert.NotEmpty(t, instance.Workspace, "isolated 模式下应该创建工作目录数据卷")
+	assert.NoError(t, docker.StartContainer(instance), "启动容器应该成功")
+
+	_, err = docker.ExecCommand(instance, &model.ContainerScript{Content: "echo answer > /workspace/solution.txt", Timeout: 10})
+	assert.NoError(t, err, "执行命令应该成功")
+
+	grader, err := docker.CreateGraderContainer(instance, template)
+	if !assert.NoError(t, err, "创建检测容器应该成功") {
+		return
+	}
+	defer docker.RemoveContainer(grader)
+	assert.Equal(t, "Running", grader.Status, "检测容器应该已经启动")
+
+	// 检测容器能读取学生的文件
+	result, err := docker.ExecCommand(grader, &model.ContainerScript{Content: "cat /workspace/solution.txt", Timeout: 10})
+	assert.NoError(t, err, "执行命令应该成功")
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Contains(t, result.Output, "answer")
+
+	// 工作目录只读，检测脚本不能修改学生的文件
+	result, err = docker.ExecCommand(grader, &model.ContainerScript{Content: "touch /workspace/solution.txt", Timeout: 10})
+	assert.NoError(t, err, "执行命令应该成功")
+	assert.NotEqual(t, 0, result.ExitCode, "只读的工作目录不能写入")
+}
+
+// 测试模板没有配置检测镜像或学生容器没有工作目录时无法创建检测容器
+func TestDockerEngine_CreateGraderContainerInvalid(t *testing.T) {
+	docker := &DockerEngine{}
+
+	template := createTestTemplate()
+	template.GradeMode = model.GradeIsolated
+	_, err := docker.CreateGraderContainer(&model.ContainerInstance{Workspace: "volume"}, template)
+	assert.Error(t, err, "没有检测镜像时应该失败")
+
+	template.GraderImage = template.Image
+	template.WorkspacePath = "/workspace"
+	_, err = docker.CreateGraderContainer(&model.ContainerInstance{Name: "student"}, template)
+	assert.Error(t, err, "学生容器没有工作目录数据卷时应该失败")
+}
@@ -0,0 +1,313 @@
+package container
+
+import (
+	"awesomeProject/internal/model"
+	"awesomeProject/pkg/configs"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	schedulerInstance *Scheduler
+	schedulerOnce     sync.Once
+
+	_ Manager = (*Scheduler)(nil)
+
+	// ErrNoAvailableNode 没有满足条件且有剩余容量的节点
+	ErrNoAvailableNode = errors.New("no available docker node")
+)
+
+// 未配置节点时默认使用的节点名称
+const defaultNodeName = "local"
+
+// Scheduler 管理多个Docker节点
+// 新容器根据模板的节点亲和性、CPU和内存限制以及节点剩余容量选择节点，其余操作根据实例记录的节点转发
+type Scheduler struct {
+	nodes []*node
+	mu    sync.Mutex // 保护节点的 pending，选择节点和占用资源是一个原子操作
+}
+
+type node struct {
+	name          string
+	engine        *DockerEngine
+	usage         func() (*NodeUsage, error) // 读取节点上容器的资源占用，默认为 engine.Usage
+	maxContainers int
+	maxCPUs       float64
+	maxMemory     int64
+	labels        map[string]string
+	pending       NodeUsage // 已选择该节点但还在创建的容器，Docker 统计不到，选择节点时一并计入
+}
+
+func newScheduler() *Scheduler {
+	schedulerOnce.Do(func() {
+		schedulerInstance = &Scheduler{}
+
+		var nodeConfigs []configs.DockerNodeConfig
+		if cfg := configs.GetConfig(); cfg != nil {
+			nodeConfigs = cfg.Docker.Nodes
+		}
+		if len(nodeConfigs) == 0 {
+			nodeConfigs = []configs.DockerNodeConfig{{Name: defaultNodeName}}
+		}
+
+		for _, nc := range nodeConfigs {
+			engine, err := newNodeEngine(nc.Host)
+			if err != nil {
+				logrus.Errorf("failed to register docker node %s: %v", nc.Name, err)
+				continue
+			}
+			schedulerInstance.nodes = append(schedulerInstance.nodes, &node{
+				name:          nc.Name,
+				engine:        engine,
+				usage:         engine.Usage,
+				maxContainers: nc.MaxContainers,
+				maxCPUs:       nc.MaxCPUs,
+				maxMemory:     nc.MaxMemory,
+				labels:        parseLabels(nc.Labels),
+			})
+			logrus.Infof("docker node %s registered", nc.Name)
+		}
+	})
+	return schedulerInstance
+}
+
+func newNodeEngine(host string) (*DockerEngine, error) {
+	if host == "" {
+		return newDockerEngine(), nil
+	}
+	return newDockerEngineWithHost(host)
+}
+
+// 解析 key=value;key=value; 格式的标签
+func parseLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range splitList(value) {
+		parts := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		if len(parts) == 2 {
+			labels[key] = strings.TrimSpace(parts[1])
+		} else {
+			labels[key] = ""
+		}
+	}
+	return labels
+}
+
+// matches 节点是否满足模板的亲和性要求
+func (n *node) matches(affinity map[string]string) bool {
+	for key, value := range affinity {
+		if nodeValue, ok := n.labels[key]; !ok || nodeValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// fits 节点剩余的CPU和内存是否能容纳模板的资源限制
+func (n *node) fits(usage *NodeUsage, template *model.ContainerTemplate) bool {
+	if n.maxCPUs > 0 && usage.CPUs+template.CPULimit > n.maxCPUs {
+		return false
+	}
+	if n.maxMemory > 0 && usage.Memory+template.MemoryLimit > n.maxMemory {
+		return false
+	}
+	return true
+}
+
+// selectNode 在满足亲和性且资源足够的节点中选择剩余容量最多的节点
+func (s *Scheduler) selectNode(template *model.ContainerTemplate) (*node, error) {
+	affinity := parseLabels(template.NodeLabels)
+
+	var selected *node
+	selectedFree := -1
+	for _, n := range s.nodes {
+		if !n.matches(affinity) {
+			continue
+		}
+
+		usage, err := n.usage()
+		if err != nil {
+			logrus.Warnf("docker node %s is unavailable: %v", n.name, err)
+			continue
+		}
+		usage.Containers += n.pending.Containers
+		usage.CPUs += n.pending.CPUs
+		usage.Memory += n.pending.Memory
+
+		free := freeCapacity(n.maxContainers, usage.Containers)
+		if free <= 0 || !n.fits(usage, template) {
+			continue
+		}
+		if free > selectedFree {
+			selected = n
+			selectedFree = free
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("%w for template %s", ErrNoAvailableNode, template.Name)
+	}
+	return selected, nil
+}
+
+// reserveNode 选择节点并占用模板的资源，容器创建完成后调用 releaseNode 释放。
+// 并发创建的容器在 Docker 统计到之前也会计入，不会都选择同一个节点
+func (s *Scheduler) reserveNode(template *model.ContainerTemplate) (*node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.selectNode(template)
+	if err != nil {
+		return nil, err
+	}
+	n.pending.Containers++
+	n.pending.CPUs += template.CPULimit
+	n.pending.Memory += template.MemoryLimit
+	return n, nil
+}
+
+// releaseNode 释放 reserveNode 占用的资源，创建成功的容器此后由 Docker 统计
+func (s *Scheduler) releaseNode(n *node, template *model.ContainerTemplate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n.pending.Containers--
+	n.pending.CPUs -= template.CPULimit
+	n.pending.Memory -= template.MemoryLimit
+}
+
+// 不限制容量的节点视为剩余容量很大，但仍优先选择运行容器少的节点
+func freeCapacity(maxContainers, running int) int {
+	if maxContainers <= 0 {
+		return 1<<20 - running
+	}
+	return maxContainers - running
+}
+
+// engineFor 获取实例所在节点的引擎，未记录节点的历史实例使用第一个节点
+func (s *Scheduler) engineFor(instance *model.ContainerInstance) (*DockerEngine, error) {
+	if len(s.nodes) == 0 {
+		return nil, ErrNoAvailableNode
+	}
+	if instance.Node == "" {
+		return s.nodes[0].engine, nil
+	}
+	for _, n := range s.nodes {
+		if n.name == instance.Node {
+			return n.engine, nil
+		}
+	}
+	return nil, fmt.Errorf("docker node %s of container %s is not registered", instance.Node, instance.Name)
+}
+
+func (s *Scheduler) CreateContainer(template *model.ContainerTemplate) (*model.ContainerInstance, error) {
+	n, err := s.reserveNode(template)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseNode(n, template)
+
+	instance, err := n.engine.CreateContainer(template)
+	if err != nil {
+		return nil, err
+	}
+	instance.Node = n.name
+	logrus.Infof("container %s scheduled to docker node %s", instance.Name, n.name)
+
+	return instance, nil
+}
+
+func (s *Scheduler) StartContainer(instance *model.ContainerInstance) error {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return err
+	}
+	return engine.StartContainer(instance)
+}
+
+func (s *Scheduler) StopContainer(instance *model.ContainerInstance) error {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return err
+	}
+	return engine.StopContainer(instance)
+}
+
+func (s *Scheduler) RemoveContainer(instance *model.ContainerInstance) error {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return err
+	}
+	return engine.RemoveContainer(instance)
+}
+
+// Exists 在所有节点中查找容器
+func (s *Scheduler) Exists(containerName string) (bool, error) {
+	for _, n := range s.nodes {
+		exists, err := n.engine.Exists(containerName)
+		if err != nil {
+			return false, fmt.Errorf("docker node %s: %v", n.name, err)
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Scheduler) ExecCommand(instance *model.ContainerInstance, script *model.ContainerScript) (*ExecResult, error) {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	return engine.ExecCommand(instance, script)
+}
+
+func (s *Scheduler) FollowLogs(ctx context.Context, instance *model.ContainerInstance, options LogOptions) (<-chan LogLine, error) {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	return engine.FollowLogs(ctx, instance, options)
+}
+
+// CreateGraderContainer 检测容器需要挂载学生容器的数据卷，必须与学生容器在同一节点
+func (s *Scheduler) CreateGraderContainer(instance *model.ContainerInstance, template *model.ContainerTemplate) (*model.ContainerInstance, error) {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	return engine.CreateGraderContainer(instance, template)
+}
+
+func (s *Scheduler) ReadFile(instance *model.ContainerInstance, path string) ([]byte, error) {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	return engine.ReadFile(instance, path)
+}
+
+func (s *Scheduler) ArchivePath(instance *model.ContainerInstance, path string) (io.ReadCloser, error) {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	return engine.ArchivePath(instance, path)
+}
+
+func (s *Scheduler) RestorePath(instance *model.ContainerInstance, path string, archive io.Reader) error {
+	engine, err := s.engineFor(instance)
+	if err != nil {
+		return err
+	}
+	return engine.RestorePath(instance, path, archive)
+}
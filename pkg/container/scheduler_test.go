@@ -0,0 +1,107 @@
+package container
+
+import (
+	"awesomeProject/internal/model"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels := parseLabels("kvm=true; zone = a ;gpu;")
+	assert.Equal(t, map[string]string{"kvm": "true", "zone": "a", "gpu": ""}, labels)
+	assert.Empty(t, parseLabels(""))
+}
+
+func TestNode_Matches(t *testing.T) {
+	n := &node{name: "node-1", labels: parseLabels("kvm=true;zone=a")}
+
+	assert.True(t, n.matches(parseLabels("")), "没有亲和性要求时任意节点都满足")
+	assert.True(t, n.matches(parseLabels("kvm=true")))
+	assert.False(t, n.matches(parseLabels("kvm=false")))
+	assert.False(t, n.matches(parseLabels("gpu=true")))
+}
+
+func TestNode_Fits(t *testing.T) {
+	n := &node{name: "node-1", maxCPUs: 8, maxMemory: 4096}
+	template := &model.ContainerTemplate{CPULimit: 2, MemoryLimit: 1024}
+
+	assert.True(t, n.fits(&NodeUsage{CPUs: 6, Memory: 3072}, template))
+	assert.False(t, n.fits(&NodeUsage{CPUs: 7}, template), "CPU不足")
+	assert.False(t, n.fits(&NodeUsage{Memory: 3584}, template), "内存不足")
+
+	unlimited := &node{name: "node-2"}
+	assert.True(t, unlimited.fits(&NodeUsage{CPUs: 100, Memory: 1 << 20}, template), "不限制资源时总能容纳")
+}
+
+func TestContainerLabels(t *testing.T) {
+	labels := containerLabels(&model.ContainerTemplate{CPULimit: 0.5, MemoryLimit: 512})
+	assert.Equal(t, map[string]string{managedLabel: "true", cpusLabel: "0.5", memoryLabel: "512"}, labels)
+}
+
+func TestScheduler_ReserveNodeConcurrently(t *testing.T) {
+	// Docker 还没有统计到正在创建的容器，节点的资源只够运行一个容器
+	idle := func() (*NodeUsage, error) { return &NodeUsage{}, nil }
+	s := &Scheduler{nodes: []*node{
+		{name: "node-1", usage: idle, maxCPUs: 2},
+		{name: "node-2", usage: idle, maxCPUs: 2},
+	}}
+	template := &model.ContainerTemplate{Name: "lab", CPULimit: 1.5}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	placed := make(map[string]int)
+	failed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := s.reserveNode(template)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				assert.ErrorIs(t, err, ErrNoAvailableNode)
+				failed++
+				return
+			}
+			placed[n.name]++
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int{"node-1": 1, "node-2": 1}, placed, "每个节点只能放置一个容器")
+	assert.Equal(t, 8, failed)
+
+	// 释放后可以再次选择该节点
+	s.releaseNode(s.nodes[0], template)
+	n, err := s.reserveNode(template)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "node-1", n.name)
+	}
+}
+
+func TestFreeCapacity(t *testing.T) {
+	assert.Equal(t, 2, freeCapacity(5, 3))
+	assert.Equal(t, 0, freeCapacity(3, 3))
+	assert.Greater(t, freeCapacity(0, 3), freeCapacity(0, 4), "不限制容量时优先选择运行容器少的节点")
+}
+
+func TestScheduler_EngineFor(t *testing.T) {
+	first := &DockerEngine{}
+	second := &DockerEngine{}
+	s := &Scheduler{nodes: []*node{
+		{name: "node-1", engine: first},
+		{name: "node-2", engine: second},
+	}}
+
+	engine, err := s.engineFor(&model.ContainerInstance{Node: "node-2"})
+	assert.NoError(t, err)
+	assert.Same(t, second, engine)
+
+	engine, err = s.engineFor(&model.ContainerInstance{})
+	assert.NoError(t, err)
+	assert.Same(t, first, engine, "没有记录节点的实例使用第一个节点")
+
+	_, err = s.engineFor(&model.ContainerInstance{Node: "node-3"})
+	assert.Error(t, err)
+}
@@ -22,6 +22,7 @@ func InitDB(dsn string, config *gorm.Config) {
 	// 自动迁移模型
 	err = DB.AutoMigrate(
 		&model.Course{},
+		&model.CourseTeacher{},
 		&model.Chapter{},
 		&model.Section{},
 		&model.User{},
@@ -29,14 +30,51 @@ func InitDB(dsn string, config *gorm.Config) {
 		&model.CourseReference{},
 		&model.ContainerTemplate{},
 		&model.ContainerInstance{},
+		&model.WorkspaceSnapshot{},
 		&model.ContainerScript{},
+		&model.SecurityProfile{},
+		&model.Submission{},
+		&model.AttemptLock{},
+		&model.QuotaLock{},
+		&model.CheckResult{},
+		&model.SubmissionFile{},
+		&model.GradingSuite{},
+		&model.RegradeJob{},
+		&model.RegradeResult{},
+		&model.SimilarityReport{},
+		&model.SimilarityPair{},
+		&model.SimilarityMatch{},
+		&model.Hint{},
+		&model.HintUsage{},
+		&model.QuizQuestion{},
+		&model.QuizOption{},
+		&model.QuizAttempt{},
+		&model.QuizAnswer{},
 	)
 	if err != nil {
 		logrus.Fatalf("failed to migrate database: %v", err)
 	}
+	migrateParamSeeds()
 	logrus.Info("database migrated successfully")
 }
 
+// migrateParamSeeds 为增加 param_seed 列之前创建的小节补充随机的实验参数种子
+func migrateParamSeeds() {
+	var sections []model.Section
+	if err := DB.Select("id").Where("param_seed IS NULL OR param_seed = ''").Find(&sections).Error; err != nil {
+		logrus.Fatalf("failed to migrate parameter seeds: %v", err)
+	}
+	for _, section := range sections {
+		seed, err := model.NewParamSeed()
+		if err == nil {
+			err = DB.Model(&model.Section{}).Where("id = ?", section.ID).Update("param_seed", seed).Error
+		}
+		if err != nil {
+			logrus.Fatalf("failed to migrate parameter seeds: %v", err)
+		}
+	}
+}
+
 func GenerateDsnFromConfig() string {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		configs.GetConfig().DB.Username,
@@ -27,29 +27,31 @@ func NewChannelManager() *ChannelManager {
 
 func (c *ChannelManager) CreateChannel(name string) (chan string, error) {
 	c.mu.Lock()
+	defer c.mu.Unlock()
 	if _, exists := c.channels[name]; exists {
 		return nil, fmt.Errorf("channel %s already exists", name)
 	}
 	ch := make(chan string, 100) // 设置合理的缓冲区大小
 	c.channels[name] = ch
-	c.mu.Unlock()
 	return ch, nil
 }
 
 func (c *ChannelManager) RemoveChannel(name string) error {
 	c.mu.Lock()
+	defer c.mu.Unlock()
 	ch, exists := c.channels[name]
 	if !exists {
 		return fmt.Errorf("channel %s not found", name)
 	}
 	close(ch)
 	delete(c.channels, name)
-	c.mu.Unlock()
 	return nil
 }
 
 func (c *ChannelManager) SendMessage(channel string, message string) error {
+	c.mu.RLock()
 	ch, exists := c.channels[channel]
+	c.mu.RUnlock()
 	if !exists {
 		return fmt.Errorf("channel %s not found", channel)
 	}
@@ -58,7 +60,9 @@ func (c *ChannelManager) SendMessage(channel string, message string) error {
 }
 
 func (c *ChannelManager) GetChannel(channel string) (chan string, error) {
+	c.mu.RLock()
 	ch, exists := c.channels[channel]
+	c.mu.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("channel %s not found", channel)
 	}
@@ -0,0 +1,38 @@
+package message
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Event 以 SSE 事件推送的消息，序列化为 JSON 后通过通道传递，接收方用 ParseEvent 还原事件名、ID 和数据
+type Event struct {
+	ID   uint64          `json:"id"`
+	Name string          `json:"event"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewEvent 构造事件消息，data 序列化为 JSON
+func NewEvent(id uint64, name string, data any) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(Event{ID: id, Name: name, Data: raw})
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// ParseEvent 解析事件消息，不是事件的普通消息返回 false
+func ParseEvent(message string) (*Event, bool) {
+	if !strings.HasPrefix(message, "{") {
+		return nil, false
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(message), &event); err != nil || event.Name == "" {
+		return nil, false
+	}
+	return &event, true
+}
@@ -77,6 +77,10 @@ func (oss *MinioClient) UploadObject(objectName, filePath string) error {
 	return nil
 }
 
+func (oss *MinioClient) DownloadObject(objectName, filePath string) error {
+	return oss.Client.FGetObject(oss.Ctx, oss.Bucket, objectName, filePath, minio.GetObjectOptions{})
+}
+
 func (oss *MinioClient) GetObjectUrl(objectName string, expireSeconds int64) (string, error) {
 	// 返回
 	reqParams := make(url.Values)
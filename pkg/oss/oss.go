@@ -3,5 +3,6 @@ package oss
 type Manager interface {
 	ListObjects() error
 	UploadObject(objectName, filePath string) error
+	DownloadObject(objectName, filePath string) error
 	GetObjectUrl(objectName string, expireSeconds int64) (string, error)
 }
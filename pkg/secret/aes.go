@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var _ Manager = (*aesManager)(nil)
+
+// aesManager 使用 AES-256-GCM 加密，密文格式为 base64(nonce + ciphertext)
+type aesManager struct {
+	aead cipher.AEAD
+}
+
+func newAESManager(key string) *aesManager {
+	// 对配置的密钥做一次 sha256，保证得到 32 字节的 AES-256 密钥
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		// 32 字节的密钥不会出错
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &aesManager{aead: aead}
+}
+
+// Encrypt 加密明文，空字符串原样返回
+func (m *aesManager) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := m.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文，空字符串原样返回
+func (m *aesManager) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %v", err)
+	}
+
+	nonceSize := m.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("invalid ciphertext: too short")
+	}
+
+	plaintext, err := m.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %v", err)
+	}
+	return string(plaintext), nil
+}
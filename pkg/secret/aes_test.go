@@ -0,0 +1,63 @@
+package secret
+
+import (
+	"awesomeProject/pkg/configs"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAESManager_EncryptDecrypt(t *testing.T) {
+	manager := newAESManager("test-key")
+	password, err := GeneratePassword()
+	assert.NoError(t, err)
+	assert.Len(t, password, defaultPasswordLength)
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"普通密码", "123456"},
+		{"随机密码", password},
+		{"空字符串", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := manager.Encrypt(tt.plaintext)
+			assert.NoError(t, err)
+			if tt.plaintext != "" {
+				assert.NotEqual(t, tt.plaintext, ciphertext, "密文不应与明文相同")
+			}
+
+			plaintext, err := manager.Decrypt(ciphertext)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.plaintext, plaintext)
+		})
+	}
+}
+
+func TestAESManager_DecryptWithWrongKey(t *testing.T) {
+	ciphertext, err := newAESManager("key-a").Encrypt("123456")
+	assert.NoError(t, err)
+
+	_, err = newAESManager("key-b").Decrypt(ciphertext)
+	assert.Error(t, err, "使用错误的密钥解密应该失败")
+
+	_, err = newAESManager("key-a").Decrypt("not-base64!")
+	assert.Error(t, err, "非法密文应该解密失败")
+}
+
+func TestCheckKey(t *testing.T) {
+	cfg := &configs.AppConfig{Env: "dev"}
+	cfg.Secret.Key = configs.DefaultSecretKey
+	assert.NoError(t, CheckKey(cfg), "开发环境允许使用默认密钥")
+
+	cfg.Env = "prod"
+	assert.True(t, errors.Is(CheckKey(cfg), ErrDefaultKey))
+	cfg.Secret.Key = ""
+	assert.True(t, errors.Is(CheckKey(cfg), ErrDefaultKey))
+
+	cfg.Secret.Key = "a-real-secret"
+	assert.NoError(t, CheckKey(cfg))
+}
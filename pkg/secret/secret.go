@@ -0,0 +1,65 @@
+package secret
+
+import (
+	"awesomeProject/pkg/configs"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+var (
+	managerInstance Manager
+	once            sync.Once
+)
+
+// 默认生成的密码长度
+const defaultPasswordLength = 16
+
+// ErrDefaultKey 非开发环境使用了默认密钥，加密的字段可以被任何人解密
+var ErrDefaultKey = errors.New("secret key is not configured or is the default key")
+
+// Manager 负责敏感字段的加解密
+type Manager interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+func NewManager() Manager {
+	once.Do(func() {
+		key := configs.DefaultSecretKey
+		if cfg := configs.GetConfig(); cfg != nil && cfg.Secret.Key != "" {
+			key = cfg.Secret.Key
+		}
+		if key == configs.DefaultSecretKey {
+			logrus.Warn("secret key is not configured, using default key")
+		}
+		managerInstance = newAESManager(key)
+	})
+	return managerInstance
+}
+
+// CheckKey 检查加密密钥，开发环境之外不允许使用默认密钥，服务启动和命令行加密前调用
+func CheckKey(cfg *configs.AppConfig) error {
+	if cfg == nil || cfg.Env == "dev" {
+		return nil
+	}
+	if cfg.Secret.Key == "" || cfg.Secret.Key == configs.DefaultSecretKey {
+		return fmt.Errorf("%w, set secret.key in %s environment", ErrDefaultKey, cfg.Env)
+	}
+	return nil
+}
+
+// GeneratePassword 生成随机密码，系统随机数不可用时返回错误，不使用可以被猜到的替代值
+func GeneratePassword() (string, error) {
+	const charset = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	b := make([]byte, defaultPasswordLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random password: %v", err)
+	}
+	for i := range b {
+		b[i] = charset[int(b[i])%len(charset)]
+	}
+	return string(b), nil
+}
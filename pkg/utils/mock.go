@@ -25,6 +25,7 @@ func InitDBData() {
 		Username: "admin",
 		Email:    "admin@example.com",
 		Password: string(password),
+		Role:     model.RoleAdmin,
 	}
 
 	if err := db.DB.Create(&admin).Error; err != nil {